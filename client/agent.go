@@ -0,0 +1,201 @@
+package client
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/bisohns/saido/driver"
+	"github.com/bisohns/saido/inspector"
+)
+
+// agentResult : cached inspector output
+type agentResult struct {
+	result driver.AgentResult
+	status int
+}
+
+// AgentServer : serves the inspectors of the local host to saido
+// instances connecting with `type: agent`
+type AgentServer struct {
+	// Token every request must carry as bearer token
+	Token string
+	// Version reported on /info
+	Version string
+	// CacheTTL is how long results are served before inspectors are run again
+	CacheTTL time.Duration
+	// Metrics allowed to be served, all metrics when empty
+	Metrics []string
+	// AllowCustom allows custom commands sent by the server to be run
+	AllowCustom bool
+	mu          sync.Mutex
+	driver      driver.Driver
+	cache       map[string]agentResult
+	mux         *http.ServeMux
+}
+
+// NewAgentServer : initialize agent serving inspectors through the local driver
+func NewAgentServer(token string, version string) *AgentServer {
+	agent := &AgentServer{
+		Token:    token,
+		Version:  version,
+		CacheTTL: 5 * time.Second,
+		driver:   &driver.Local{},
+		cache:    make(map[string]agentResult),
+		mux:      http.NewServeMux(),
+	}
+	agent.mux.HandleFunc("/info", agent.info)
+	agent.mux.HandleFunc("/metrics/", agent.metric)
+	return agent
+}
+
+func writeAgentJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+func (agent *AgentServer) authorized(req *http.Request) bool {
+	token := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+	return agent.Token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(agent.Token)) == 1
+}
+
+func (agent *AgentServer) info(w http.ResponseWriter, req *http.Request) {
+	details, err := agent.driver.GetDetails()
+	if err != nil {
+		writeAgentJSON(w, http.StatusInternalServerError, driver.AgentResult{Error: err.Error()})
+		return
+	}
+	hostname, _ := os.Hostname()
+	writeAgentJSON(w, http.StatusOK, driver.AgentInfo{
+		Version:  agent.Version,
+		Hostname: hostname,
+		Platform: details,
+	})
+}
+
+func (agent *AgentServer) allowed(name string) (bool, string) {
+	if !inspector.Valid(name) {
+		return false, fmt.Sprintf("%s is not a valid metric", name)
+	}
+	if strings.HasPrefix(name, inspector.CustomCommand) && !agent.AllowCustom {
+		return false, "Custom metrics are not allowed on this agent"
+	}
+	if len(agent.Metrics) > 0 && !strings.HasPrefix(name, inspector.CustomCommand) {
+		for _, metric := range agent.Metrics {
+			if metric == name {
+				return true, ""
+			}
+		}
+		return false, fmt.Sprintf("%s is not served by this agent", name)
+	}
+	return true, ""
+}
+
+func (agent *AgentServer) metric(w http.ResponseWriter, req *http.Request) {
+	name := strings.TrimPrefix(req.URL.Path, "/metrics/")
+	custom := req.URL.Query().Get("custom")
+	if ok, reason := agent.allowed(name); !ok {
+		writeAgentJSON(w, http.StatusForbidden, driver.AgentResult{Name: name, Error: reason})
+		return
+	}
+	key := name + "\x00" + custom
+	agent.mu.Lock()
+	cached, ok := agent.cache[key]
+	agent.mu.Unlock()
+	if ok && time.Since(cached.result.CollectedAt) < agent.CacheTTL {
+		writeAgentJSON(w, cached.status, cached.result)
+		return
+	}
+	cached = agent.collect(name, custom)
+	agent.mu.Lock()
+	agent.cache[key] = cached
+	agent.mu.Unlock()
+	writeAgentJSON(w, cached.status, cached.result)
+}
+
+// collect : run inspector on the local driver
+func (agent *AgentServer) collect(name string, custom string) agentResult {
+	result := driver.AgentResult{
+		Name:        name,
+		CollectedAt: time.Now(),
+	}
+	initialized, err := inspector.Init(name, &agent.driver, custom)
+	if err != nil {
+		result.Error = err.Error()
+		return agentResult{result: result, status: http.StatusBadRequest}
+	}
+	collected, err := inspector.Collect(``, name, initialized)
+	var parseErr *inspector.ParseError
+	if err != nil && !(errors.As(err, &parseErr) && collected != nil) {
+		log.Debugf("Could not collect %s: %s", name, err)
+		result.Error = err.Error()
+		return agentResult{result: result, status: http.StatusInternalServerError}
+	}
+	if err != nil {
+		log.Debugf("Could not fully parse %s: %s", name, err)
+		result.Error = err.Error()
+	}
+	data, err := json.Marshal(collected.Values)
+	if err != nil {
+		result.Error = err.Error()
+		return agentResult{result: result, status: http.StatusInternalServerError}
+	}
+	result.Data = data
+	result.Units = collected.Units
+	result.CollectedAt = collected.Time
+	return agentResult{result: result, status: http.StatusOK}
+}
+
+func (agent *AgentServer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		writeAgentJSON(w, http.StatusMethodNotAllowed, driver.AgentResult{Error: "Method not allowed"})
+		return
+	}
+	if !agent.authorized(req) {
+		writeAgentJSON(w, http.StatusUnauthorized, driver.AgentResult{Error: "Unauthorized"})
+		return
+	}
+	agent.mux.ServeHTTP(w, req)
+}
+
+// validMetrics : check metrics given on the command line
+func (agent *AgentServer) validMetrics() error {
+	for _, metric := range agent.Metrics {
+		if !inspector.Valid(metric) {
+			return fmt.Errorf("%s is not a valid metric", metric)
+		}
+	}
+	return nil
+}
+
+// ListenAndServe : serve on address, over https when cert and key are set
+func (agent *AgentServer) ListenAndServe(address string, certFile string, keyFile string) error {
+	if agent.Token == "" {
+		return fmt.Errorf("Cannot start agent without a token")
+	}
+	if err := agent.validMetrics(); err != nil {
+		return err
+	}
+	// platform is detected once before handlers share the driver
+	if _, err := agent.driver.GetDetails(); err != nil {
+		return err
+	}
+	server := &http.Server{
+		Addr:              address,
+		Handler:           agent,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	if certFile != "" {
+		return server.ListenAndServeTLS(certFile, keyFile)
+	}
+	return server.ListenAndServe()
+}
@@ -0,0 +1,100 @@
+//go:build !windows
+// +build !windows
+
+package client
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/bisohns/saido/driver"
+	"github.com/bisohns/saido/inspector"
+)
+
+func newTestAgent(t *testing.T, agent *AgentServer, token string) driver.Driver {
+	server := httptest.NewServer(agent)
+	t.Cleanup(server.Close)
+	host, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+	portNum, _ := strconv.Atoi(port)
+	return &driver.Agent{Host: host, Port: portNum, Token: token}
+}
+
+func TestAgentServesMetrics(t *testing.T) {
+	agent := NewAgentServer("secret", "1.0.0")
+	d := newTestAgent(t, agent, "secret")
+	details, err := d.GetDetails()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !(details.IsLinux || details.IsDarwin) {
+		t.Errorf("Expected platform of agent, found %v", details)
+	}
+	info, _ := d.(*driver.Agent).AgentInfo()
+	if info.Version != "1.0.0" {
+		t.Errorf("Expected version of agent, found %s", info.Version)
+	}
+	i, err := inspector.Init("memory", &d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := i.(*inspector.Remote); !ok {
+		t.Fatalf("Expected remote inspector on agent, found %T", i)
+	}
+	result, err := i.Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if values, ok := result.Values.(map[string]interface{}); !ok || len(values) == 0 {
+		t.Errorf("Expected memory values from agent, found %v", result.Values)
+	}
+}
+
+func TestAgentRejectsInvalidToken(t *testing.T) {
+	agent := NewAgentServer("secret", "1.0.0")
+	d := newTestAgent(t, agent, "wrong")
+	if _, err := d.GetDetails(); err == nil {
+		t.Error("Expected error with invalid token")
+	}
+	if _, err := d.(*driver.Agent).AgentMetric("memory", ""); err == nil {
+		t.Error("Expected error with invalid token")
+	}
+}
+
+func TestAgentRestrictsMetrics(t *testing.T) {
+	agent := NewAgentServer("secret", "1.0.0")
+	agent.Metrics = []string{"uptime"}
+	d := newTestAgent(t, agent, "secret").(*driver.Agent)
+	if _, err := d.AgentMetric("memory", ""); err == nil {
+		t.Error("Expected error for metric not served")
+	}
+	if _, err := d.AgentMetric("custom-date", "date"); err == nil {
+		t.Error("Expected error for custom metric without allow custom")
+	}
+}
+
+func TestAgentCachesResults(t *testing.T) {
+	agent := NewAgentServer("secret", "1.0.0")
+	agent.AllowCustom = true
+	agent.CacheTTL = time.Minute
+	get := func() driver.AgentResult {
+		req := httptest.NewRequest(http.MethodGet, "/metrics/custom-date?custom=date", nil)
+		req.Header.Set("Authorization", "Bearer secret")
+		rec := httptest.NewRecorder()
+		agent.ServeHTTP(rec, req)
+		var result driver.AgentResult
+		json.NewDecoder(rec.Body).Decode(&result)
+		return result
+	}
+	first, second := get(), get()
+	if first.Error != "" {
+		t.Fatal(first.Error)
+	}
+	if !first.CollectedAt.Equal(second.CollectedAt) || string(first.Data) != string(second.Data) {
+		t.Error("Expected second result to be served from cache")
+	}
+}
@@ -6,10 +6,11 @@ import (
 )
 
 type Client struct {
-	Socket          *websocket.Conn
-	Send            chan *SendMessage
-	Received        chan *ReceiveMessage
-	StopHostPolling chan bool
+	Socket   *websocket.Conn
+	Send     chan *SendMessage
+	Received chan *ReceiveMessage
+	// Leave : notified when the socket is closed
+	Leave chan *Client
 }
 
 // Write to websocket
@@ -36,7 +37,7 @@ func (client *Client) Read() {
 		err := client.Socket.ReadJSON(&message)
 		if err != nil {
 			log.Errorf("While reading from client: %s", err)
-			client.StopHostPolling <- true
+			client.Leave <- client
 			return
 		} else {
 			client.Received <- message
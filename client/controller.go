@@ -1,7 +1,7 @@
 package client
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
@@ -31,11 +31,23 @@ type HostsController struct {
 	Drivers map[string]*driver.Driver
 	// ReadOnlyHosts : restrict pinging every other server except these
 	ReadOnlyHosts []string
-	// ClientConnected : shows that a client is connected
-	ClientConnected bool
-	Client          chan *Client
-	Received        chan *ReceiveMessage
-	StopPolling     chan bool
+	// filterBy : host requested by the client, all hosts when empty
+	filterBy string
+	// Federation : upstream saido instances republished in hub mode
+	Federation *Federation
+	// Health : state of hosts derived from their polls
+	Health *HealthTracker
+	// clients : connected clients results of polls are sent to
+	clients map[*Client]bool
+	// latest : last message of every host and metric, sent to clients as
+	// they connect
+	latest map[string]map[string]*SendMessage
+	// polling : hosts whose poll is still running
+	polling  map[string]bool
+	Client   chan *Client
+	Received chan *ReceiveMessage
+	// Leave : clients whose socket was closed
+	Leave chan *Client
 }
 
 func (hosts *HostsController) getDriver(address string) *driver.Driver {
@@ -51,34 +63,121 @@ func (hosts *HostsController) resetDriver(host config.Host) {
 	hosts.Drivers[host.Address] = &hostDriver
 }
 
-func (hosts *HostsController) clientConnected() bool {
+func (hosts *HostsController) setReadOnlyHost(hostlist config.HostList) {
 	hosts.mu.Lock()
 	defer hosts.mu.Unlock()
-	return hosts.ClientConnected
+	hosts.ReadOnlyHosts = hostlist
 }
 
-func (hosts *HostsController) setClientConnected(connected bool) {
+func (hosts *HostsController) setFilter(filterBy string) {
+	if filterBy == "" {
+		hosts.setReadOnlyHost(hosts.Info.GetAllHostAddresses())
+	} else {
+		hosts.setReadOnlyHost([]string{filterBy})
+	}
 	hosts.mu.Lock()
 	defer hosts.mu.Unlock()
-	hosts.ClientConnected = connected
+	hosts.filterBy = filterBy
+	for client := range hosts.clients {
+		hosts.replay(client)
+	}
 }
 
-func (hosts *HostsController) setReadOnlyHost(hostlist config.HostList) {
+func (hosts *HostsController) getFilter() string {
 	hosts.mu.Lock()
 	defer hosts.mu.Unlock()
-	hosts.ReadOnlyHosts = hostlist
+	return hosts.filterBy
+}
+
+// addClient : send results of polls to client starting with the latest
+func (hosts *HostsController) addClient(client *Client) {
+	hosts.mu.Lock()
+	defer hosts.mu.Unlock()
+	hosts.clients[client] = true
+	hosts.replay(client)
+}
+
+// removeClient : stop sending to client ending its writer
+func (hosts *HostsController) removeClient(client *Client) {
+	hosts.mu.Lock()
+	defer hosts.mu.Unlock()
+	if hosts.clients[client] {
+		delete(hosts.clients, client)
+		close(client.Send)
+	}
+}
+
+// replay : send latest messages of hosts not filtered out to client,
+// hosts.mu is held
+func (hosts *HostsController) replay(client *Client) {
+	for _, address := range hosts.ReadOnlyHosts {
+		for _, message := range hosts.latest[address] {
+			deliver(client, message)
+		}
+	}
+	if hosts.Federation != nil {
+		for _, message := range hosts.Federation.Messages(hosts.filterBy) {
+			deliver(client, message)
+		}
+	}
+}
+
+// deliver : send message unless the buffer of client is full, a client not
+// keeping up misses messages rather than holding up polls
+func deliver(client *Client, message *SendMessage) {
+	select {
+	case client.Send <- message:
+	default:
+		log.Debug("Dropping message to a client not keeping up")
+	}
+}
+
+// send : keep message as the latest of host and name and send it to every
+// client unless host is filtered out
+func (hosts *HostsController) send(host config.Host, name string, message *SendMessage) {
+	hosts.mu.Lock()
+	defer hosts.mu.Unlock()
+	if hosts.latest[host.Address] == nil {
+		hosts.latest[host.Address] = make(map[string]*SendMessage)
+	}
+	hosts.latest[host.Address][name] = message
+	if !config.Contains(hosts.ReadOnlyHosts, host) {
+		return
+	}
+	for client := range hosts.clients {
+		deliver(client, message)
+	}
+}
+
+// sendFederated : republish latest messages of upstream hosts
+func (hosts *HostsController) sendFederated() {
+	messages := hosts.Federation.Messages(hosts.getFilter())
+	hosts.mu.Lock()
+	defer hosts.mu.Unlock()
+	for client := range hosts.clients {
+		for _, message := range messages {
+			deliver(client, message)
+		}
+	}
 }
 
-func (hosts *HostsController) handleError(err error, metric string, host config.Host, client *Client) {
-	var errorContent string
-	if !strings.Contains(fmt.Sprintf("%s", err), "127") {
+func (hosts *HostsController) handleError(err error, metric string, host config.Host) {
+	var (
+		errorContent string
+		parseErr     *inspector.ParseError
+	)
+	if errors.As(err, &parseErr) {
+		errorContent = fmt.Sprintf("Could not parse metric %s from driver %s with error %s", metric, host.Address, err)
+	} else if !strings.Contains(fmt.Sprintf("%s", err), "127") {
 		errorContent = fmt.Sprintf("Could not retrieve metric %s from driver %s with error %s", metric, host.Address, err)
 	} else {
 		errorContent = fmt.Sprintf("Command %s not found on driver %s", metric, host.Address)
 	}
 	log.Debug(errorContent)
 	//FIXME: what kind of errors do we especially want to reset driver for
-	if _, ok := err.(*driver.SSHConnectError); ok {
+	// errors of APIs reached through ssh e.g the docker engine are wrapped
+	var connectErr *driver.SSHConnectError
+	if errors.As(err, &connectErr) {
 		hosts.resetDriver(host)
 	}
 	message := &SendMessage{
@@ -89,67 +188,129 @@ func (hosts *HostsController) handleError(err error, metric string, host config.
 		},
 		Error: true,
 	}
-	client.Send <- message
+	hosts.send(host, metric, message)
 }
 
-func (hosts *HostsController) sendMetric(host config.Host, metrics map[string]string, client *Client) {
+func (hosts *HostsController) sendMetric(host config.Host, metrics map[string]string) {
 	var (
 		err               error
-		data              []byte
+		result            *inspector.Result
 		initializedMetric inspector.Inspector
 		platformDetails   driver.SystemDetails
+		outcome           PollOutcome
 	)
 	if hosts.getDriver(host.Address) == nil {
 		hosts.resetDriver(host)
 	}
+	// commands of a failed batch are run alone
+	if batcher, ok := (*hosts.getDriver(host.Address)).(driver.Batcher); ok {
+		end, err := batcher.StartBatch()
+		if err != nil {
+			log.Debugf("Could not batch commands of %s: %s", host.Address, err)
+		}
+		defer end()
+	}
 	for metric, custom := range metrics {
 		inspectorDriver := hosts.getDriver(host.Address)
 		platformDetails, err = (*inspectorDriver).GetDetails()
 		if err != nil {
 			log.Error(err)
-			hosts.handleError(err, metric, host, client)
+			outcome.fail(metric, err)
+			hosts.handleError(err, metric, host)
 			continue
 		}
+		// only drivers running shell commands can escalate, others e.g
+		// agents keep running inspectors themselves
+		_, shell := (*inspectorDriver).(driver.InputRunner)
+		if become := host.Connection.Become; become != nil && shell && become.Applies(metric) {
+			var privileged driver.Driver = &driver.Privileged{
+				Driver: *inspectorDriver,
+				Become: driver.Become{
+					Method:   become.Method,
+					User:     become.User,
+					Password: become.Password,
+				},
+			}
+			inspectorDriver = &privileged
+		}
 		initializedMetric, err = inspector.Init(metric, inspectorDriver, custom)
 		if err != nil {
 			log.Error(err)
-			hosts.handleError(err, metric, host, client)
+			outcome.fail(metric, err)
+			hosts.handleError(err, metric, host)
 			continue
 		}
-		data, err = initializedMetric.Execute()
-		if err == nil {
-			var unmarsh interface{}
-			json.Unmarshal(data, &unmarsh)
+		result, err = inspector.Collect(host.Address, metric, initializedMetric)
+		// values parsed before a parse error are still sent
+		var parseErr *inspector.ParseError
+		if err == nil || (errors.As(err, &parseErr) && result != nil) {
+			outcome.Collected = append(outcome.Collected, metric)
 			message := &SendMessage{
 				Message: Message{
 					Host:     host.Address,
 					Platform: platformDetails.Name,
 					Name:     metric,
-					Data:     unmarsh,
+					Data:     result.Values,
+					Units:    result.Units,
+					Time:     &result.Time,
+					Duration: result.Duration,
 				},
 				Error: false,
 			}
-			if config.Contains(hosts.ReadOnlyHosts, host) {
-				client.Send <- message
-			}
-		} else {
-			hosts.handleError(err, metric, host, client)
+			hosts.send(host, metric, message)
+		}
+		if err != nil {
+			outcome.fail(metric, err)
+			hosts.handleError(err, metric, host)
 		}
 	}
+	hosts.sendStatus(host, outcome)
 }
 
-func (hosts *HostsController) Poll(client *Client) {
+// sendStatus : record the outcome of a poll of host and send its status
+func (hosts *HostsController) sendStatus(host config.Host, outcome PollOutcome) {
+	if len(outcome.Collected) == 0 && len(outcome.Failed) == 0 {
+		return
+	}
+	status := hosts.Health.Record(host.Address, outcome, time.Now())
+	if status.Flapping {
+		log.Debugf("%s is flapping, now %s", host.Address, status.State)
+	}
+	hosts.send(host, "", &SendMessage{Status: true, Message: status})
+}
+
+// pollHost : send metrics of host unless its last poll is still running
+func (hosts *HostsController) pollHost(host config.Host) {
+	hosts.mu.Lock()
+	if hosts.polling[host.Address] {
+		hosts.mu.Unlock()
+		log.Debugf("Skipping poll of %s still polled since the last interval", host.Address)
+		return
+	}
+	hosts.polling[host.Address] = true
+	hosts.mu.Unlock()
+	defer func() {
+		hosts.mu.Lock()
+		defer hosts.mu.Unlock()
+		delete(hosts.polling, host.Address)
+	}()
+	// TODO: Decide if we want an override or a merge
+	// For now we use a merge
+	metrics := config.MergeMetrics(hosts.Info.Metrics, host.Metrics)
+	hosts.sendMetric(host, metrics)
+}
+
+// Poll : poll every host once per interval whether clients are connected
+// or not so the health of hosts stays current, clients only receive the
+// results
+func (hosts *HostsController) Poll() {
 	for {
+		inspector.ForgetHosts(hosts.Info.GetAllHostAddresses())
 		for _, host := range hosts.Info.Hosts {
-			if !hosts.clientConnected() {
-				return
-			}
-			if config.Contains(hosts.ReadOnlyHosts, host) {
-				// TODO: Decide if we want an override or a merge
-				// For now we use a merge
-				metrics := config.MergeMetrics(hosts.Info.Metrics, host.Metrics)
-				go hosts.sendMetric(host, metrics, client)
-			}
+			go hosts.pollHost(host)
+		}
+		if hosts.Federation != nil {
+			hosts.sendFederated()
 		}
 		log.Debugf("Delaying for %d seconds", hosts.Info.PollInterval)
 		time.Sleep(time.Duration(hosts.Info.PollInterval) * time.Second)
@@ -157,18 +318,18 @@ func (hosts *HostsController) Poll(client *Client) {
 }
 
 func (hosts *HostsController) Run() {
+	if hosts.Federation != nil {
+		hosts.Federation.Start()
+	}
+	go hosts.Poll()
 	for {
 		select {
 		case client := <-hosts.Client:
-			go hosts.Poll(client)
+			hosts.addClient(client)
 		case received := <-hosts.Received:
-			if received.FilterBy == "" {
-				hosts.setReadOnlyHost(hosts.Info.GetAllHostAddresses())
-			} else {
-				hosts.setReadOnlyHost([]string{received.FilterBy})
-			}
-		case poll := <-hosts.StopPolling:
-			hosts.setClientConnected(!poll)
+			hosts.setFilter(received.FilterBy)
+		case client := <-hosts.Leave:
+			hosts.removeClient(client)
 		}
 	}
 
@@ -181,13 +342,12 @@ func (hosts *HostsController) ServeHTTP(w http.ResponseWriter, req *http.Request
 		return
 	}
 	client := &Client{
-		Socket:          socket,
-		Send:            make(chan *SendMessage, messageBufferSize),
-		Received:        hosts.Received,
-		StopHostPolling: hosts.StopPolling,
+		Socket:   socket,
+		Send:     make(chan *SendMessage, messageBufferSize),
+		Received: hosts.Received,
+		Leave:    hosts.Leave,
 	}
 	hosts.Client <- client
-	hosts.StopPolling <- false
 	go client.Write()
 	client.Read()
 }
@@ -195,6 +355,18 @@ func (hosts *HostsController) ServeHTTP(w http.ResponseWriter, req *http.Request
 // NewHostsController : initialze host controller with config file
 func NewHostsController(cfg *config.Config) *HostsController {
 	dashboardInfo := config.GetDashboardInfoConfig(cfg)
+	inspector.SetDisplayUnits(dashboardInfo.Units)
+	for _, definition := range dashboardInfo.Inspectors {
+		if err := inspector.Register(definition); err != nil {
+			log.Fatal(err)
+		}
+	}
+	// metrics of plugins that could not describe themselves are invalid
+	for _, plugin := range dashboardInfo.Plugins {
+		if err := inspector.RegisterPlugin(plugin); err != nil {
+			log.Errorf("Could not register plugin %s: %s", plugin.Name, err)
+		}
+	}
 	for metric := range dashboardInfo.Metrics {
 		if !inspector.Valid(metric) {
 			log.Fatalf("%s is not a valid metric", metric)
@@ -209,13 +381,19 @@ func NewHostsController(cfg *config.Config) *HostsController {
 	}
 
 	hosts := &HostsController{
-		Info:            dashboardInfo,
-		Drivers:         make(map[string]*driver.Driver),
-		ReadOnlyHosts:   dashboardInfo.GetAllHostAddresses(),
-		Client:          make(chan *Client),
-		Received:        make(chan *ReceiveMessage),
-		StopPolling:     make(chan bool),
-		ClientConnected: true,
+		Info:          dashboardInfo,
+		Drivers:       make(map[string]*driver.Driver),
+		ReadOnlyHosts: dashboardInfo.GetAllHostAddresses(),
+		Health:        NewHealthTracker(dashboardInfo.GetAllHostAddresses()),
+		clients:       make(map[*Client]bool),
+		latest:        make(map[string]map[string]*SendMessage),
+		polling:       make(map[string]bool),
+		Client:        make(chan *Client),
+		Received:      make(chan *ReceiveMessage),
+		Leave:         make(chan *Client),
+	}
+	if len(dashboardInfo.Upstreams) > 0 {
+		hosts.Federation = NewFederation(dashboardInfo.Upstreams)
 	}
 	return hosts
 }
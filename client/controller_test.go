@@ -0,0 +1,25 @@
+package client
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/bisohns/saido/config"
+	"github.com/bisohns/saido/driver"
+)
+
+func TestHandleErrorResetsDriver(t *testing.T) {
+	host := config.Host{Address: "localhost", Connection: &config.Connection{Type: "local"}}
+	hosts := &HostsController{
+		Drivers: make(map[string]*driver.Driver),
+		clients: make(map[*Client]bool),
+		latest:  make(map[string]map[string]*SendMessage),
+	}
+	hosts.resetDriver(host)
+	connected := hosts.getDriver(host.Address)
+	// errors of the docker engine API are wrapped by net/http
+	hosts.handleError(&url.Error{Op: "Get", URL: "http://docker/containers/json", Err: &driver.SSHConnectError{}}, "containers", host)
+	if hosts.getDriver(host.Address) == connected {
+		t.Error("Expected driver to be reset after a wrapped connect error")
+	}
+}
@@ -0,0 +1,301 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/bisohns/saido/config"
+)
+
+var (
+	// federationRetry : delay before reconnecting to an upstream
+	federationRetry = 5 * time.Second
+	// UpstreamMetric : name of the metric reporting health of upstreams
+	UpstreamMetric = "upstream"
+)
+
+// UpstreamHealth : state of the subscription to an upstream
+type UpstreamHealth struct {
+	URL       string
+	Connected bool
+	// Hosts : number of hosts the upstream reported metrics for
+	Hosts       int
+	LastMessage time.Time
+	Reconnects  int
+	Error       string
+}
+
+// upstreamEnvelope : SendMessage with the message left undecoded
+type upstreamEnvelope struct {
+	Error   bool
+	Status  bool
+	Message json.RawMessage
+}
+
+// upstreamMessage : fields of Message and ErrorMessage
+type upstreamMessage struct {
+	Host     string
+	Name     string
+	Platform string
+	Data     json.RawMessage
+	Units    map[string]string
+	Time     *time.Time
+	Duration time.Duration
+	Error    string
+	Source   string
+}
+
+// Federation : subscribes to upstream saido instances and keeps the
+// latest message of every upstream host and metric to republish
+type Federation struct {
+	Upstreams []config.Upstream
+	mu        sync.Mutex
+	latest    map[string]map[string]*SendMessage
+	health    map[string]*UpstreamHealth
+	dialer    *websocket.Dialer
+}
+
+// NewFederation : initialize federation of upstreams
+func NewFederation(upstreams []config.Upstream) *Federation {
+	federation := &Federation{
+		Upstreams: upstreams,
+		latest:    make(map[string]map[string]*SendMessage),
+		health:    make(map[string]*UpstreamHealth),
+		dialer: &websocket.Dialer{
+			HandshakeTimeout: 30 * time.Second,
+		},
+	}
+	for _, upstream := range upstreams {
+		federation.latest[upstream.Name] = make(map[string]*SendMessage)
+		federation.health[upstream.Name] = &UpstreamHealth{URL: upstreamURL(upstream.URL)}
+	}
+	return federation
+}
+
+// upstreamURL : websocket URL of the metrics stream of an upstream
+func upstreamURL(address string) string {
+	parsed, err := url.Parse(address)
+	if err != nil {
+		return address
+	}
+	switch parsed.Scheme {
+	case "http", "":
+		parsed.Scheme = "ws"
+	case "https":
+		parsed.Scheme = "wss"
+	}
+	if parsed.Path == "" || parsed.Path == "/" {
+		parsed.Path = "/metrics"
+	}
+	return parsed.String()
+}
+
+// Start : subscribe to every upstream, reconnecting when disconnected
+func (f *Federation) Start() {
+	for _, upstream := range f.Upstreams {
+		go func(upstream config.Upstream) {
+			for {
+				err := f.subscribe(upstream)
+				log.Errorf("Disconnected from upstream %s: %s", upstream.Name, err)
+				f.disconnected(upstream, err)
+				time.Sleep(federationRetry)
+			}
+		}(upstream)
+	}
+}
+
+func (f *Federation) subscribe(upstream config.Upstream) error {
+	address := upstreamURL(upstream.URL)
+	socket, _, err := f.dialer.Dial(address, nil)
+	if err != nil {
+		return err
+	}
+	defer socket.Close()
+	f.mu.Lock()
+	f.health[upstream.Name].Connected = true
+	f.health[upstream.Name].Error = ""
+	f.mu.Unlock()
+	log.Infof("Subscribed to upstream %s on %s", upstream.Name, address)
+	for {
+		var envelope upstreamEnvelope
+		if err = socket.ReadJSON(&envelope); err != nil {
+			return err
+		}
+		f.receive(upstream, envelope)
+	}
+}
+
+// republish : rename host of a message coming from upstream
+func republish(upstream config.Upstream, message upstreamMessage) (string, string) {
+	source := upstream.Name
+	if message.Source != "" {
+		source = fmt.Sprintf("%s/%s", upstream.Name, message.Source)
+	}
+	if upstream.Mode == "group" {
+		return message.Host, source
+	}
+	return fmt.Sprintf("%s/%s", upstream.Name, message.Host), source
+}
+
+func (f *Federation) receive(upstream config.Upstream, envelope upstreamEnvelope) {
+	var message upstreamMessage
+	if err := json.Unmarshal(envelope.Message, &message); err != nil {
+		log.Errorf("Could not parse message from upstream %s: %s", upstream.Name, err)
+		return
+	}
+	host, source := republish(upstream, message)
+	sent := &SendMessage{Error: envelope.Error, Status: envelope.Status}
+	if envelope.Status {
+		var status HostStatus
+		if err := json.Unmarshal(envelope.Message, &status); err != nil {
+			log.Errorf("Could not parse status from upstream %s: %s", upstream.Name, err)
+			return
+		}
+		status.Host = host
+		status.Source = source
+		sent.Message = status
+	} else if envelope.Error {
+		sent.Message = ErrorMessage{
+			Host:   host,
+			Error:  message.Error,
+			Name:   message.Name,
+			Source: source,
+		}
+	} else {
+		sent.Message = Message{
+			Host:     host,
+			Name:     message.Name,
+			Platform: message.Platform,
+			Data:     message.Data,
+			Units:    message.Units,
+			Time:     message.Time,
+			Duration: message.Duration,
+			Source:   source,
+		}
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.latest[upstream.Name][host+"\x00"+message.Name] = sent
+	health := f.health[upstream.Name]
+	health.LastMessage = time.Now()
+	hostSet := make(map[string]bool)
+	for _, latest := range f.latest[upstream.Name] {
+		hostSet[federatedHost(latest)] = true
+	}
+	health.Hosts = len(hostSet)
+}
+
+// disconnected : drop messages of upstream so stale values are not served
+func (f *Federation) disconnected(upstream config.Upstream, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	health := f.health[upstream.Name]
+	if health.Connected {
+		health.Reconnects++
+	}
+	health.Connected = false
+	health.Hosts = 0
+	health.Error = err.Error()
+	f.latest[upstream.Name] = make(map[string]*SendMessage)
+}
+
+func federatedHost(message *SendMessage) string {
+	switch content := message.Message.(type) {
+	case Message:
+		return content.Host
+	case ErrorMessage:
+		return content.Host
+	case HostStatus:
+		return content.Host
+	}
+	return ""
+}
+
+// Statuses : latest status of every upstream host
+func (f *Federation) Statuses() []HostStatus {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	statuses := []HostStatus{}
+	for _, upstream := range f.Upstreams {
+		for _, message := range f.latest[upstream.Name] {
+			if status, ok := message.Message.(HostStatus); ok {
+				statuses = append(statuses, status)
+			}
+		}
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Host < statuses[j].Host
+	})
+	return statuses
+}
+
+// Health : health of upstream as reported by its pseudo-host
+func (f *Federation) Health(name string) (UpstreamHealth, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	health, ok := f.health[name]
+	if !ok {
+		return UpstreamHealth{}, false
+	}
+	return *health, true
+}
+
+// Messages : latest messages of upstream hosts matching filter, all hosts
+// when empty, followed by the pseudo-host of every upstream
+func (f *Federation) Messages(filter string) []*SendMessage {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	messages := []*SendMessage{}
+	for _, upstream := range f.Upstreams {
+		for _, message := range f.latest[upstream.Name] {
+			if filter == "" || federatedHost(message) == filter {
+				messages = append(messages, message)
+			}
+		}
+		if filter == "" || filter == upstream.Name {
+			messages = append(messages, &SendMessage{
+				Message: Message{
+					Host:     upstream.Name,
+					Name:     UpstreamMetric,
+					Platform: "saido",
+					Data:     *f.health[upstream.Name],
+					Source:   upstream.Name,
+				},
+			})
+		}
+	}
+	return messages
+}
+
+// UpstreamStatuses : status of the pseudo-host of every upstream, up while
+// subscribed and down once the subscription failed
+func (f *Federation) UpstreamStatuses() []HostStatus {
+	statuses := []HostStatus{}
+	for _, upstream := range f.Upstreams {
+		health, _ := f.Health(upstream.Name)
+		status := HostStatus{
+			Host:   upstream.Name,
+			State:  HostUnknown,
+			Error:  health.Error,
+			Source: upstream.Name,
+		}
+		if health.Connected {
+			status.State = HostUp
+		} else if health.Error != "" {
+			status.State = HostDown
+		}
+		if !health.LastMessage.IsZero() {
+			lastMessage := health.LastMessage
+			status.LastSuccess = &lastMessage
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
@@ -0,0 +1,148 @@
+package client
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bisohns/saido/config"
+)
+
+// newFakeUpstream : saido instance streaming a metric and an error
+// then holding the connection open until closed
+func newFakeUpstream(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/metrics" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		socket, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer socket.Close()
+		collected := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+		socket.WriteJSON(&SendMessage{
+			Message: Message{
+				Host:     "web-1",
+				Name:     "memory",
+				Platform: "Linux",
+				Data:     map[string]int{"MemTotal": 1024},
+				Time:     &collected,
+				Duration: 20 * time.Millisecond,
+			},
+		})
+		socket.WriteJSON(&SendMessage{
+			Error:   true,
+			Message: ErrorMessage{Host: "web-2", Name: "disk", Error: "unreachable"},
+		})
+		for {
+			if _, _, err := socket.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func waitForMessages(t *testing.T, federation *Federation, count int) []*SendMessage {
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		// messages of upstream hosts are followed by the pseudo-host
+		if messages := federation.Messages(""); len(messages) >= count+1 {
+			return messages
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("Timed out waiting for %d upstream messages", count)
+	return nil
+}
+
+func TestFederationPrefixesHosts(t *testing.T) {
+	upstream := newFakeUpstream(t)
+	federation := NewFederation([]config.Upstream{
+		{Name: "dc1", URL: upstream.URL, Mode: "prefix"},
+	})
+	federation.Start()
+	waitForMessages(t, federation, 2)
+	filtered := federation.Messages("dc1/web-1")
+	if len(filtered) != 1 {
+		t.Fatalf("Expected single message for filtered host, found %d", len(filtered))
+	}
+	message, ok := filtered[0].Message.(Message)
+	if !ok || message.Source != "dc1" || message.Platform != "Linux" {
+		t.Errorf("Unexpected republished message %v", filtered[0].Message)
+	}
+	if message.Time == nil || message.Time.Hour() != 12 || message.Duration != 20*time.Millisecond {
+		t.Errorf("Expected time and duration of the upstream, found %v %s", message.Time, message.Duration)
+	}
+	errored := federation.Messages("dc1/web-2")
+	if len(errored) != 1 || !errored[0].Error {
+		t.Errorf("Expected error message for dc1/web-2, found %v", errored)
+	}
+	health, _ := federation.Health("dc1")
+	if !health.Connected || health.Hosts != 2 {
+		t.Errorf("Unexpected health of upstream %v", health)
+	}
+	pseudo := federation.Messages("dc1")
+	if len(pseudo) != 1 || pseudo[0].Message.(Message).Name != UpstreamMetric {
+		t.Errorf("Expected pseudo-host of upstream, found %v", pseudo)
+	}
+}
+
+func TestFederationGroupsHosts(t *testing.T) {
+	upstream := newFakeUpstream(t)
+	federation := NewFederation([]config.Upstream{
+		{Name: "dc1", URL: upstream.URL + "/metrics", Mode: "group"},
+	})
+	federation.Start()
+	waitForMessages(t, federation, 2)
+	filtered := federation.Messages("web-1")
+	if len(filtered) != 1 || filtered[0].Message.(Message).Source != "dc1" {
+		t.Errorf("Expected host name to be kept with source, found %v", filtered)
+	}
+}
+
+func TestFederationUnreachableUpstream(t *testing.T) {
+	upstream := newFakeUpstream(t)
+	upstream.Close()
+	federation := NewFederation([]config.Upstream{
+		{Name: "dc1", URL: upstream.URL, Mode: "prefix"},
+	})
+	federation.Start()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if health, _ := federation.Health("dc1"); health.Error != "" {
+			if health.Connected || health.Hosts != 0 {
+				t.Errorf("Unexpected health of unreachable upstream %v", health)
+			}
+			hosts := &HostsController{Health: NewHealthTracker(nil), Federation: federation}
+			rec := httptest.NewRecorder()
+			hosts.ServeHosts(rec, httptest.NewRequest(http.MethodGet, "/hosts/dc1", nil))
+			var status HostStatus
+			json.NewDecoder(rec.Body).Decode(&status)
+			if status.State != HostDown || status.Error == "" {
+				t.Errorf("Expected unreachable upstream to be down on /hosts, found %+v", status)
+			}
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Error("Timed out waiting for upstream error")
+}
+
+func TestUpstreamURL(t *testing.T) {
+	cases := map[string]string{
+		"http://dc1:3000":          "ws://dc1:3000/metrics",
+		"https://dc1.example.com/": "wss://dc1.example.com/metrics",
+		"ws://dc1:3000/metrics":    "ws://dc1:3000/metrics",
+	}
+	for address, expected := range cases {
+		if found := upstreamURL(address); found != expected {
+			t.Errorf("Expected %s for %s, found %s", expected, address, found)
+		}
+	}
+}
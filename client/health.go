@@ -0,0 +1,223 @@
+package client
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bisohns/saido/driver"
+)
+
+// States of a host derived from the outcome of its polls
+const (
+	HostUnknown  = "unknown"
+	HostUp       = "up"
+	HostDegraded = "degraded"
+	HostDown     = "down"
+)
+
+var (
+	// flapPolls : recent polls state changes are counted over
+	flapPolls = 10
+	// flapChanges : state changes within flapPolls for a host to flap
+	flapChanges = 4
+	// historySize : state changes kept in the history of a host
+	historySize = 20
+)
+
+// HostTransition : change of state of a host
+type HostTransition struct {
+	From  string
+	To    string
+	At    time.Time
+	Error string `json:",omitempty"`
+}
+
+// HostStatus : health of a host pushed along with its metrics
+type HostStatus struct {
+	Host  string
+	State string
+	// LastPoll : when a poll of the host last finished
+	LastPoll    *time.Time `json:",omitempty"`
+	LastSuccess *time.Time `json:",omitempty"`
+	// ConsecutiveFailures : polls in a row no metric could be collected
+	ConsecutiveFailures int
+	// FailedMetrics : metrics that failed on the last poll
+	FailedMetrics []string `json:",omitempty"`
+	// Flapping : state changed flapChanges times within the last polls
+	Flapping bool
+	Error    string `json:",omitempty"`
+	// History : last state changes, oldest first
+	History []HostTransition `json:",omitempty"`
+	// Source : upstream the status was republished from by a hub
+	Source string `json:",omitempty"`
+}
+
+// PollOutcome : metrics collected and failed during a poll of a host
+type PollOutcome struct {
+	Collected []string
+	Failed    []string
+	// Error : last error of a failed metric
+	Error string
+	// Unreachable : a metric failed as the host could not be connected to
+	Unreachable bool
+}
+
+// fail : record metric as failed with err
+func (o *PollOutcome) fail(metric string, err error) {
+	o.Failed = append(o.Failed, metric)
+	o.Error = err.Error()
+	if connectError(err) {
+		o.Unreachable = true
+	}
+}
+
+// connectError : err is raised when connecting to a host rather than by a
+// command or parser run on it
+func connectError(err error) bool {
+	var (
+		sshErr  *driver.SSHConnectError
+		dialErr *net.OpError
+	)
+	return errors.As(err, &sshErr) || (errors.As(err, &dialErr) && dialErr.Op == "dial")
+}
+
+// state : state a poll with outcome leaves a host in, hosts that could be
+// connected to are at most degraded by failed metrics
+func (o PollOutcome) state() string {
+	switch {
+	case o.Unreachable && len(o.Collected) == 0:
+		return HostDown
+	case len(o.Failed) > 0:
+		return HostDegraded
+	}
+	return HostUp
+}
+
+// hostHealth : status of a host and states of its recent polls
+type hostHealth struct {
+	status HostStatus
+	recent []string
+}
+
+// HealthTracker : health of hosts derived from the outcome of their polls
+type HealthTracker struct {
+	mu    sync.Mutex
+	hosts map[string]*hostHealth
+}
+
+// NewHealthTracker : hosts start in the unknown state until polled
+func NewHealthTracker(addresses []string) *HealthTracker {
+	tracker := &HealthTracker{hosts: make(map[string]*hostHealth)}
+	for _, address := range addresses {
+		tracker.hosts[address] = &hostHealth{status: HostStatus{Host: address, State: HostUnknown}}
+	}
+	return tracker
+}
+
+// Record : update the status of host with the outcome of a poll
+func (t *HealthTracker) Record(host string, outcome PollOutcome, at time.Time) HostStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	health, ok := t.hosts[host]
+	if !ok {
+		health = &hostHealth{status: HostStatus{Host: host, State: HostUnknown}}
+		t.hosts[host] = health
+	}
+	status := &health.status
+	state := outcome.state()
+	if len(outcome.Collected) == 0 && len(outcome.Failed) > 0 {
+		status.ConsecutiveFailures++
+	} else {
+		status.ConsecutiveFailures = 0
+		status.LastSuccess = &at
+	}
+	if state != status.State {
+		status.History = append(status.History, HostTransition{
+			From:  status.State,
+			To:    state,
+			At:    at,
+			Error: outcome.Error,
+		})
+		if len(status.History) > historySize {
+			status.History = status.History[len(status.History)-historySize:]
+		}
+	}
+	health.recent = append(health.recent, state)
+	if len(health.recent) > flapPolls {
+		health.recent = health.recent[len(health.recent)-flapPolls:]
+	}
+	changes := 0
+	for index := 1; index < len(health.recent); index++ {
+		if health.recent[index] != health.recent[index-1] {
+			changes++
+		}
+	}
+	status.Flapping = changes >= flapChanges
+	status.State = state
+	status.LastPoll = &at
+	status.FailedMetrics = outcome.Failed
+	status.Error = outcome.Error
+	return copyStatus(*status)
+}
+
+// Status : status of host and whether it is tracked
+func (t *HealthTracker) Status(host string) (HostStatus, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	health, ok := t.hosts[host]
+	if !ok {
+		return HostStatus{}, false
+	}
+	return copyStatus(health.status), true
+}
+
+// Statuses : status of every host sorted by host
+func (t *HealthTracker) Statuses() []HostStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	statuses := make([]HostStatus, 0, len(t.hosts))
+	for _, health := range t.hosts {
+		statuses = append(statuses, copyStatus(health.status))
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Host < statuses[j].Host
+	})
+	return statuses
+}
+
+// copyStatus : status whose history is not shared with the tracker
+func copyStatus(status HostStatus) HostStatus {
+	status.History = append([]HostTransition(nil), status.History...)
+	return status
+}
+
+// ServeHosts : status of every host on /hosts and of a single host on
+// /hosts/<address>, including upstreams and their hosts in hub mode
+func (hosts *HostsController) ServeHosts(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		writeAgentJSON(w, http.StatusMethodNotAllowed, ErrorMessage{Error: "Method not allowed"})
+		return
+	}
+	statuses := hosts.Health.Statuses()
+	if hosts.Federation != nil {
+		statuses = append(statuses, hosts.Federation.UpstreamStatuses()...)
+		statuses = append(statuses, hosts.Federation.Statuses()...)
+	}
+	address := strings.Trim(strings.TrimPrefix(req.URL.Path, "/hosts"), "/")
+	if address == "" {
+		writeAgentJSON(w, http.StatusOK, statuses)
+		return
+	}
+	for _, status := range statuses {
+		if status.Host == address {
+			writeAgentJSON(w, http.StatusOK, status)
+			return
+		}
+	}
+	writeAgentJSON(w, http.StatusNotFound, ErrorMessage{Host: address, Error: "Cannot find host " + address})
+}
@@ -0,0 +1,161 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/bisohns/saido/config"
+	"github.com/bisohns/saido/driver"
+)
+
+func TestHealthTracker(t *testing.T) {
+	tracker := NewHealthTracker([]string{"web-1"})
+	if status, _ := tracker.Status("web-1"); status.State != HostUnknown {
+		t.Fatalf("Expected hosts to be unknown until polled, found %s", status.State)
+	}
+	start := time.Now()
+	up := PollOutcome{Collected: []string{"memory", "disk"}}
+	partial := PollOutcome{Collected: []string{"memory"}}
+	partial.fail("disk", errors.New("df: not found"))
+	// failed metrics of a host that could be connected to only degrade it
+	var failed, unreachable PollOutcome
+	failed.fail("memory", errors.New("Command exited with 127"))
+	unreachable.fail("memory", &driver.SSHConnectError{})
+
+	polls := []struct {
+		outcome  PollOutcome
+		state    string
+		failures int
+	}{
+		{up, HostUp, 0},
+		{partial, HostDegraded, 0},
+		{failed, HostDegraded, 1},
+		{failed, HostDegraded, 2},
+		{unreachable, HostDown, 3},
+		{up, HostUp, 0},
+	}
+	var status HostStatus
+	for index, poll := range polls {
+		status = tracker.Record("web-1", poll.outcome, start.Add(time.Duration(index)*time.Second))
+		if status.State != poll.state || status.ConsecutiveFailures != poll.failures {
+			t.Fatalf("Poll %d: expected %s after %d failures, found %+v", index, poll.state, poll.failures, status)
+		}
+	}
+	if status.LastSuccess == nil || !status.LastSuccess.Equal(start.Add(5*time.Second)) || status.Flapping {
+		t.Errorf("Unexpected status %+v", status)
+	}
+	if len(status.History) != 4 || status.History[0].From != HostUnknown || status.History[2].Error != unreachable.Error {
+		t.Errorf("Unexpected history %+v", status.History)
+	}
+	tracker.Record("web-1", partial, start.Add(6*time.Second))
+	if status = tracker.Record("web-1", up, start.Add(7*time.Second)); !status.Flapping {
+		t.Errorf("Expected host changing state every poll to flap, found %+v", status)
+	}
+
+	// hosts that cannot be dialed are down on their first failure
+	var refused PollOutcome
+	refused.fail("containers", &url.Error{Op: "Get", Err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}})
+	if status := tracker.Record("db-1", refused, start); status.State != HostDown {
+		t.Errorf("Expected unreachable new host to be down, found %s", status.State)
+	}
+}
+
+func TestServeHosts(t *testing.T) {
+	hosts := &HostsController{Health: NewHealthTracker([]string{"web-1", "db-1"})}
+	hosts.Health.Record("web-1", PollOutcome{Collected: []string{"memory"}}, time.Now())
+	rec := httptest.NewRecorder()
+	hosts.ServeHosts(rec, httptest.NewRequest(http.MethodGet, "/hosts", nil))
+	var statuses []HostStatus
+	if err := json.NewDecoder(rec.Body).Decode(&statuses); err != nil || len(statuses) != 2 {
+		t.Fatalf("Expected status of every host, found %v %v", statuses, err)
+	}
+	if statuses[0].Host != "db-1" || statuses[0].State != HostUnknown || statuses[0].LastPoll != nil || statuses[1].State != HostUp {
+		t.Errorf("Unexpected statuses %+v", statuses)
+	}
+	rec = httptest.NewRecorder()
+	hosts.ServeHosts(rec, httptest.NewRequest(http.MethodGet, "/hosts/web-1", nil))
+	var status HostStatus
+	if err := json.NewDecoder(rec.Body).Decode(&status); err != nil || status.Host != "web-1" {
+		t.Errorf("Expected status of web-1, found %+v", status)
+	}
+	rec = httptest.NewRecorder()
+	hosts.ServeHosts(rec, httptest.NewRequest(http.MethodGet, "/hosts/unknown", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected not found, found %d", rec.Code)
+	}
+}
+
+func TestFederationStatuses(t *testing.T) {
+	upstream := config.Upstream{Name: "dc1", URL: "http://dc1"}
+	federation := NewFederation([]config.Upstream{upstream})
+	message, _ := json.Marshal(HostStatus{Host: "web-1", State: HostDown, ConsecutiveFailures: 3})
+	federation.receive(upstream, upstreamEnvelope{Status: true, Message: message})
+	statuses := federation.Statuses()
+	if len(statuses) != 1 || statuses[0].Host != "dc1/web-1" || statuses[0].Source != "dc1" || statuses[0].State != HostDown {
+		t.Fatalf("Expected status of upstream host, found %+v", statuses)
+	}
+	if republished := federation.Messages("dc1/web-1"); len(republished) != 1 || !republished[0].Status {
+		t.Errorf("Expected status to be republished, found %+v", republished)
+	}
+}
+
+func TestPollOncePerHost(t *testing.T) {
+	host := config.Host{Address: "localhost", Connection: &config.Connection{Type: "local"}}
+	hosts := &HostsController{
+		Info:          &config.DashboardInfo{Hosts: []config.Host{host}, Metrics: config.Metrics{"uptime": ""}},
+		Drivers:       make(map[string]*driver.Driver),
+		ReadOnlyHosts: []string{host.Address},
+		Health:        NewHealthTracker([]string{host.Address}),
+		clients:       make(map[*Client]bool),
+		latest:        make(map[string]map[string]*SendMessage),
+		polling:       make(map[string]bool),
+	}
+	// hosts are polled with no client connected
+	hosts.pollHost(host)
+	if status, _ := hosts.Health.Status(host.Address); status.State != HostUp || status.LastPoll == nil {
+		t.Fatalf("Expected host polled without clients to be up, found %+v", status)
+	}
+
+	clients := []*Client{{Send: make(chan *SendMessage, 10)}, {Send: make(chan *SendMessage, 10)}}
+	for _, client := range clients {
+		hosts.addClient(client)
+		if len(client.Send) != 2 {
+			t.Fatalf("Expected latest metric and status on connect, found %d messages", len(client.Send))
+		}
+		for len(client.Send) > 0 {
+			<-client.Send
+		}
+	}
+	hosts.pollHost(host)
+	for _, client := range clients {
+		if len(client.Send) != 2 {
+			t.Errorf("Expected metric and status of the poll, found %d messages", len(client.Send))
+		}
+	}
+	if message, ok := (<-clients[1].Send).Message.(Message); !ok || message.Time == nil {
+		t.Errorf("Expected metric collected at a time, found %+v", message)
+	}
+	// a poll is recorded once however many clients are connected
+	if recent := hosts.Health.hosts[host.Address].recent; len(recent) != 2 {
+		t.Errorf("Expected 2 recorded polls, found %d", len(recent))
+	}
+
+	hosts.removeClient(clients[0])
+	for len(clients[0].Send) > 0 {
+		<-clients[0].Send
+	}
+	select {
+	case _, open := <-clients[0].Send:
+		if open {
+			t.Error("Expected no messages to removed clients")
+		}
+	default:
+		t.Error("Expected messages to removed clients to end")
+	}
+}
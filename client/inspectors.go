@@ -0,0 +1,28 @@
+package client
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/bisohns/saido/inspector"
+)
+
+// ServeInspectors : schemas of every inspector on /inspectors and of a
+// single inspector on /inspectors/<name>
+func ServeInspectors(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		writeAgentJSON(w, http.StatusMethodNotAllowed, ErrorMessage{Error: "Method not allowed"})
+		return
+	}
+	name := strings.Trim(strings.TrimPrefix(req.URL.Path, "/inspectors"), "/")
+	if name == "" {
+		writeAgentJSON(w, http.StatusOK, inspector.Schemas())
+		return
+	}
+	schema, ok := inspector.GetSchema(name)
+	if !ok {
+		writeAgentJSON(w, http.StatusNotFound, ErrorMessage{Name: name, Error: "Cannot find inspector with name " + name})
+		return
+	}
+	writeAgentJSON(w, http.StatusOK, schema)
+}
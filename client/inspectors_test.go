@@ -0,0 +1,30 @@
+package client
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bisohns/saido/inspector"
+)
+
+func TestServeInspectors(t *testing.T) {
+	rec := httptest.NewRecorder()
+	ServeInspectors(rec, httptest.NewRequest(http.MethodGet, "/inspectors", nil))
+	var schemas []inspector.Schema
+	if err := json.NewDecoder(rec.Body).Decode(&schemas); err != nil || len(schemas) == 0 {
+		t.Fatalf("Expected schemas, found %v", err)
+	}
+	rec = httptest.NewRecorder()
+	ServeInspectors(rec, httptest.NewRequest(http.MethodGet, "/inspectors/memory", nil))
+	var memory inspector.Schema
+	if err := json.NewDecoder(rec.Body).Decode(&memory); err != nil || memory.Name != "memory" {
+		t.Errorf("Expected memory schema, found %+v", memory)
+	}
+	rec = httptest.NewRecorder()
+	ServeInspectors(rec, httptest.NewRequest(http.MethodGet, "/inspectors/unknown", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected not found, found %d", rec.Code)
+	}
+}
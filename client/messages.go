@@ -1,7 +1,11 @@
 package client
 
+import "time"
+
 type SendMessage struct {
-	Error   bool
+	Error bool
+	// Status : Message is the HostStatus of a host after a poll
+	Status  bool `json:",omitempty"`
 	Message interface{}
 }
 
@@ -9,6 +13,8 @@ type ErrorMessage struct {
 	Host  string
 	Error string
 	Name  string
+	// Source : upstream the message was republished from by a hub
+	Source string `json:",omitempty"`
 }
 
 type Message struct {
@@ -16,6 +22,13 @@ type Message struct {
 	Name     string
 	Platform string
 	Data     interface{}
+	// Units of fields of Data e.g {"MemTotal": "MB"}
+	Units map[string]string `json:",omitempty"`
+	// Time the metric started being collected and Duration of collecting it
+	Time     *time.Time    `json:",omitempty"`
+	Duration time.Duration `json:",omitempty"`
+	// Source : upstream the message was republished from by a hub
+	Source string `json:",omitempty"`
 }
 
 // ReceiveMessage : specify the host to filter by
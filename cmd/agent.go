@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/bisohns/saido/client"
+	"github.com/bisohns/saido/config"
+	"github.com/bisohns/saido/driver"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	agentPort        int
+	agentToken       string
+	agentCache       time.Duration
+	agentMetrics     []string
+	agentAllowCustom bool
+	agentTLSCert     string
+	agentTLSKey      string
+	agentInspectors  []string
+	agentPlugins     map[string]string
+)
+
+var agentCmd = &cobra.Command{
+	Use:   "agent",
+	Short: "Serve metrics of this host to saido servers",
+	Long: `Run inspectors on this host and serve them over HTTP to saido servers
+connecting with a connection of type agent`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if verbose {
+			log.SetLevel(log.DebugLevel)
+		} else {
+			log.SetLevel(log.InfoLevel)
+		}
+		if agentToken == "" {
+			agentToken = os.Getenv("SAIDO_AGENT_TOKEN")
+		}
+		if (agentTLSCert == "") != (agentTLSKey == "") {
+			log.Fatal("Must specify both --tls-cert and --tls-key")
+		}
+		registerInspectors(config.LoadInspectors(nil, agentInspectors))
+		plugins := make(map[string]config.PluginDefinition)
+		for name, command := range agentPlugins {
+			plugins[name] = config.PluginDefinition{Command: command}
+		}
+		registerPlugins(config.LoadPlugins(plugins))
+		agent := client.NewAgentServer(agentToken, Version)
+		agent.CacheTTL = agentCache
+		agent.Metrics = agentMetrics
+		agent.AllowCustom = agentAllowCustom
+		address := fmt.Sprintf(":%d", agentPort)
+		log.Info("agent listening on ", address)
+		if err := agent.ListenAndServe(address, agentTLSCert, agentTLSKey); err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	agentCmd.Flags().IntVarP(&agentPort, "port", "p", driver.DefaultAgentPort, "Port to serve metrics on")
+	agentCmd.Flags().StringVar(&agentToken, "token", "", "Token servers must authenticate with, defaults to $SAIDO_AGENT_TOKEN")
+	agentCmd.Flags().DurationVar(&agentCache, "cache", 5*time.Second, "Duration to serve metrics from cache")
+	agentCmd.Flags().StringSliceVar(&agentMetrics, "metrics", nil, "Metrics to serve, all metrics when empty")
+	agentCmd.Flags().BoolVar(&agentAllowCustom, "allow-custom", false, "Allow servers to run custom commands")
+	agentCmd.Flags().StringVar(&agentTLSCert, "tls-cert", "", "Certificate to serve https with")
+	agentCmd.Flags().StringVar(&agentTLSKey, "tls-key", "", "Key of the certificate to serve https with")
+	agentCmd.Flags().StringSliceVar(&agentInspectors, "inspector-files", nil, "YAML files declaring inspectors, globs are expanded")
+	agentCmd.Flags().StringToStringVar(&agentPlugins, "plugin", nil, "Plugins to serve as name=command")
+	agentCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Run agent in verbose mode")
+	rootCmd.AddCommand(agentCmd)
+}
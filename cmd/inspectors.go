@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/bisohns/saido/config"
+	"github.com/bisohns/saido/inspector"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var inspectorsJSON bool
+
+var inspectorsCmd = &cobra.Command{
+	Use:   "inspectors [name]",
+	Short: "List inspectors and the fields they read",
+	Long: `List every inspector with the platforms it supports, or the fields,
+units and descriptions of the named inspector. Inspectors declared by
+--config are listed along with those built in, in the units it sets`,
+	Args: cobra.MaximumNArgs(1),
+	// the banner would not be valid JSON
+	PersistentPostRun: func(cmd *cobra.Command, args []string) {
+		if !inspectorsJSON {
+			rootCmd.PersistentPostRun(cmd, args)
+		}
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		if cfgFile != "" {
+			cfg = config.LoadConfig(cfgFile)
+			registerInspectors(config.LoadInspectors(cfg.Inspectors, cfg.InspectorFiles))
+			registerPlugins(config.LoadPlugins(cfg.Plugins))
+			inspector.SetDisplayUnits(config.LoadUnits(cfg.Units))
+		}
+		var output interface{} = inspector.Schemas()
+		if len(args) == 1 {
+			schema, ok := inspector.GetSchema(args[0])
+			if !ok {
+				log.Fatalf("Cannot find inspector with name %s", args[0])
+			}
+			output = schema
+		}
+		if inspectorsJSON {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			if err := encoder.Encode(output); err != nil {
+				log.Fatal(err)
+			}
+			return
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		switch output := output.(type) {
+		case []inspector.Schema:
+			fmt.Fprintln(w, "NAME\tPLATFORMS\tDESCRIPTION")
+			for _, schema := range output {
+				fmt.Fprintf(w, "%s\t%s\t%s\n", schema.Name, strings.Join(schema.Platforms, ","), schema.Description)
+			}
+		case inspector.Schema:
+			fmt.Fprintf(w, "%s: %s\nPlatforms: %s\n\n", output.Name, output.Description, strings.Join(output.Platforms, ", "))
+			fmt.Fprintln(w, "FIELD\tTYPE\tUNIT\tKIND\tDESCRIPTION")
+			for _, field := range output.Fields {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", field.Name, field.Type, field.Unit, field.Kind, field.Description)
+			}
+		}
+		w.Flush()
+	},
+}
+
+// registerInspectors : make declared inspectors available before use
+func registerInspectors(definitions []config.InspectorDefinition) {
+	for _, definition := range definitions {
+		if err := inspector.Register(definition); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+// registerPlugins : make plugins available, plugins failing to describe
+// themselves are left out
+func registerPlugins(plugins []config.PluginDefinition) {
+	for _, plugin := range plugins {
+		if err := inspector.RegisterPlugin(plugin); err != nil {
+			log.Errorf("Could not register plugin %s: %s", plugin.Name, err)
+		}
+	}
+}
+
+func init() {
+	inspectorsCmd.Flags().BoolVar(&inspectorsJSON, "json", false, "Print schemas as JSON")
+	rootCmd.AddCommand(inspectorsCmd)
+}
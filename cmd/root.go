@@ -65,6 +65,10 @@ var rootCmd = &cobra.Command{
 		hosts := client.NewHostsController(cfg)
 
 		server.Handle("/metrics", hosts)
+		server.HandleFunc("/inspectors", client.ServeInspectors)
+		server.HandleFunc("/inspectors/", client.ServeInspectors)
+		server.HandleFunc("/hosts", hosts.ServeHosts)
+		server.HandleFunc("/hosts/", hosts.ServeHosts)
 		log.Info("listening on :", port)
 		_, err := strconv.Atoi(port)
 		if err != nil {
@@ -97,7 +101,8 @@ func init() {
 	rootCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Run saido in verbose mode")
 	rootCmd.Flags().BoolVarP(&browserFlag, "open-browser", "b", false, "Prompt open browser")
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "Path to config file")
-	if len(os.Args) >= 2 && os.Args[1] != "version" || len(os.Args) == 1 {
+	// version, agent and inspectors run without a config file
+	if len(os.Args) >= 2 && os.Args[1] != "version" && os.Args[1] != "agent" && os.Args[1] != "inspectors" || len(os.Args) == 1 {
 		cobra.MarkFlagRequired(rootCmd.PersistentFlags(), "config")
 	}
 }
@@ -0,0 +1,387 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// ansibleGroup : hosts, vars and children of an inventory group
+type ansibleGroup struct {
+	Hosts    map[string]map[string]string
+	Vars     map[string]string
+	Children []string
+}
+
+type ansibleInventory struct {
+	Groups map[string]*ansibleGroup
+}
+
+func newAnsibleInventory() *ansibleInventory {
+	return &ansibleInventory{
+		Groups: make(map[string]*ansibleGroup),
+	}
+}
+
+func (inv *ansibleInventory) group(name string) *ansibleGroup {
+	group, ok := inv.Groups[name]
+	if !ok {
+		group = &ansibleGroup{
+			Hosts: make(map[string]map[string]string),
+			Vars:  make(map[string]string),
+		}
+		inv.Groups[name] = group
+	}
+	return group
+}
+
+func (group *ansibleGroup) addHost(name string, vars map[string]string) {
+	existing, ok := group.Hosts[name]
+	if !ok {
+		existing = make(map[string]string)
+		group.Hosts[name] = existing
+	}
+	for k, v := range vars {
+		existing[k] = v
+	}
+}
+
+// LoadAnsibleInventory : load hosts from an INI or YAML ansible inventory
+func LoadAnsibleInventory(inventoryPath string) ([]Host, error) {
+	content, err := ioutil.ReadFile(expandHome(inventoryPath))
+	if err != nil {
+		return nil, err
+	}
+	var inv *ansibleInventory
+	switch strings.ToLower(filepath.Ext(inventoryPath)) {
+	case ".yaml", ".yml":
+		inv, err = parseAnsibleYAML(content)
+	default:
+		inv, err = parseAnsibleINI(string(content))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return inv.hosts(), nil
+}
+
+var ansibleRange = regexp.MustCompile(`\[([0-9]+):([0-9]+)\]`)
+
+// expandAnsibleHost : expand numeric ranges e.g www[01:03].example.com
+func expandAnsibleHost(pattern string) []string {
+	match := ansibleRange.FindStringSubmatchIndex(pattern)
+	if match == nil {
+		return []string{pattern}
+	}
+	startStr := pattern[match[2]:match[3]]
+	endStr := pattern[match[4]:match[5]]
+	start, _ := strconv.Atoi(startStr)
+	end, _ := strconv.Atoi(endStr)
+	format := "%d"
+	if len(startStr) > 1 && strings.HasPrefix(startStr, "0") {
+		format = fmt.Sprintf("%%0%dd", len(startStr))
+	}
+	var hosts []string
+	for index := start; index <= end; index++ {
+		expanded := pattern[:match[0]] + fmt.Sprintf(format, index) + pattern[match[1]:]
+		hosts = append(hosts, expandAnsibleHost(expanded)...)
+	}
+	return hosts
+}
+
+// splitAnsibleVars : split `key=value key2="value 2"` respecting quotes
+func splitAnsibleVars(line string) []string {
+	var (
+		fields  []string
+		current strings.Builder
+		quote   rune
+	)
+	for _, char := range line {
+		switch {
+		case quote != 0 && char == quote:
+			quote = 0
+		case quote == 0 && (char == '"' || char == '\''):
+			quote = char
+		case quote == 0 && (char == ' ' || char == '\t'):
+			if current.Len() > 0 {
+				fields = append(fields, current.String())
+				current.Reset()
+			}
+		default:
+			current.WriteRune(char)
+		}
+	}
+	if current.Len() > 0 {
+		fields = append(fields, current.String())
+	}
+	return fields
+}
+
+func parseAnsibleVars(fields []string) map[string]string {
+	vars := make(map[string]string)
+	for _, field := range fields {
+		parts := strings.SplitN(field, "=", 2)
+		if len(parts) == 2 {
+			vars[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+		}
+	}
+	return vars
+}
+
+/*
+	parseAnsibleINI : parse inventory in the following format
+
+mail.example.com ansible_port=5555
+
+[webservers]
+www[01:02].example.com ansible_user=deploy
+
+[webservers:vars]
+ansible_ssh_private_key_file=~/.ssh/deploy
+
+[prod:children]
+webservers
+*/
+func parseAnsibleINI(content string) (*ansibleInventory, error) {
+	inv := newAnsibleInventory()
+	section, kind := "ungrouped", "hosts"
+	inv.group("all").Children = append(inv.group("all").Children, section)
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") {
+				return nil, fmt.Errorf("inventory line %d: invalid section %s", lineNo, line)
+			}
+			section, kind = strings.Trim(line, "[]"), "hosts"
+			if parts := strings.SplitN(section, ":", 2); len(parts) == 2 {
+				section, kind = parts[0], parts[1]
+			}
+			inv.group(section)
+			if section != "all" {
+				inv.group("all").Children = append(inv.group("all").Children, section)
+			}
+			continue
+		}
+		group := inv.group(section)
+		switch kind {
+		case "hosts":
+			fields := splitAnsibleVars(line)
+			vars := parseAnsibleVars(fields[1:])
+			for _, host := range expandAnsibleHost(fields[0]) {
+				group.addHost(host, vars)
+			}
+		case "vars":
+			for k, v := range parseAnsibleVars([]string{line}) {
+				group.Vars[k] = v
+			}
+		case "children":
+			inv.group(line)
+			group.Children = append(group.Children, line)
+		default:
+			return nil, fmt.Errorf("inventory line %d: unsupported section type %s", lineNo, kind)
+		}
+	}
+	return inv, scanner.Err()
+}
+
+/*
+	parseAnsibleYAML : parse inventory in the following format
+
+all:
+  hosts:
+    mail.example.com:
+      ansible_port: 5555
+  children:
+    webservers:
+      hosts:
+        www[01:02].example.com:
+      vars:
+        ansible_user: deploy
+*/
+func parseAnsibleYAML(content []byte) (*ansibleInventory, error) {
+	var raw map[string]interface{}
+	if err := yaml.Unmarshal(content, &raw); err != nil {
+		return nil, err
+	}
+	inv := newAnsibleInventory()
+	for name, group := range raw {
+		if err := inv.parseYAMLGroup(name, group); err != nil {
+			return nil, err
+		}
+		if name != "all" {
+			inv.group("all").Children = append(inv.group("all").Children, name)
+		}
+	}
+	return inv, nil
+}
+
+func (inv *ansibleInventory) parseYAMLGroup(name string, raw interface{}) error {
+	group := inv.group(name)
+	if raw == nil {
+		return nil
+	}
+	content, ok := raw.(map[interface{}]interface{})
+	if !ok {
+		return fmt.Errorf("Failed to parse inventory group %s", name)
+	}
+	if hosts, ok := content["hosts"].(map[interface{}]interface{}); ok {
+		for host, vars := range hosts {
+			hostVars, _ := vars.(map[interface{}]interface{})
+			for _, expanded := range expandAnsibleHost(fmt.Sprintf("%v", host)) {
+				group.addHost(expanded, coerceAnsibleVars(hostVars))
+			}
+		}
+	}
+	if vars, ok := content["vars"].(map[interface{}]interface{}); ok {
+		for k, v := range coerceAnsibleVars(vars) {
+			group.Vars[k] = v
+		}
+	}
+	if children, ok := content["children"].(map[interface{}]interface{}); ok {
+		for child, childContent := range children {
+			childName := fmt.Sprintf("%v", child)
+			group.Children = append(group.Children, childName)
+			if err := inv.parseYAMLGroup(childName, childContent); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// coerceAnsibleVars : vars as strings, vars without a value are unset
+func coerceAnsibleVars(raw map[interface{}]interface{}) map[string]string {
+	vars := make(map[string]string)
+	for k, v := range raw {
+		if v != nil {
+			vars[fmt.Sprintf("%v", k)] = fmt.Sprintf("%v", v)
+		}
+	}
+	return vars
+}
+
+// collectGroups : walk from `all` down to every host recording the depth
+// of every group, its longest path from `all`, and the groups every host
+// belongs to directly or through its parents
+func (inv *ansibleInventory) collectGroups(name string, depth int, path []string, depths map[string]int, memberships map[string]map[string]bool) {
+	for _, parent := range path {
+		if parent == name {
+			return
+		}
+	}
+	if current, ok := depths[name]; !ok || depth > current {
+		depths[name] = depth
+	}
+	path = append(path, name)
+	group := inv.group(name)
+	for host := range group.Hosts {
+		if memberships[host] == nil {
+			memberships[host] = make(map[string]bool)
+		}
+		for _, member := range path {
+			memberships[host][member] = true
+		}
+	}
+	for _, child := range group.Children {
+		inv.collectGroups(child, depth+1, path, depths, memberships)
+	}
+}
+
+// hostVars : vars of host following ansible precedence, vars of deeper
+// groups override their parents, groups of the same depth are applied
+// by name and host vars override every group
+func (inv *ansibleInventory) hostVars(host string, groups map[string]bool, depths map[string]int) map[string]string {
+	ordered := make([]string, 0, len(groups))
+	for name := range groups {
+		ordered = append(ordered, name)
+	}
+	sort.Slice(ordered, func(i, j int) bool {
+		if depths[ordered[i]] != depths[ordered[j]] {
+			return depths[ordered[i]] < depths[ordered[j]]
+		}
+		return ordered[i] < ordered[j]
+	})
+	vars := make(map[string]string)
+	for _, name := range ordered {
+		for k, v := range inv.group(name).Vars {
+			vars[k] = v
+		}
+	}
+	for _, name := range ordered {
+		for k, v := range inv.group(name).Hosts[host] {
+			vars[k] = v
+		}
+	}
+	return vars
+}
+
+func (inv *ansibleInventory) hosts() []Host {
+	depths := make(map[string]int)
+	memberships := make(map[string]map[string]bool)
+	inv.collectGroups("all", 0, nil, depths, memberships)
+	names := make([]string, 0, len(memberships))
+	for name := range memberships {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	hosts := []Host{}
+	for _, name := range names {
+		hosts = append(hosts, Host{
+			Address:    name,
+			Connection: ansibleConnection(name, inv.hostVars(name, memberships[name], depths)),
+		})
+	}
+	return hosts
+}
+
+func ansibleConnection(name string, vars map[string]string) *Connection {
+	conn := &Connection{
+		Type: "ssh",
+		Host: name,
+	}
+	if vars["ansible_connection"] == "local" {
+		conn.Type = "local"
+	}
+	if become, _ := strconv.ParseBool(vars["ansible_become"]); become {
+		conn.Become = &Become{
+			Method:   vars["ansible_become_method"],
+			User:     vars["ansible_become_user"],
+			Password: vars["ansible_become_password"],
+		}
+	}
+	if conn.Type == "local" {
+		return conn
+	}
+	if host, ok := vars["ansible_host"]; ok {
+		conn.Host = host
+	}
+	if port, err := strconv.Atoi(vars["ansible_port"]); err == nil {
+		conn.Port = int32(port)
+	}
+	conn.Username = vars["ansible_user"]
+	conn.Password = vars["ansible_password"]
+	if conn.Password == "" {
+		conn.Password = vars["ansible_ssh_pass"]
+	}
+	if key, ok := vars["ansible_ssh_private_key_file"]; ok {
+		conn.PrivateKeyPath = expandHome(key)
+	}
+	// key file takes precedence over password unless tried in order
+	if conn.PrivateKeyPath != "" && conn.Password != "" {
+		conn.AuthMethods = []string{"key", "password"}
+	}
+	return conn
+}
@@ -0,0 +1,125 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testINIInventory = `
+mail.example.com ansible_port=5555 ansible_connection=local
+
+[webservers]
+www[01:02].example.com ansible_user=deploy
+legacy.example.com ansible_host=10.0.0.9 ansible_user=root
+
+[webservers:vars]
+ansible_user=web
+ansible_ssh_private_key_file=/keys/web
+
+[prod:children]
+webservers
+
+[prod:vars]
+ansible_port=2222
+`
+
+const testYAMLInventory = `
+all:
+  hosts:
+    mail.example.com:
+      ansible_connection: local
+  children:
+    webservers:
+      hosts:
+        www[01:02].example.com:
+          ansible_user: deploy
+        legacy.example.com:
+          ansible_host: 10.0.0.9
+          ansible_user: root
+      vars:
+        ansible_user: web
+        ansible_ssh_private_key_file: /keys/web
+    prod:
+      children:
+        webservers:
+      vars:
+        ansible_port: 2222
+`
+
+func TestLoadAnsibleInventory(t *testing.T) {
+	dir := t.TempDir()
+	for file, content := range map[string]string{
+		"hosts.ini":  testINIInventory,
+		"hosts.yaml": testYAMLInventory,
+	} {
+		inventoryPath := filepath.Join(dir, file)
+		os.WriteFile(inventoryPath, []byte(content), 0600)
+		hosts, err := LoadAnsibleInventory(inventoryPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(hosts) != 4 {
+			t.Fatalf("%s: expected 4 hosts, got %d", file, len(hosts))
+		}
+		byAddress := make(map[string]*Connection)
+		for _, host := range hosts {
+			byAddress[host.Address] = host.Connection
+		}
+		if byAddress["mail.example.com"].Type != "local" {
+			t.Errorf("%s: expected local connection for mail", file)
+		}
+		www := byAddress["www01.example.com"]
+		if www == nil || www.Username != "deploy" || www.Port != 2222 || www.PrivateKeyPath != "/keys/web" {
+			t.Errorf("%s: unexpected connection for www01 %+v", file, www)
+		}
+		legacy := byAddress["legacy.example.com"]
+		if legacy.Host != "10.0.0.9" || legacy.Username != "root" {
+			t.Errorf("%s: unexpected connection for legacy %+v", file, legacy)
+		}
+	}
+}
+
+const testConflictingInventory = `
+all:
+  vars:
+    ansible_user: everyone
+    ansible_port: 22
+    ansible_host:
+  children:
+    zone:
+      vars:
+        ansible_user: zone
+        ansible_port: 2200
+      children:
+        app:
+          hosts:
+            app1.example.com:
+          vars:
+            ansible_user: app
+    audit:
+      vars:
+        ansible_port: 2300
+      hosts:
+        app1.example.com:
+`
+
+func TestAnsibleVarPrecedence(t *testing.T) {
+	inventoryPath := filepath.Join(t.TempDir(), "hosts.yaml")
+	os.WriteFile(inventoryPath, []byte(testConflictingInventory), 0600)
+	// vars of the child app override zone whatever the order groups are
+	// read in, audit and zone have the same depth and are applied by name
+	for run := 0; run < 20; run++ {
+		hosts, err := LoadAnsibleInventory(inventoryPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(hosts) != 1 {
+			t.Fatalf("Expected single host, got %+v", hosts)
+		}
+		conn := hosts[0].Connection
+		if conn.Username != "app" || conn.Port != 2200 || conn.Host != "app1.example.com" {
+			t.Fatalf("Unexpected connection %+v", conn)
+		}
+	}
+}
@@ -3,6 +3,9 @@ package config
 import (
 	"fmt"
 	"io/ioutil"
+	"net"
+	"sort"
+	"strings"
 
 	// "github.com/bisohns/saido/driver"
 
@@ -20,6 +23,10 @@ type DashboardInfo struct {
 	Metrics      Metrics
 	Title        string
 	PollInterval int
+	Upstreams    []Upstream
+	Inspectors   []InspectorDefinition
+	Plugins      []PluginDefinition
+	Units        DisplayUnits
 }
 
 func Contains(hostList HostList, host Host) bool {
@@ -31,6 +38,15 @@ func Contains(hostList HostList, host Host) bool {
 	return false
 }
 
+func containsString(list []string, str string) bool {
+	for _, compare := range list {
+		if compare == str {
+			return true
+		}
+	}
+	return false
+}
+
 func MergeMetrics(a, b Metrics) (metrics Metrics) {
 	metrics = Metrics{}
 	inputs := [2]Metrics{a, b}
@@ -51,14 +67,128 @@ func (dashboardInfo *DashboardInfo) GetAllHostAddresses() (addresses HostList) {
 	return
 }
 
+// DNSRecordTypes : supported values for `type` of a dns query
+var DNSRecordTypes = []string{"", "A", "AAAA", "CNAME", "MX", "TXT", "SRV"}
+
+// SSHAuthMethods : supported values for `auth_methods` on an ssh connection
+var SSHAuthMethods = []string{
+	"password",
+	"key",
+	"agent",
+	"certificate",
+	"keyboard_interactive",
+}
+
+// Become : privilege escalation for commands run on a connection
+type Become struct {
+	// Method : sudo (default) or doas
+	Method   string `mapstructure:"method"`
+	User     string `mapstructure:"user"`
+	Password string `mapstructure:"password"`
+	// Metrics : metrics to run with escalation, all metrics when empty
+	Metrics []string `mapstructure:"metrics"`
+}
+
+// Applies : checks if metric should be run with escalation
+func (b *Become) Applies(metric string) bool {
+	return len(b.Metrics) == 0 || containsString(b.Metrics, metric)
+}
+
+// PortTarget : endpoint probed from saido by a portcheck connection
+type PortTarget struct {
+	// Name : shown in results, defaults to address
+	Name string `mapstructure:"name"`
+	// Address : host:port e.g db1:5432
+	Address string `mapstructure:"address"`
+	// Protocol : tcp (default) or udp
+	Protocol string `mapstructure:"protocol"`
+	// TLS : perform a TLS handshake after connecting over tcp
+	TLS                bool   `mapstructure:"tls"`
+	ServerName         string `mapstructure:"server_name"`
+	InsecureSkipVerify bool   `mapstructure:"insecure_skip_verify"`
+	// Send : payload written after connecting, Go escapes e.g \x00 are allowed
+	Send string `mapstructure:"send"`
+	// Expect : substring the banner or udp response must contain
+	Expect string `mapstructure:"expect"`
+}
+
+// DNSQuery : record resolved by a dns connection
+type DNSQuery struct {
+	Name string `mapstructure:"name"`
+	// Type : A (default), AAAA, CNAME, MX, TXT or SRV
+	Type string `mapstructure:"type"`
+	// Expect : answers in any order e.g `10 mail.example.com` for MX and
+	// `priority weight port target` for SRV
+	Expect []string `mapstructure:"expect"`
+}
+
 type Connection struct {
 	Type                 string `mapstructure:"type"`
 	Username             string `mapstructure:"username"`
 	Password             string `mapstructure:"password"`
 	PrivateKeyPath       string `mapstructure:"private_key_path"`
 	PrivateKeyPassPhrase string `mapstructure:"private_key_passphrase"`
-	Port                 int32  `mapstructure:"port"`
-	Host                 string
+	// CertificatePath : OpenSSH user certificate e.g ~/.ssh/id_ed25519-cert.pub
+	CertificatePath string `mapstructure:"certificate_path"`
+	// AuthMethods : ordered list of auth methods to try, see SSHAuthMethods
+	AuthMethods []string `mapstructure:"auth_methods"`
+	// AgentForwarding : forward the local SSH_AUTH_SOCK agent to the host
+	AgentForwarding bool `mapstructure:"agent_forwarding"`
+	// ProxyJump : chain of jump hosts each with their own credentials,
+	// a hop is identified by its `host`
+	ProxyJump []Connection `mapstructure:"proxy_jump"`
+	// SSHConfig : OpenSSH client config to resolve unset parameters from,
+	// overrides the global `ssh-config`
+	SSHConfig string `mapstructure:"ssh_config"`
+	// Container : name or id of container for docker connections which
+	// defaults to the host name, or container of a kubernetes pod
+	Container string `mapstructure:"container"`
+	// Socket : docker engine socket used by docker connections and the
+	// containers metric, defaults to /var/run/docker.sock
+	Socket string `mapstructure:"socket"`
+	// DockerCLI : use `docker exec` instead of the docker engine API
+	DockerCLI bool `mapstructure:"docker_cli"`
+	// Kubeconfig : kubeconfig for kubernetes connections, defaults to
+	// $KUBECONFIG or ~/.kube/config
+	Kubeconfig string `mapstructure:"kubeconfig"`
+	// Context : kubeconfig context, defaults to current-context
+	Context   string `mapstructure:"context"`
+	Namespace string `mapstructure:"namespace"`
+	// Pod : pod to run inspectors in through exec
+	Pod string `mapstructure:"pod"`
+	// Kubectl : use kubectl instead of the kubernetes API
+	Kubectl bool `mapstructure:"kubectl"`
+	// Token : bearer token of agent connections
+	Token string `mapstructure:"token"`
+	// TLS : connect to the agent or node_exporter over https
+	TLS                bool `mapstructure:"tls"`
+	InsecureSkipVerify bool `mapstructure:"insecure_skip_verify"`
+	// Path : metrics path of node_exporter connections, defaults to /metrics
+	Path string `mapstructure:"path"`
+	// SNMPVersion : 1, 2c (default) or 3 for snmp connections
+	SNMPVersion string `mapstructure:"snmp_version"`
+	// Community : SNMP v1/v2c community, defaults to public
+	Community string `mapstructure:"community"`
+	// SecurityLevel : SNMP v3 noAuthNoPriv, authNoPriv or authPriv
+	SecurityLevel string `mapstructure:"security_level"`
+	AuthProtocol  string `mapstructure:"auth_protocol"`
+	AuthPassword  string `mapstructure:"auth_password"`
+	PrivProtocol  string `mapstructure:"priv_protocol"`
+	PrivPassword  string `mapstructure:"priv_password"`
+	ContextName   string `mapstructure:"context_name"`
+	// Targets : endpoints probed by portcheck connections
+	Targets []PortTarget `mapstructure:"targets"`
+	// Queries : records resolved by dns connections
+	Queries []DNSQuery `mapstructure:"queries"`
+	// Timeout : seconds to wait for every portcheck probe or dns query,
+	// defaults to 5
+	Timeout int `mapstructure:"timeout"`
+	// Batch : run the commands of a poll in one ssh session
+	Batch bool `mapstructure:"batch"`
+	// Become : run commands with sudo or doas
+	Become *Become `mapstructure:"become"`
+	Port   int32   `mapstructure:"port"`
+	Host   string  `mapstructure:"host"`
 }
 
 type Host struct {
@@ -69,11 +199,78 @@ type Host struct {
 	Metrics Metrics
 }
 
+// Upstream : saido instance whose hosts are republished by a hub
+type Upstream struct {
+	Name string `yaml:"-"`
+	// URL : address of the upstream e.g http://dc1.example.com:3000
+	URL string `yaml:"url"`
+	// Mode : prefix (default) host names with the upstream name or group
+	// them by keeping host names and setting the source
+	Mode string `yaml:"mode"`
+}
+
+// DisplayByteSizes : supported values for `bytes` of display units
+var DisplayByteSizes = []string{"", "B", "KB", "MB", "GB", "TB", "auto"}
+
+// DisplayStandards : supported values for `standard` of display units
+var DisplayStandards = []string{"", "iec", "si"}
+
+// DisplayUnits : units byte sizes of metrics are displayed in
+type DisplayUnits struct {
+	// Bytes : B, KB, MB (default), GB, TB or auto to pick the largest
+	// unit a value of a field is at least 1 of
+	Bytes string `yaml:"bytes"`
+	// Standard : iec for powers of 1024 labelled KiB, MiB ... or si for
+	// powers of 1000, powers of 1024 labelled KB, MB ... when empty
+	Standard string `yaml:"standard"`
+	// Metrics : Bytes of single metrics e.g disk: TB
+	Metrics map[string]string `yaml:"metrics"`
+}
+
+// LoadUnits : display units after checking their values
+func LoadUnits(units DisplayUnits) DisplayUnits {
+	if err := validateUnits(units); err != nil {
+		log.Fatalf("Invalid units: %s", err)
+	}
+	return units
+}
+
+// validateUnits : checks values of display units
+func validateUnits(units DisplayUnits) error {
+	if !containsString(DisplayByteSizes, units.Bytes) {
+		return fmt.Errorf("unknown bytes %q, expected one of %v", units.Bytes, DisplayByteSizes[1:])
+	}
+	if !containsString(DisplayStandards, units.Standard) {
+		return fmt.Errorf("unknown standard %q, expected one of %v", units.Standard, DisplayStandards[1:])
+	}
+	for metric, bytes := range units.Metrics {
+		if bytes == "" || !containsString(DisplayByteSizes, bytes) {
+			return fmt.Errorf("unknown bytes %q of %s, expected one of %v", bytes, metric, DisplayByteSizes[1:])
+		}
+	}
+	return nil
+}
+
 type Config struct {
 	Hosts        map[interface{}]interface{} `yaml:"hosts"`
 	Metrics      map[interface{}]interface{} `yaml:"metrics"`
 	Title        string                      `yaml:"title"`
 	PollInterval int                         `yaml:"poll-interval"`
+	// SSHConfig : OpenSSH client config e.g ~/.ssh/config used as fallback
+	// for ssh connection parameters of every host
+	SSHConfig string `yaml:"ssh-config"`
+	// Inventory : INI or YAML ansible inventory to load hosts from
+	Inventory string `yaml:"inventory"`
+	// Upstreams : saido instances to subscribe to in hub mode
+	Upstreams map[string]Upstream `yaml:"upstreams"`
+	// Inspectors : inspectors declared in yaml, registered at startup
+	Inspectors map[string]InspectorDefinition `yaml:"inspectors"`
+	// InspectorFiles : globs of yaml files declaring more inspectors
+	InspectorFiles []string `yaml:"inspector-files"`
+	// Plugins : inspectors run as subprocesses, registered at startup
+	Plugins map[string]PluginDefinition `yaml:"plugins"`
+	// Units : units byte sizes are displayed in
+	Units DisplayUnits `yaml:"units"`
 }
 
 func LoadConfig(configPath string) *Config {
@@ -93,6 +290,11 @@ func coerceMetrics(rawMetrics map[interface{}]interface{}) map[string]string {
 	metrics := make(map[string]string)
 	for metric, customCommand := range rawMetrics {
 		metric := fmt.Sprintf("%v", metric)
+		// metrics listed without a value e.g `process:`
+		if customCommand == nil {
+			metrics[metric] = ""
+			continue
+		}
 		metrics[metric] = fmt.Sprintf("%v", customCommand)
 	}
 	return metrics
@@ -106,7 +308,28 @@ func GetDashboardInfoConfig(config *Config) *DashboardInfo {
 		dashboardInfo.Title = config.Title
 	}
 
-	dashboardInfo.Hosts = parseConfig("root", "", config.Hosts, &Connection{})
+	dashboardInfo.Hosts = []Host{}
+	if config.Hosts != nil {
+		dashboardInfo.Hosts = parseConfig("root", "", config.Hosts, &Connection{})
+	}
+	if config.Inventory != "" {
+		inventoryHosts, err := LoadAnsibleInventory(config.Inventory)
+		if err != nil {
+			log.Fatalf("Failed to load inventory %s: %s", config.Inventory, err)
+		}
+		// hosts defined in saido config take precedence
+		addresses := dashboardInfo.GetAllHostAddresses()
+		for _, host := range inventoryHosts {
+			if !Contains(addresses, host) {
+				dashboardInfo.Hosts = append(dashboardInfo.Hosts, host)
+			}
+		}
+	}
+	resolveConnections(dashboardInfo.Hosts, config.SSHConfig)
+	dashboardInfo.Upstreams = parseUpstreams(config.Upstreams)
+	dashboardInfo.Inspectors = LoadInspectors(config.Inspectors, config.InspectorFiles)
+	dashboardInfo.Plugins = LoadPlugins(config.Plugins)
+	dashboardInfo.Units = LoadUnits(config.Units)
 	dashboardInfo.Metrics = coerceMetrics(config.Metrics)
 	for _, host := range dashboardInfo.Hosts {
 		log.Debugf("%s: %v", host.Address, host.Connection)
@@ -118,18 +341,137 @@ func GetDashboardInfoConfig(config *Config) *DashboardInfo {
 	return dashboardInfo
 }
 
+func parseUpstreams(upstreams map[string]Upstream) []Upstream {
+	names := make([]string, 0, len(upstreams))
+	for name := range upstreams {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parsed := []Upstream{}
+	for _, name := range names {
+		upstream := upstreams[name]
+		upstream.Name = name
+		if upstream.URL == "" {
+			log.Fatalf("Must specify url for upstream %s", name)
+		}
+		if upstream.Mode == "" {
+			upstream.Mode = "prefix"
+		}
+		if !containsString([]string{"prefix", "group"}, upstream.Mode) {
+			log.Fatalf("%s is not a valid mode for upstream %s, use prefix or group", upstream.Mode, name)
+		}
+		parsed = append(parsed, upstream)
+	}
+	return parsed
+}
+
 func parseConnection(conn map[interface{}]interface{}) *Connection {
 	var c Connection
 	mapstructure.Decode(conn, &c)
-	if c.Type == "ssh" && c.Port == 0 {
-		c.Port = 22
-	}
-	if c.Password != "" && c.PrivateKeyPath != "" {
+	// both are allowed only when the order of trial is explicit
+	if c.Password != "" && c.PrivateKeyPath != "" && len(c.AuthMethods) == 0 {
 		log.Fatal("Cannot specify both password login and private key login on same connection")
 	}
+	for _, method := range c.AuthMethods {
+		if !containsString(SSHAuthMethods, method) {
+			log.Fatalf("%s is not a valid ssh auth method, use one of %v", method, SSHAuthMethods)
+		}
+	}
+	if c.Become != nil && !containsString([]string{"", "sudo", "doas"}, c.Become.Method) {
+		log.Fatalf("%s is not a valid become method, use sudo or doas", c.Become.Method)
+	}
+	for index := range c.ProxyJump {
+		if c.ProxyJump[index].Host == "" {
+			log.Fatal("Must specify host for every proxy_jump hop")
+		}
+	}
+	for _, target := range c.Targets {
+		if _, _, err := net.SplitHostPort(target.Address); err != nil {
+			log.Fatalf("%s is not a valid portcheck address: %s", target.Address, err)
+		}
+		if !containsString([]string{"", "tcp", "udp"}, target.Protocol) {
+			log.Fatalf("%s is not a valid portcheck protocol, use tcp or udp", target.Protocol)
+		}
+		if target.TLS && target.Protocol == "udp" {
+			log.Fatalf("Cannot use tls on udp portcheck %s", target.Address)
+		}
+	}
+	for _, query := range c.Queries {
+		if query.Name == "" {
+			log.Fatal("Must specify name for every dns query")
+		}
+		if !containsString(DNSRecordTypes, strings.ToUpper(query.Type)) {
+			log.Fatalf("%s is not a valid dns record type, use one of %v", query.Type, DNSRecordTypes)
+		}
+	}
 	return &c
 }
 
+// resolveConnections : fill in unset ssh parameters from the OpenSSH
+// config of each host then apply defaults
+func resolveConnections(hosts []Host, globalSSHConfig string) {
+	loaded := make(map[string]*SSHConfig)
+	for _, host := range hosts {
+		conn := host.Connection
+		if conn.Type == "kubernetes" && conn.Kubeconfig != "" {
+			conn.Kubeconfig = expandHome(conn.Kubeconfig)
+		}
+		if conn.Type != "ssh" {
+			continue
+		}
+		// copy hops so hosts sharing a group connection stay independent
+		conn.ProxyJump = append([]Connection{}, conn.ProxyJump...)
+		sshConfigPath := globalSSHConfig
+		if conn.SSHConfig != "" {
+			sshConfigPath = conn.SSHConfig
+		}
+		if sshConfigPath != "" {
+			sshConfig, ok := loaded[sshConfigPath]
+			if !ok {
+				var err error
+				sshConfig, err = LoadSSHConfig(sshConfigPath)
+				if err != nil {
+					log.Fatalf("Failed to load ssh config %s: %s", sshConfigPath, err)
+				}
+				loaded[sshConfigPath] = sshConfig
+			}
+			sshConfig.Resolve(host.Address, conn)
+		}
+		if conn.Port == 0 {
+			conn.Port = 22
+		}
+		for index := range conn.ProxyJump {
+			if conn.ProxyJump[index].Port == 0 {
+				conn.ProxyJump[index].Port = 22
+			}
+			if conn.ProxyJump[index].Username == "" {
+				conn.ProxyJump[index].Username = conn.Username
+			}
+		}
+	}
+}
+
+// inheritKubernetes : fill in the cluster of a kubernetes connection from
+// the kubernetes connection of its group, pod and container stay per host
+func inheritKubernetes(conn *Connection, parent *Connection, raw map[interface{}]interface{}) {
+	if parent == nil || conn.Type != "kubernetes" || parent.Type != "kubernetes" {
+		return
+	}
+	if conn.Kubeconfig == "" {
+		conn.Kubeconfig = parent.Kubeconfig
+	}
+	if conn.Context == "" {
+		conn.Context = parent.Context
+	}
+	if conn.Namespace == "" {
+		conn.Namespace = parent.Namespace
+	}
+	// kubectl: false on a child overrides kubectl: true of the group
+	if _, ok := raw["kubectl"]; !ok {
+		conn.Kubectl = parent.Kubectl
+	}
+}
+
 func parseConfig(name string, host string, group map[interface{}]interface{}, currentConnection *Connection) []Host {
 	currentConn := currentConnection
 	allHosts := []Host{}
@@ -142,6 +484,7 @@ func parseConfig(name string, host string, group map[interface{}]interface{}, cu
 		}
 
 		currentConn = parseConnection(v)
+		inheritKubernetes(currentConn, currentConnection, v)
 	}
 
 	if children, ok := group["children"]; ok {
@@ -163,11 +506,13 @@ func parseConfig(name string, host string, group map[interface{}]interface{}, cu
 	}
 
 	if !isParent {
-		currentConn.Host = host
+		// copy so hosts sharing a group connection do not overwrite each other
+		hostConn := *currentConn
+		hostConn.Host = host
 
 		newHost := Host{
 			Address:    host,
-			Connection: currentConn,
+			Connection: &hostConn,
 		}
 		if alias, ok := group["alias"]; ok {
 			newHost.Alias = alias.(string)
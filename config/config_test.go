@@ -0,0 +1,59 @@
+package config
+
+import (
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+const testKubernetesHosts = `
+connection:
+  type: kubernetes
+  kubeconfig: /etc/saido/kubeconfig
+  context: production
+  namespace: web
+  kubectl: true
+children:
+  cluster:
+    connection:
+      type: kubernetes
+      namespace: default
+  redis:
+    connection:
+      type: kubernetes
+      namespace: cache
+      pod: redis-0
+      kubectl: false
+  frontend:
+    connection:
+      type: kubernetes
+      context: staging
+      pod: frontend-0
+  db:
+    connection:
+      type: ssh
+      username: root
+`
+
+func TestKubernetesConnectionInheritance(t *testing.T) {
+	var group map[interface{}]interface{}
+	if err := yaml.Unmarshal([]byte(testKubernetesHosts), &group); err != nil {
+		t.Fatal(err)
+	}
+	hosts := make(map[string]*Connection)
+	for _, host := range parseConfig("root", "", group, &Connection{}) {
+		hosts[host.Address] = host.Connection
+	}
+	if cluster := hosts["cluster"]; cluster.Kubeconfig != "/etc/saido/kubeconfig" || cluster.Context != "production" || cluster.Namespace != "default" || !cluster.Kubectl {
+		t.Errorf("Expected cluster to inherit kubeconfig, context and kubectl, found %+v", cluster)
+	}
+	if redis := hosts["redis"]; redis.Kubeconfig != "/etc/saido/kubeconfig" || redis.Namespace != "cache" || redis.Pod != "redis-0" || redis.Kubectl {
+		t.Errorf("Expected redis to keep its namespace and kubectl, found %+v", redis)
+	}
+	if frontend := hosts["frontend"]; frontend.Context != "staging" || frontend.Namespace != "web" {
+		t.Errorf("Expected frontend to keep its context and inherit namespace, found %+v", frontend)
+	}
+	if db := hosts["db"]; db.Kubeconfig != "" || db.Context != "" || db.Namespace != "" {
+		t.Errorf("Expected ssh connection to inherit nothing, found %+v", db)
+	}
+}
@@ -0,0 +1,226 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
+)
+
+// InspectorPlatforms : platforms a declared inspector can run on
+var InspectorPlatforms = []string{"linux", "darwin", "windows"}
+
+// InspectorParsers : supported values for `type` of an inspector parser
+var InspectorParsers = []string{"regex", "columns", "json"}
+
+// InspectorFieldTypes : supported values for `type` of an inspector field
+var InspectorFieldTypes = []string{"number", "string", "bool"}
+
+// InspectorParser : how the output of a declared inspector is read
+type InspectorParser struct {
+	// Type : regex, columns or json
+	Type string `yaml:"type"`
+	// Pattern : regular expression whose named groups are fields
+	Pattern string `yaml:"pattern"`
+	// List : read every match of pattern or every object of a json list
+	// as a row, lines of columns are always rows
+	List bool `yaml:"list"`
+	// Skip : lines skipped before reading columns e.g headers
+	Skip int `yaml:"skip"`
+	// Separator : of columns, defaults to whitespace
+	Separator string `yaml:"separator"`
+	// Columns : field of every column of a line, `-` skips a column
+	Columns []string `yaml:"columns"`
+	// Path : dot separated path to the object or list of objects read
+	// from json output, the whole output when empty
+	Path string `yaml:"path"`
+}
+
+// InspectorField : value read by a declared inspector
+type InspectorField struct {
+	Name string `yaml:"name"`
+	// Type : number (default), string or bool
+	Type string `yaml:"type"`
+	Unit string `yaml:"unit"`
+	// Kind : gauge (default) or counter for numbers
+	Kind        string `yaml:"kind"`
+	Description string `yaml:"description"`
+	// Path : dot separated path of the field in json objects, defaults
+	// to name
+	Path string `yaml:"path"`
+}
+
+// InspectorDefinition : inspector declared in yaml instead of Go
+type InspectorDefinition struct {
+	Name        string `yaml:"-"`
+	Description string `yaml:"description"`
+	// Commands : command run on each platform e.g linux: chronyc tracking
+	Commands map[string]string `yaml:"commands"`
+	// Files : file read on platforms without a command
+	Files  map[string]string `yaml:"files"`
+	Parser InspectorParser   `yaml:"parser"`
+	Fields []InspectorField  `yaml:"fields"`
+}
+
+// inspectorFile : inspectors are declared under `inspectors` in files
+// listed by `inspector-files`
+type inspectorFile struct {
+	Inspectors map[string]InspectorDefinition `yaml:"inspectors"`
+}
+
+// LoadInspectors : inspectors declared in config followed by those of
+// inspector files, patterns of files are expanded as globs
+func LoadInspectors(inspectors map[string]InspectorDefinition, files []string) []InspectorDefinition {
+	declared := make(map[string]InspectorDefinition)
+	for name, definition := range inspectors {
+		declared[name] = definition
+	}
+	for _, pattern := range files {
+		paths, err := filepath.Glob(expandHome(pattern))
+		if err != nil {
+			log.Fatalf("%s is not a valid inspector file pattern: %s", pattern, err)
+		}
+		if len(paths) == 0 {
+			log.Fatalf("No inspector files match %s", pattern)
+		}
+		for _, path := range paths {
+			content, err := ioutil.ReadFile(path)
+			if err != nil {
+				log.Fatalf("Failed to read inspector file %s: %s", path, err)
+			}
+			var file inspectorFile
+			if err := yaml.Unmarshal(content, &file); err != nil {
+				log.Fatalf("Failed to parse inspector file %s: %s", path, err)
+			}
+			for name, definition := range file.Inspectors {
+				if _, ok := declared[name]; ok {
+					log.Fatalf("Inspector %s of %s is already declared", name, path)
+				}
+				declared[name] = definition
+			}
+		}
+	}
+	names := make([]string, 0, len(declared))
+	for name := range declared {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parsed := []InspectorDefinition{}
+	for _, name := range names {
+		definition := declared[name]
+		definition.Name = name
+		if err := validateInspector(&definition); err != nil {
+			log.Fatalf("Invalid inspector %s: %s", name, err)
+		}
+		parsed = append(parsed, definition)
+	}
+	return parsed
+}
+
+// validateInspector : check a definition and apply defaults
+func validateInspector(definition *InspectorDefinition) error {
+	if len(definition.Commands) == 0 && len(definition.Files) == 0 {
+		return fmt.Errorf("must specify commands or files")
+	}
+	for _, platforms := range []map[string]string{definition.Commands, definition.Files} {
+		for platform := range platforms {
+			if !containsString(InspectorPlatforms, platform) {
+				return fmt.Errorf("%s is not a valid platform, use one of %v", platform, InspectorPlatforms)
+			}
+		}
+	}
+	parser := definition.Parser
+	if !containsString(InspectorParsers, parser.Type) {
+		return fmt.Errorf("%s is not a valid parser, use one of %v", parser.Type, InspectorParsers)
+	}
+	if len(definition.Fields) == 0 {
+		return fmt.Errorf("must specify fields")
+	}
+	fields := []string{}
+	for index := range definition.Fields {
+		field := &definition.Fields[index]
+		if field.Name == "" {
+			return fmt.Errorf("must specify name for every field")
+		}
+		if containsString(fields, field.Name) {
+			return fmt.Errorf("field %s is declared twice", field.Name)
+		}
+		fields = append(fields, field.Name)
+		if field.Type == "" {
+			field.Type = "number"
+		}
+		if !containsString(InspectorFieldTypes, field.Type) {
+			return fmt.Errorf("%s is not a valid type of field %s, use one of %v", field.Type, field.Name, InspectorFieldTypes)
+		}
+		if field.Type == "number" && field.Kind == "" {
+			field.Kind = "gauge"
+		}
+		if !containsString([]string{"", "gauge", "counter"}, field.Kind) {
+			return fmt.Errorf("%s is not a valid kind of field %s, use gauge or counter", field.Kind, field.Name)
+		}
+		if field.Path == "" {
+			field.Path = field.Name
+		}
+	}
+	switch parser.Type {
+	case "regex":
+		pattern, err := regexp.Compile(parser.Pattern)
+		if err != nil {
+			return fmt.Errorf("invalid pattern: %s", err)
+		}
+		for _, field := range fields {
+			if pattern.SubexpIndex(field) < 0 {
+				return fmt.Errorf("pattern has no group named %s", field)
+			}
+		}
+	case "columns":
+		definition.Parser.List = true
+		for _, field := range fields {
+			if !containsString(parser.Columns, field) {
+				return fmt.Errorf("columns do not include field %s", field)
+			}
+		}
+	}
+	return nil
+}
+
+// PluginDefinition : inspector run as a subprocess exchanging JSON lines
+// with saido over its stdin and stdout
+type PluginDefinition struct {
+	Name string `yaml:"-"`
+	// Command : executable of the plugin
+	Command string   `yaml:"command"`
+	Args    []string `yaml:"args"`
+	// Timeout : seconds to wait for the plugin on every run, defaults to 10
+	Timeout int `yaml:"timeout"`
+}
+
+// LoadPlugins : plugins sorted by name with defaults applied
+func LoadPlugins(plugins map[string]PluginDefinition) []PluginDefinition {
+	names := make([]string, 0, len(plugins))
+	for name := range plugins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parsed := []PluginDefinition{}
+	for _, name := range names {
+		plugin := plugins[name]
+		plugin.Name = name
+		if plugin.Command == "" {
+			log.Fatalf("Must specify command for plugin %s", name)
+		}
+		plugin.Command = expandHome(plugin.Command)
+		if plugin.Timeout < 0 {
+			log.Fatalf("Cannot set negative timeout for plugin %s", name)
+		}
+		if plugin.Timeout == 0 {
+			plugin.Timeout = 10
+		}
+		parsed = append(parsed, plugin)
+	}
+	return parsed
+}
@@ -0,0 +1,104 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testInspectorFile = `
+inspectors:
+  sensors:
+    commands:
+      linux: sensors -j
+    parser:
+      type: json
+      path: sensors
+      list: true
+    fields:
+      - name: temp
+        path: temp.current
+        unit: C
+  users:
+    files:
+      darwin: /etc/passwd
+    parser:
+      type: columns
+      separator: ':'
+      columns: [name, '-', uid]
+    fields:
+      - name: name
+        type: string
+      - name: uid
+`
+
+func TestLoadInspectors(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "sensors.yaml"), []byte(testInspectorFile), 0600)
+	declared := map[string]InspectorDefinition{
+		"ntp": {
+			Commands: map[string]string{"linux": "chronyc tracking"},
+			Parser:   InspectorParser{Type: "regex", Pattern: `System time\s+:\s+(?P<offset>[\d.]+)`},
+			Fields:   []InspectorField{{Name: "offset", Unit: "s"}},
+		},
+	}
+	inspectors := LoadInspectors(declared, []string{filepath.Join(dir, "*.yaml")})
+	if len(inspectors) != 3 || inspectors[0].Name != "ntp" || inspectors[2].Name != "users" {
+		t.Fatalf("Unexpected inspectors %+v", inspectors)
+	}
+	offset := inspectors[0].Fields[0]
+	if offset.Type != "number" || offset.Kind != "gauge" || offset.Path != "offset" {
+		t.Errorf("Expected defaults of offset, found %+v", offset)
+	}
+	if temp := inspectors[1].Fields[0]; temp.Path != "temp.current" || temp.Unit != "C" {
+		t.Errorf("Unexpected temp %+v", temp)
+	}
+	if users := inspectors[2]; !users.Parser.List || users.Fields[0].Kind != "" {
+		t.Errorf("Expected columns to read rows, found %+v", users)
+	}
+}
+
+func TestValidateInspector(t *testing.T) {
+	valid := func() InspectorDefinition {
+		return InspectorDefinition{
+			Commands: map[string]string{"linux": "chronyc tracking"},
+			Parser:   InspectorParser{Type: "regex", Pattern: `(?P<offset>[\d.]+)`},
+			Fields:   []InspectorField{{Name: "offset"}},
+		}
+	}
+	invalid := map[string]func(*InspectorDefinition){
+		"no command": func(d *InspectorDefinition) { d.Commands = nil },
+		"platform":   func(d *InspectorDefinition) { d.Commands["solaris"] = "ntpq" },
+		"parser":     func(d *InspectorDefinition) { d.Parser.Type = "xml" },
+		"pattern":    func(d *InspectorDefinition) { d.Parser.Pattern = `(` },
+		"group":      func(d *InspectorDefinition) { d.Parser.Pattern = `(?P<skew>.*)` },
+		"type":       func(d *InspectorDefinition) { d.Fields[0].Type = "date" },
+		"kind":       func(d *InspectorDefinition) { d.Fields[0].Kind = "rate" },
+		"duplicate":  func(d *InspectorDefinition) { d.Fields = append(d.Fields, d.Fields[0]) },
+		"columns":    func(d *InspectorDefinition) { d.Parser = InspectorParser{Type: "columns", Columns: []string{"skew"}} },
+	}
+	for name, change := range invalid {
+		definition := valid()
+		change(&definition)
+		if err := validateInspector(&definition); err == nil {
+			t.Errorf("Expected error for invalid %s", name)
+		}
+	}
+	definition := valid()
+	if err := validateInspector(&definition); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestLoadPlugins(t *testing.T) {
+	plugins := LoadPlugins(map[string]PluginDefinition{
+		"users": {Command: "/opt/saido/users", Args: []string{"--all"}},
+		"mysql": {Command: "/opt/saido/mysql", Timeout: 30},
+	})
+	if len(plugins) != 2 || plugins[0].Name != "mysql" || plugins[0].Timeout != 30 {
+		t.Fatalf("Unexpected plugins %+v", plugins)
+	}
+	if users := plugins[1]; users.Timeout != 10 || users.Args[0] != "--all" {
+		t.Errorf("Expected default timeout of users, found %+v", users)
+	}
+}
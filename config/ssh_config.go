@@ -0,0 +1,207 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+type sshConfigBlock struct {
+	// Host patterns, nil for `Match` blocks which are not supported
+	patterns []string
+	options  map[string]string
+}
+
+// SSHConfig : parsed OpenSSH client config e.g ~/.ssh/config
+type SSHConfig struct {
+	blocks []sshConfigBlock
+}
+
+// LoadSSHConfig : read and parse OpenSSH client config from path
+func LoadSSHConfig(configPath string) (*SSHConfig, error) {
+	file, err := os.Open(expandHome(configPath))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ParseSSHConfig(file)
+}
+
+// ParseSSHConfig : parse the Host blocks of an OpenSSH client config,
+// options before the first Host apply to every host
+func ParseSSHConfig(r io.Reader) (*SSHConfig, error) {
+	config := &SSHConfig{}
+	current := sshConfigBlock{
+		patterns: []string{"*"},
+		options:  make(map[string]string),
+	}
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		keyword, args := splitSSHConfigLine(line)
+		if len(args) == 0 {
+			return nil, fmt.Errorf("ssh config line %d: missing argument for %s", lineNo, keyword)
+		}
+		switch keyword {
+		case "host":
+			config.blocks = append(config.blocks, current)
+			current = sshConfigBlock{
+				patterns: args,
+				options:  make(map[string]string),
+			}
+		case "match":
+			config.blocks = append(config.blocks, current)
+			current = sshConfigBlock{
+				options: make(map[string]string),
+			}
+		default:
+			// first obtained value is used
+			if _, ok := current.options[keyword]; !ok {
+				current.options[keyword] = strings.Join(args, " ")
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	config.blocks = append(config.blocks, current)
+	return config, nil
+}
+
+// splitSSHConfigLine : keywords are case insensitive and may be separated
+// from arguments by whitespace or `=`, arguments may be quoted
+func splitSSHConfigLine(line string) (string, []string) {
+	index := strings.IndexAny(line, " \t=")
+	if index == -1 {
+		return strings.ToLower(line), nil
+	}
+	keyword := strings.ToLower(line[:index])
+	rest := strings.TrimLeft(line[index:], " \t")
+	rest = strings.TrimPrefix(rest, "=")
+	var (
+		args    []string
+		current strings.Builder
+		quoted  bool
+	)
+	for _, char := range rest {
+		switch {
+		case char == '"':
+			quoted = !quoted
+		case (char == ' ' || char == '\t') && !quoted:
+			if current.Len() > 0 {
+				args = append(args, current.String())
+				current.Reset()
+			}
+		default:
+			current.WriteRune(char)
+		}
+	}
+	if current.Len() > 0 {
+		args = append(args, current.String())
+	}
+	return keyword, args
+}
+
+func (b sshConfigBlock) matches(alias string) bool {
+	matched := false
+	for _, pattern := range b.patterns {
+		negated := strings.HasPrefix(pattern, "!")
+		pattern = strings.TrimPrefix(pattern, "!")
+		ok, _ := path.Match(pattern, alias)
+		if ok && negated {
+			return false
+		}
+		if ok {
+			matched = true
+		}
+	}
+	return matched
+}
+
+// Get : value of keyword for host alias from the first block that sets it
+func (c *SSHConfig) Get(alias, keyword string) string {
+	keyword = strings.ToLower(keyword)
+	for _, block := range c.blocks {
+		if !block.matches(alias) {
+			continue
+		}
+		if value, ok := block.options[keyword]; ok {
+			return value
+		}
+	}
+	return ""
+}
+
+// Resolve : fill in connection parameters not set in saido config using
+// HostName, User, Port, IdentityFile and ProxyJump of the host alias
+func (c *SSHConfig) Resolve(alias string, conn *Connection) {
+	c.resolve(alias, conn, true)
+}
+
+func (c *SSHConfig) resolve(alias string, conn *Connection, withProxyJump bool) {
+	if hostname := c.Get(alias, "HostName"); hostname != "" && conn.Host == alias {
+		conn.Host = strings.ReplaceAll(hostname, "%h", alias)
+	}
+	if user := c.Get(alias, "User"); user != "" && conn.Username == "" {
+		conn.Username = user
+	}
+	if port := c.Get(alias, "Port"); port != "" && conn.Port == 0 {
+		if parsed, err := strconv.Atoi(port); err == nil {
+			conn.Port = int32(parsed)
+		}
+	}
+	identity := c.Get(alias, "IdentityFile")
+	usesKey := conn.Password == "" || len(conn.AuthMethods) != 0
+	if identity != "" && conn.PrivateKeyPath == "" && usesKey {
+		conn.PrivateKeyPath = expandHome(strings.ReplaceAll(identity, "%d", "~"))
+	}
+	proxyJump := c.Get(alias, "ProxyJump")
+	if !withProxyJump || proxyJump == "" || proxyJump == "none" || len(conn.ProxyJump) != 0 {
+		return
+	}
+	for _, jump := range strings.Split(proxyJump, ",") {
+		hop := parseJumpSpec(jump)
+		// hops are resolved against the same file but their own
+		// ProxyJump is ignored to avoid cycles
+		c.resolve(hop.Host, &hop, false)
+		conn.ProxyJump = append(conn.ProxyJump, hop)
+	}
+}
+
+// parseJumpSpec : parse [user@]host[:port]
+func parseJumpSpec(spec string) Connection {
+	var hop Connection
+	spec = strings.TrimSpace(spec)
+	if at := strings.LastIndex(spec, "@"); at != -1 {
+		hop.Username = spec[:at]
+		spec = spec[at+1:]
+	}
+	if colon := strings.LastIndex(spec, ":"); colon != -1 && !strings.HasSuffix(spec, "]") {
+		if port, err := strconv.Atoi(spec[colon+1:]); err == nil {
+			hop.Port = int32(port)
+			spec = spec[:colon]
+		}
+	}
+	hop.Host = strings.Trim(spec, "[]")
+	return hop
+}
+
+func expandHome(filePath string) string {
+	if filePath == "~" || strings.HasPrefix(filePath, "~/") {
+		home, err := os.UserHomeDir()
+		if err == nil {
+			return filepath.Join(home, strings.TrimPrefix(filePath, "~"))
+		}
+	}
+	return filePath
+}
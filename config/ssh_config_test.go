@@ -0,0 +1,59 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+const testSSHConfig = `
+User default-user
+
+Host bastion
+    HostName bastion.example.net
+    User jump
+    Port 2200
+
+Host web-* !web-legacy
+    HostName %h.internal.example.net
+    IdentityFile ~/.ssh/web_ed25519
+    ProxyJump bastion
+
+Host *
+    Port 22
+    User fallback
+`
+
+func TestSSHConfigResolve(t *testing.T) {
+	sshConfig, err := ParseSSHConfig(strings.NewReader(testSSHConfig))
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn := &Connection{Type: "ssh", Host: "web-1"}
+	sshConfig.Resolve("web-1", conn)
+	if conn.Host != "web-1.internal.example.net" {
+		t.Errorf("Expected HostName to be resolved, got %s", conn.Host)
+	}
+	if conn.Username != "default-user" || conn.Port != 22 {
+		t.Errorf("Expected first obtained values to be used, got %s %d", conn.Username, conn.Port)
+	}
+	if !strings.HasSuffix(conn.PrivateKeyPath, "/.ssh/web_ed25519") || strings.HasPrefix(conn.PrivateKeyPath, "~") {
+		t.Errorf("Expected expanded identity file, got %s", conn.PrivateKeyPath)
+	}
+	if len(conn.ProxyJump) != 1 || conn.ProxyJump[0].Host != "bastion.example.net" || conn.ProxyJump[0].Port != 2200 {
+		t.Errorf("Expected bastion to be resolved as jump host, got %+v", conn.ProxyJump)
+	}
+
+	// values set in saido config take precedence and negated patterns do not match
+	legacy := &Connection{Type: "ssh", Host: "web-legacy", Username: "root", Password: "secret"}
+	sshConfig.Resolve("web-legacy", legacy)
+	if legacy.Host != "web-legacy" || legacy.Username != "root" || legacy.PrivateKeyPath != "" || len(legacy.ProxyJump) != 0 {
+		t.Errorf("Unexpected resolution for negated host %+v", legacy)
+	}
+}
+
+func TestParseJumpSpec(t *testing.T) {
+	hop := parseJumpSpec("admin@10.0.0.1:2222")
+	if hop.Username != "admin" || hop.Host != "10.0.0.1" || hop.Port != 2222 {
+		t.Errorf("Could not parse jump spec %+v", hop)
+	}
+}
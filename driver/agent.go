@@ -0,0 +1,175 @@
+package driver
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var (
+	// DefaultAgentPort : port `saido agent` listens on
+	DefaultAgentPort = 3100
+	agentTimeout     = 30 * time.Second
+)
+
+type AgentError struct {
+	content string
+	agent   string
+}
+
+func (e *AgentError) Error() string {
+	return fmt.Sprintf("Agent Error on %s: %s", e.agent, e.content)
+}
+
+// AgentInfo : response of the agent info endpoint
+type AgentInfo struct {
+	Version  string
+	Hostname string
+	Platform SystemDetails
+}
+
+// AgentResult : response of the agent metric endpoint
+type AgentResult struct {
+	Name string
+	// Data is the output of the inspector
+	Data json.RawMessage `json:",omitempty"`
+	// Units of fields of Data
+	Units map[string]string `json:",omitempty"`
+	// CollectedAt is when the agent ran the inspector, older than the
+	// request when served from its cache
+	CollectedAt time.Time
+	// Error is set along with Data when only part of the output of the
+	// inspector could be parsed
+	Error string `json:",omitempty"`
+}
+
+// AgentProvider : drivers fetching inspector results collected remotely
+type AgentProvider interface {
+	AgentMetric(name string, custom string) (*AgentResult, error)
+}
+
+// Agent : Driver for fetching metrics from a `saido agent`
+type Agent struct {
+	driverBase
+	Host string
+	Port int
+	// Token sent as bearer token to the agent
+	Token string
+	// TLS connects over https
+	TLS bool
+	// InsecureSkipVerify skips verification of the agent certificate
+	InsecureSkipVerify bool
+	client             *http.Client
+	info               *AgentInfo
+}
+
+func (d *Agent) String() string {
+	return fmt.Sprintf("agent (%s)", d.address())
+}
+
+func (d *Agent) address() string {
+	scheme := "http"
+	if d.TLS {
+		scheme = "https"
+	}
+	port := d.Port
+	if port == 0 {
+		port = DefaultAgentPort
+	}
+	return fmt.Sprintf("%s://%s:%d", scheme, d.Host, port)
+}
+
+func (d *Agent) get(path string, out interface{}) error {
+	if d.client == nil {
+		d.client = &http.Client{
+			Timeout: agentTimeout,
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: d.InsecureSkipVerify},
+			},
+		}
+	}
+	req, err := http.NewRequest(http.MethodGet, d.address()+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+d.Token)
+	res, err := d.client.Do(req)
+	if err != nil {
+		return &AgentError{content: err.Error(), agent: d.address()}
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		var result AgentResult
+		json.NewDecoder(res.Body).Decode(&result)
+		return &AgentError{
+			content: fmt.Sprintf("agent returned %d: %s", res.StatusCode, result.Error),
+			agent:   d.address(),
+		}
+	}
+	if err = json.NewDecoder(res.Body).Decode(out); err != nil {
+		return &AgentError{
+			content: fmt.Sprintf("invalid response: %s", err),
+			agent:   d.address(),
+		}
+	}
+	return nil
+}
+
+// AgentInfo : version and platform reported by the agent
+func (d *Agent) AgentInfo() (AgentInfo, error) {
+	if d.info == nil {
+		var info AgentInfo
+		if err := d.get("/info", &info); err != nil {
+			return AgentInfo{}, err
+		}
+		d.info = &info
+	}
+	return *d.info, nil
+}
+
+// AgentMetric : fetch output of inspector name from the agent, output
+// that could only partly be parsed is returned along with the error
+func (d *Agent) AgentMetric(name string, custom string) (*AgentResult, error) {
+	query := url.Values{}
+	if custom != "" {
+		query.Set("custom", custom)
+	}
+	path := fmt.Sprintf("/metrics/%s", url.PathEscape(name))
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+	var result AgentResult
+	if err := d.get(path, &result); err != nil {
+		return nil, err
+	}
+	if result.Error != "" {
+		return &result, &AgentError{content: result.Error, agent: d.address()}
+	}
+	return &result, nil
+}
+
+func (d *Agent) ReadFile(path string) (string, error) {
+	return ``, errors.New("Cannot read files through an agent, metrics are collected by the agent")
+}
+
+func (d *Agent) RunCommand(command string) (string, error) {
+	return ``, errors.New("Cannot run commands through an agent, metrics are collected by the agent")
+}
+
+func (d *Agent) GetDetails() (SystemDetails, error) {
+	if d.Info == nil {
+		info, err := d.AgentInfo()
+		if err != nil {
+			return SystemDetails{}, err
+		}
+		details := info.Platform
+		details.Extra = strings.TrimSpace(fmt.Sprintf("%s agent %s", details.Extra, info.Version))
+		d.Info = &details
+	}
+	return *d.Info, nil
+}
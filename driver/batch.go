@@ -0,0 +1,141 @@
+package driver
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Batcher : drivers running the commands of a poll in one round trip,
+// commands run during a poll are batched on the next one
+type Batcher interface {
+	// StartBatch : run the commands of the last poll at once and serve
+	// them from the results until end is called. A batch still open for
+	// an overlapping poll is left to it and end does nothing
+	StartBatch() (end func(), err error)
+}
+
+// BatchCommandError : command of a batch that exited with a non zero
+// status, formatted like errors of single ssh commands
+type BatchCommandError struct {
+	status int
+}
+
+func (e *BatchCommandError) Error() string {
+	return fmt.Sprintf("Process exited with status %d", e.status)
+}
+
+type batchResult struct {
+	output string
+	err    error
+}
+
+// commandBatch : commands seen during a poll and results of the batch
+// run at its start
+type commandBatch struct {
+	mu      sync.Mutex
+	active  bool
+	seen    []string
+	results map[string]batchResult
+}
+
+// start : commands of the last poll, recording commands of this one,
+// false while the batch of another poll is open
+func (b *commandBatch) start() ([]string, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.active {
+		return nil, false
+	}
+	commands := b.seen
+	b.seen = nil
+	b.results = make(map[string]batchResult)
+	b.active = true
+	return commands, true
+}
+
+func (b *commandBatch) fill(results map[string]batchResult) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for command, result := range results {
+		b.results[command] = result
+	}
+}
+
+// result : record command and return its result if it was batched
+func (b *commandBatch) result(command string) (batchResult, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.active {
+		return batchResult{}, false
+	}
+	seen := false
+	for _, compare := range b.seen {
+		seen = seen || compare == command
+	}
+	if !seen {
+		b.seen = append(b.seen, command)
+	}
+	result, ok := b.results[command]
+	return result, ok
+}
+
+func (b *commandBatch) end() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.active = false
+	b.results = nil
+}
+
+// batchMarker : delimiter of sections unlikely to appear in output
+func batchMarker() string {
+	nonce := make([]byte, 8)
+	rand.Read(nonce)
+	return "SAIDO-BATCH-" + hex.EncodeToString(nonce)
+}
+
+// batchScript : shell script running every command in a subshell so an
+// exit or failure of one does not end the others, every section is
+// delimited by marker and index and ends with the exit status
+func batchScript(marker string, commands []string) string {
+	var script strings.Builder
+	for index, command := range commands {
+		fmt.Fprintf(&script, "printf '%s %d\\n'\n(%s\n) 2>&1 </dev/null\nprintf '\\n%s %d %%d\\n' $?\n",
+			marker, index, command, marker, index)
+	}
+	return script.String()
+}
+
+// splitBatch : results of the sections of output, commands whose section
+// is missing or incomplete are left out to be run alone
+func splitBatch(marker string, commands []string, output string) map[string]batchResult {
+	results := make(map[string]batchResult)
+	for index, command := range commands {
+		start := fmt.Sprintf("%s %d\n", marker, index)
+		begin := strings.Index(output, start)
+		if begin < 0 {
+			continue
+		}
+		section := output[begin+len(start):]
+		endMarker := fmt.Sprintf("\n%s %d ", marker, index)
+		end := strings.Index(section, endMarker)
+		if end < 0 {
+			continue
+		}
+		statusLine := strings.SplitN(section[end+len(endMarker):], "\n", 2)[0]
+		status, err := strconv.Atoi(strings.TrimSpace(statusLine))
+		if err != nil {
+			continue
+		}
+		// output of failed commands is kept to diagnose them
+		result := batchResult{output: section[:end]}
+		if status != 0 {
+			result.err = &BatchCommandError{status: status}
+		}
+		results[command] = result
+	}
+	return results
+}
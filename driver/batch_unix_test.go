@@ -0,0 +1,82 @@
+//go:build !windows
+// +build !windows
+
+package driver
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBatchScript(t *testing.T) {
+	commands := []string{
+		`cat /proc/self/status | head -1`,
+		`printf 'no newline'`,
+		`exit 3`,
+		`saido-missing-command`,
+		`echo "still running" # after a comment`,
+	}
+	marker := batchMarker()
+	d := Local{}
+	output, err := d.RunCommand(batchScript(marker, commands))
+	if err != nil {
+		t.Fatal(err)
+	}
+	results := splitBatch(marker, commands, output)
+	if len(results) != len(commands) {
+		t.Fatalf("Expected a result of every command, found %+v", results)
+	}
+	if status := results[commands[0]]; status.err != nil || !strings.HasPrefix(status.output, "Name:") {
+		t.Errorf("Unexpected status %+v", status)
+	}
+	if printed := results[commands[1]]; printed.output != "no newline" {
+		t.Errorf("Expected output without added newline, found %q", printed.output)
+	}
+	if exited := results[commands[2]]; exited.err == nil || exited.err.Error() != "Process exited with status 3" {
+		t.Errorf("Expected exit to fail only its command, found %+v", exited)
+	}
+	if missing := results[commands[3]]; missing.err == nil || !strings.Contains(missing.err.Error(), "127") {
+		t.Errorf("Expected missing command to exit with 127, found %+v", missing)
+	}
+	if last := results[commands[4]]; last.output != "still running\n" {
+		t.Errorf("Expected commands after a failure to run, found %+v", last)
+	}
+	// sections cut off are run alone
+	cut := splitBatch(marker, commands, output[:strings.Index(output, "no newline")])
+	if _, ok := cut[commands[1]]; ok || len(cut) != 1 {
+		t.Errorf("Expected only the complete section, found %+v", cut)
+	}
+}
+
+func TestCommandBatch(t *testing.T) {
+	var batch commandBatch
+	if _, ok := batch.result(`uname`); ok {
+		t.Error("Expected no results outside of a batch")
+	}
+	if commands, ok := batch.start(); !ok || len(commands) != 0 {
+		t.Errorf("Expected nothing to batch on the first poll, found %v", commands)
+	}
+	batch.result(`df -a -k`)
+	batch.result(`cat /proc/loadavg`)
+	batch.result(`df -a -k`)
+	batch.end()
+	commands, _ := batch.start()
+	if len(commands) != 2 || commands[0] != `df -a -k` {
+		t.Fatalf("Expected commands of the last poll, found %v", commands)
+	}
+	// an overlapping poll leaves the open batch alone
+	if _, ok := batch.start(); ok {
+		t.Error("Expected no batch to start while one is open")
+	}
+	batch.fill(map[string]batchResult{`df -a -k`: {output: "Filesystem"}})
+	if result, ok := batch.result(`df -a -k`); !ok || result.output != "Filesystem" {
+		t.Errorf("Expected batched result, found %+v", result)
+	}
+	if _, ok := batch.result(`cat /proc/loadavg`); ok {
+		t.Error("Expected command missing from the batch to be run alone")
+	}
+	batch.end()
+	if _, ok := batch.result(`df -a -k`); ok {
+		t.Error("Expected results to be dropped after the batch")
+	}
+}
@@ -0,0 +1,183 @@
+package driver
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// InputRunner : drivers that can write to the stdin of a command
+type InputRunner interface {
+	RunCommandWithInput(command string, input string) (string, error)
+}
+
+type PermissionError struct {
+	content string
+	client  string
+}
+
+func (e *PermissionError) Error() string {
+	return fmt.Sprintf("Permission Error on %s: %s", e.client, e.content)
+}
+
+// permissionDenied : output of sudo, doas and commands lacking privilege
+var permissionDenied = []string{
+	"a password is required",
+	"incorrect password",
+	"is not in the sudoers file",
+	"is not allowed to run sudo",
+	"authentication failed",
+	"not permitted",
+	"permission denied",
+}
+
+// Become : privilege escalation settings
+type Become struct {
+	// Method is one of sudo (default) or doas
+	Method string
+	// User to run as, defaults to root
+	User string
+	// Password is written to stdin of sudo, doas only supports nopass rules
+	Password string
+}
+
+// Privileged : Driver running every command of the wrapped driver
+// with privilege escalation
+type Privileged struct {
+	Driver Driver
+	Become Become
+}
+
+func shellQuote(command string) string {
+	return fmt.Sprintf(`'%s'`, strings.ReplaceAll(command, `'`, `'\''`))
+}
+
+// wrap : wraps command to run as the become user returning the input to
+// be written to stdin. stdin of the command itself is detached unless
+// input is written to it so a password is never read by anything but sudo
+func (b Become) wrap(command string, input string) (string, string, error) {
+	user := b.User
+	if user == "" {
+		user = "root"
+	}
+	if input != "" && b.Password != "" {
+		return "", "", errors.New("Cannot write to stdin of commands run with a become password")
+	}
+	if input == "" {
+		command = "exec </dev/null; " + command
+	}
+	shell := fmt.Sprintf("sh -c %s", shellQuote(command))
+	switch b.Method {
+	case "", "sudo":
+		if b.Password == "" {
+			return fmt.Sprintf("sudo -n -u %s -- %s", shellQuote(user), shell), input, nil
+		}
+		return fmt.Sprintf("sudo -S -p '' -u %s -- %s", shellQuote(user), shell), b.Password + "\n", nil
+	case "doas":
+		if b.Password != "" {
+			return "", "", errors.New("doas cannot read password non-interactively, use a nopass rule instead")
+		}
+		return fmt.Sprintf("doas -n -u %s %s", shellQuote(user), shell), input, nil
+	}
+	return "", "", fmt.Errorf("Unsupported become method %s", b.Method)
+}
+
+func (d *Privileged) String() string {
+	user := d.Become.User
+	if user == "" {
+		user = "root"
+	}
+	return fmt.Sprintf("%v as %s", d.Driver, user)
+}
+
+func (d *Privileged) client(details SystemDetails) string {
+	if stringer, ok := d.Driver.(fmt.Stringer); ok {
+		return stringer.String()
+	}
+	return details.Name
+}
+
+func (d *Privileged) ReadFile(path string) (string, error) {
+	log.Debugf("Reading content with privilege %s", path)
+	return d.RunCommand(fmt.Sprintf(`cat %s`, shellQuote(path)))
+}
+
+func (d *Privileged) RunCommand(command string) (string, error) {
+	return d.RunCommandWithInput(command, "")
+}
+
+// RunCommandWithInput : run command with privilege writing input to its
+// stdin, not supported along with a become password read from stdin
+func (d *Privileged) RunCommandWithInput(command string, input string) (string, error) {
+	details, err := d.Driver.GetDetails()
+	if err != nil {
+		return ``, err
+	}
+	if !(details.IsLinux || details.IsDarwin) {
+		return ``, fmt.Errorf("Cannot use become on drivers outside (linux, darwin), found %s", details.Name)
+	}
+	runner, ok := d.Driver.(InputRunner)
+	if !ok {
+		return ``, errors.New("Driver does not support privilege escalation")
+	}
+	wrapped, stdin, err := d.Become.wrap(command, input)
+	if err != nil {
+		return ``, err
+	}
+	out, err := runner.RunCommandWithInput(wrapped, stdin)
+	if err != nil {
+		lowered := strings.ToLower(out)
+		for _, denied := range permissionDenied {
+			if strings.Contains(lowered, denied) {
+				return ``, &PermissionError{
+					content: strings.TrimSpace(out),
+					client:  d.client(details),
+				}
+			}
+		}
+		return ``, err
+	}
+	return out, nil
+}
+
+func (d *Privileged) GetDetails() (SystemDetails, error) {
+	return d.Driver.GetDetails()
+}
+
+// DockerEngine : engine of the wrapped driver, the engine API is not run
+// with privilege escalation
+func (d *Privileged) DockerEngine() (*DockerEngine, error) {
+	provider, ok := d.Driver.(DockerEngineProvider)
+	if !ok {
+		return nil, errors.New("Driver cannot reach a docker engine")
+	}
+	return provider.DockerEngine()
+}
+
+// KubernetesGet : request of the wrapped driver to the API server
+func (d *Privileged) KubernetesGet(path string, out interface{}) error {
+	provider, ok := d.Driver.(KubernetesProvider)
+	if !ok {
+		return errors.New("Driver cannot reach a kubernetes API")
+	}
+	return provider.KubernetesGet(path, out)
+}
+
+// KubernetesNamespace : namespace of the wrapped driver
+func (d *Privileged) KubernetesNamespace() string {
+	if provider, ok := d.Driver.(KubernetesProvider); ok {
+		return provider.KubernetesNamespace()
+	}
+	return ""
+}
+
+// StartBatch : batch commands of the wrapped driver, privileged commands
+// without a password are batched along with the others
+func (d *Privileged) StartBatch() (func(), error) {
+	if batcher, ok := d.Driver.(Batcher); ok {
+		return batcher.StartBatch()
+	}
+	return func() {}, nil
+}
@@ -0,0 +1,60 @@
+//go:build !windows
+// +build !windows
+
+package driver
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeSudo : checks the password written to stdin before running the command
+const fakeSudo = `#!/bin/sh
+if [ "$1" = "-S" ]; then
+  read password
+  if [ "$password" != "secret" ]; then
+    echo "sudo: 1 incorrect password attempt" >&2
+    exit 1
+  fi
+fi
+while [ "$1" != "--" ]; do shift; done
+shift
+exec "$@"
+`
+
+func TestPrivilegedRunCommand(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "sudo"), []byte(fakeSudo), 0755)
+	t.Setenv("PATH", dir+":"+os.Getenv("PATH"))
+
+	d := &Privileged{
+		Driver: &Local{},
+		Become: Become{Password: "secret"},
+	}
+	// command must not be able to read the password from stdin
+	output, err := d.RunCommand(`echo "it's $(cat)"`)
+	if err != nil || strings.TrimSpace(output) != "it's" {
+		t.Errorf("Expected escalated command output, got %q %v", output, err)
+	}
+
+	if _, err = d.RunCommandWithInput(`cat`, "input"); err == nil {
+		t.Error("Expected input along with a become password to be rejected")
+	}
+	d.Become.Password = ""
+	if output, err = d.RunCommandWithInput(`cat`, "input"); err != nil || output != "input" {
+		t.Errorf("Expected input to be written to the command, got %q %v", output, err)
+	}
+
+	d.Become.Password = "wrong"
+	_, err = d.RunCommand(`id`)
+	if _, ok := err.(*PermissionError); !ok {
+		t.Errorf("Expected permission error for wrong password, got %v", err)
+	}
+
+	d.Become = Become{Method: "doas", Password: "secret"}
+	if _, err = d.RunCommand(`id`); err == nil {
+		t.Error("Expected doas with password to be rejected")
+	}
+}
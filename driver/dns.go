@@ -0,0 +1,238 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// DefaultDNSPort : port resolvers listen on
+	DefaultDNSPort = 53
+	// DefaultDNSTimeout : time to wait for every query
+	DefaultDNSTimeout = 5 * time.Second
+)
+
+// DNSQuery : name and record type to resolve
+type DNSQuery struct {
+	Name string
+	// Type is one of A (default), AAAA, CNAME, MX, TXT or SRV
+	Type string
+	// Expect are the answers the query must return in any order
+	Expect []string
+}
+
+// DNSResult : outcome of a DNSQuery, latency is in seconds
+type DNSResult struct {
+	Name    string
+	Type    string
+	Answers []string
+	Latency float64
+	// Success is false on errors and on answers not matching Expect
+	Success bool
+	// Mismatch is set when answers differ from Expect
+	Mismatch   bool
+	Missing    []string `json:",omitempty"`
+	Unexpected []string `json:",omitempty"`
+	Error      string   `json:",omitempty"`
+}
+
+// DNSResolver : drivers that resolve queries against a resolver
+type DNSResolver interface {
+	Resolve() []DNSResult
+}
+
+// DNS : Driver for checking records served by a resolver
+type DNS struct {
+	driverBase
+	// Host of the resolver
+	Host string
+	// Port defaults to DefaultDNSPort
+	Port    int
+	Queries []DNSQuery
+	// Timeout defaults to DefaultDNSTimeout
+	Timeout time.Duration
+}
+
+func (d *DNS) String() string {
+	return fmt.Sprintf("dns (%s)", d.target())
+}
+
+func (d *DNS) target() string {
+	port := d.Port
+	if port == 0 {
+		port = DefaultDNSPort
+	}
+	return net.JoinHostPort(d.Host, strconv.Itoa(port))
+}
+
+func (d *DNS) timeout() time.Duration {
+	if d.Timeout == 0 {
+		return DefaultDNSTimeout
+	}
+	return d.Timeout
+}
+
+// resolver : sends every query to the configured resolver instead of
+// the ones of the system
+func (d *DNS) resolver() *net.Resolver {
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			dialer := net.Dialer{Timeout: d.timeout()}
+			return dialer.DialContext(ctx, network, d.target())
+		},
+	}
+}
+
+// fqdn : absolute names skip the search domains of the system
+func fqdn(name string) string {
+	if strings.HasSuffix(name, ".") {
+		return name
+	}
+	return name + "."
+}
+
+func (d *DNS) lookup(ctx context.Context, query DNSQuery) ([]string, error) {
+	resolver := d.resolver()
+	name := fqdn(query.Name)
+	answers := []string{}
+	switch strings.ToUpper(query.Type) {
+	case "", "A", "AAAA":
+		network := "ip4"
+		if strings.ToUpper(query.Type) == "AAAA" {
+			network = "ip6"
+		}
+		ips, err := resolver.LookupIP(ctx, network, name)
+		if err != nil {
+			return nil, err
+		}
+		for _, ip := range ips {
+			answers = append(answers, ip.String())
+		}
+	case "CNAME":
+		cname, err := resolver.LookupCNAME(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		answers = append(answers, strings.TrimSuffix(cname, "."))
+	case "MX":
+		records, err := resolver.LookupMX(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		for _, record := range records {
+			answers = append(answers, fmt.Sprintf("%d %s", record.Pref, strings.TrimSuffix(record.Host, ".")))
+		}
+	case "TXT":
+		records, err := resolver.LookupTXT(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		answers = append(answers, records...)
+	case "SRV":
+		_, records, err := resolver.LookupSRV(ctx, "", "", name)
+		if err != nil {
+			return nil, err
+		}
+		for _, record := range records {
+			answers = append(answers, fmt.Sprintf("%d %d %d %s", record.Priority, record.Weight, record.Port, strings.TrimSuffix(record.Target, ".")))
+		}
+	default:
+		return nil, fmt.Errorf("Unsupported record type %s", query.Type)
+	}
+	sort.Strings(answers)
+	return answers, nil
+}
+
+// compareAnswers : expected answers missing and answers not expected,
+// names are compared without case and trailing dots
+func compareAnswers(expected []string, answers []string) (missing []string, unexpected []string) {
+	normalize := func(answer string) string {
+		return strings.ToLower(strings.TrimSuffix(strings.TrimSpace(answer), "."))
+	}
+	found := make(map[string]bool)
+	for _, answer := range answers {
+		found[normalize(answer)] = true
+	}
+	wanted := make(map[string]bool)
+	for _, answer := range expected {
+		wanted[normalize(answer)] = true
+		if !found[normalize(answer)] {
+			missing = append(missing, answer)
+		}
+	}
+	for _, answer := range answers {
+		if !wanted[normalize(answer)] {
+			unexpected = append(unexpected, answer)
+		}
+	}
+	return
+}
+
+func (d *DNS) resolve(query DNSQuery) DNSResult {
+	result := DNSResult{
+		Name: query.Name,
+		Type: strings.ToUpper(query.Type),
+	}
+	if result.Type == `` {
+		result.Type = `A`
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), d.timeout())
+	defer cancel()
+	start := time.Now()
+	answers, err := d.lookup(ctx, query)
+	result.Latency = time.Since(start).Seconds()
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Answers = answers
+	if len(query.Expect) > 0 {
+		result.Missing, result.Unexpected = compareAnswers(query.Expect, answers)
+		result.Mismatch = len(result.Missing) > 0 || len(result.Unexpected) > 0
+	}
+	result.Success = !result.Mismatch
+	return result
+}
+
+// Resolve : run all queries concurrently, results keep the order of the
+// queries
+func (d *DNS) Resolve() []DNSResult {
+	results := make([]DNSResult, len(d.Queries))
+	var wg sync.WaitGroup
+	for index, query := range d.Queries {
+		wg.Add(1)
+		go func(index int, query DNSQuery) {
+			defer wg.Done()
+			results[index] = d.resolve(query)
+		}(index, query)
+	}
+	wg.Wait()
+	return results
+}
+
+func (d *DNS) ReadFile(path string) (string, error) {
+	return ``, errors.New("Cannot read files on dns driver")
+}
+
+func (d *DNS) RunCommand(command string) (string, error) {
+	return ``, errors.New("Cannot run commands on dns driver")
+}
+
+func (d *DNS) GetDetails() (SystemDetails, error) {
+	if d.Info == nil {
+		d.Info = &SystemDetails{
+			Name:  "dns",
+			Extra: d.target(),
+			IsDNS: true,
+		}
+	}
+	return *d.Info, nil
+}
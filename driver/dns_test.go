@@ -0,0 +1,152 @@
+package driver
+
+import (
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// fakeDNSRecord : answer served by the stub resolver
+type fakeDNSRecord struct {
+	name string
+	body dnsmessage.ResourceBody
+}
+
+// newFakeDNS : stub resolver over udp answering from records, names
+// with a CNAME record are answered with the alias whatever the type
+func newFakeDNS(t *testing.T, records []fakeDNSRecord) int {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			var request dnsmessage.Message
+			if err := request.Unpack(buf[:n]); err != nil || len(request.Questions) != 1 {
+				continue
+			}
+			question := request.Questions[0]
+			response := dnsmessage.Message{
+				Header: dnsmessage.Header{
+					ID:                 request.ID,
+					Response:           true,
+					Authoritative:      true,
+					RecursionAvailable: true,
+				},
+				Questions: request.Questions,
+			}
+			// records of a CNAME target must follow the CNAME record
+			name := strings.ToLower(question.Name.String())
+			for _, record := range records {
+				rrType := recordType(record.body)
+				if record.name != name || rrType != question.Type && rrType != dnsmessage.TypeCNAME {
+					continue
+				}
+				response.Answers = append(response.Answers, dnsmessage.Resource{
+					Header: dnsmessage.ResourceHeader{
+						Name:  dnsmessage.MustNewName(record.name),
+						Type:  rrType,
+						Class: dnsmessage.ClassINET,
+						TTL:   60,
+					},
+					Body: record.body,
+				})
+				if cname, ok := record.body.(*dnsmessage.CNAMEResource); ok && question.Type != dnsmessage.TypeCNAME {
+					name = strings.ToLower(cname.CNAME.String())
+				}
+			}
+			if len(response.Answers) == 0 {
+				response.RCode = dnsmessage.RCodeNameError
+			}
+			out, err := response.Pack()
+			if err != nil {
+				continue
+			}
+			conn.WriteTo(out, addr)
+		}
+	}()
+	return conn.LocalAddr().(*net.UDPAddr).Port
+}
+
+func recordType(body dnsmessage.ResourceBody) dnsmessage.Type {
+	switch body.(type) {
+	case *dnsmessage.AResource:
+		return dnsmessage.TypeA
+	case *dnsmessage.AAAAResource:
+		return dnsmessage.TypeAAAA
+	case *dnsmessage.CNAMEResource:
+		return dnsmessage.TypeCNAME
+	case *dnsmessage.MXResource:
+		return dnsmessage.TypeMX
+	case *dnsmessage.TXTResource:
+		return dnsmessage.TypeTXT
+	case *dnsmessage.SRVResource:
+		return dnsmessage.TypeSRV
+	}
+	return 0
+}
+
+func TestDNSOnStubResolver(t *testing.T) {
+	port := newFakeDNS(t, []fakeDNSRecord{
+		{"db.internal.", &dnsmessage.AResource{A: [4]byte{10, 0, 0, 5}}},
+		{"db.internal.", &dnsmessage.AResource{A: [4]byte{10, 0, 0, 6}}},
+		{"db.internal.", &dnsmessage.AAAAResource{AAAA: [16]byte{0xfd, 15: 5}}},
+		{"www.internal.", &dnsmessage.CNAMEResource{CNAME: dnsmessage.MustNewName("web.internal.")}},
+		{"web.internal.", &dnsmessage.AResource{A: [4]byte{10, 0, 0, 8}}},
+		{"internal.", &dnsmessage.MXResource{Pref: 10, MX: dnsmessage.MustNewName("mail.internal.")}},
+		{"internal.", &dnsmessage.TXTResource{TXT: []string{"v=spf1 -all"}}},
+		{"_ldap._tcp.internal.", &dnsmessage.SRVResource{Priority: 0, Weight: 5, Port: 389, Target: dnsmessage.MustNewName("ldap.internal.")}},
+	})
+	d := &DNS{
+		Host:    "127.0.0.1",
+		Port:    port,
+		Timeout: time.Second,
+		Queries: []DNSQuery{
+			{Name: "db.internal", Expect: []string{"10.0.0.6", "10.0.0.5"}},
+			{Name: "db.internal", Type: "aaaa"},
+			{Name: "www.internal", Type: "CNAME", Expect: []string{"web.internal."}},
+			{Name: "internal", Type: "MX", Expect: []string{"10 mail.internal"}},
+			{Name: "internal", Type: "TXT", Expect: []string{"v=spf1 -all"}},
+			{Name: "_ldap._tcp.internal", Type: "SRV", Expect: []string{"0 5 389 ldap.internal"}},
+			{Name: "db.internal", Expect: []string{"10.0.0.5", "10.0.0.7"}},
+			{Name: "missing.internal"},
+		},
+	}
+	results := d.Resolve()
+	for index, result := range results[:6] {
+		if !result.Success || result.Mismatch || result.Error != "" {
+			t.Errorf("Expected query %d to succeed, found %+v", index, result)
+		}
+	}
+	if !reflect.DeepEqual(results[0].Answers, []string{"10.0.0.5", "10.0.0.6"}) || results[0].Type != "A" {
+		t.Errorf("Unexpected A answers %+v", results[0])
+	}
+	if !reflect.DeepEqual(results[1].Answers, []string{"fd00::5"}) || results[1].Type != "AAAA" {
+		t.Errorf("Unexpected AAAA answers %+v", results[1])
+	}
+	mismatch := results[6]
+	if mismatch.Success || !mismatch.Mismatch {
+		t.Errorf("Expected mismatch, found %+v", mismatch)
+	}
+	if !reflect.DeepEqual(mismatch.Missing, []string{"10.0.0.7"}) || !reflect.DeepEqual(mismatch.Unexpected, []string{"10.0.0.6"}) {
+		t.Errorf("Unexpected differences %+v", mismatch)
+	}
+	if results[7].Success || results[7].Error == "" {
+		t.Errorf("Expected error for missing name, found %+v", results[7])
+	}
+	for _, result := range results {
+		if result.Latency <= 0 {
+			t.Errorf("Expected latency for %s, found %f", result.Name, result.Latency)
+		}
+	}
+}
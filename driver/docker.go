@@ -0,0 +1,268 @@
+package driver
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os/exec"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	// DefaultDockerSocket : unix socket of the docker engine
+	DefaultDockerSocket = "/var/run/docker.sock"
+	dockerTimeout       = 30 * time.Second
+)
+
+type DockerRunError struct {
+	content   string
+	container string
+}
+
+func (e *DockerRunError) Error() string {
+	return fmt.Sprintf("Docker Run Error on %s: %s", e.container, e.content)
+}
+
+// DockerEngine : minimal client for the docker engine API
+type DockerEngine struct {
+	client *http.Client
+}
+
+// DockerEngineProvider : drivers that can reach a docker engine
+type DockerEngineProvider interface {
+	DockerEngine() (*DockerEngine, error)
+}
+
+// NewDockerEngine : engine API client dialing with dial e.g over a unix
+// socket or a socket forwarded through ssh
+func NewDockerEngine(dial func(ctx context.Context) (net.Conn, error)) *DockerEngine {
+	return &DockerEngine{
+		client: &http.Client{
+			Timeout: dockerTimeout,
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					return dial(ctx)
+				},
+			},
+		},
+	}
+}
+
+// NewDockerSocketEngine : engine API client on a local unix socket
+func NewDockerSocketEngine(socket string) *DockerEngine {
+	if socket == "" {
+		socket = DefaultDockerSocket
+	}
+	return NewDockerEngine(func(ctx context.Context) (net.Conn, error) {
+		var dialer net.Dialer
+		return dialer.DialContext(ctx, "unix", socket)
+	})
+}
+
+// Do : send request to the engine and decode JSON response into out
+func (e *DockerEngine) Do(method string, path string, body interface{}, out interface{}) error {
+	res, err := e.request(method, path, body)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(res.Body).Decode(out)
+}
+
+func (e *DockerEngine) request(method string, path string, body interface{}) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		content, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(content)
+	}
+	// host is ignored as every request is dialed on the socket
+	req, err := http.NewRequest(method, "http://docker"+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	res, err := e.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		defer res.Body.Close()
+		var message struct {
+			Message string `json:"message"`
+		}
+		json.NewDecoder(res.Body).Decode(&message)
+		return nil, fmt.Errorf("docker engine returned %d for %s: %s", res.StatusCode, path, message.Message)
+	}
+	return res, nil
+}
+
+// Exec : run command inside container returning stdout and stderr
+func (e *DockerEngine) Exec(container string, cmd []string, env []string) (string, string, int, error) {
+	var created struct {
+		ID string `json:"Id"`
+	}
+	err := e.Do(http.MethodPost, fmt.Sprintf("/containers/%s/exec", url.PathEscape(container)), map[string]interface{}{
+		"AttachStdout": true,
+		"AttachStderr": true,
+		"Cmd":          cmd,
+		"Env":          env,
+	}, &created)
+	if err != nil {
+		return ``, ``, 0, err
+	}
+	res, err := e.request(http.MethodPost, fmt.Sprintf("/exec/%s/start", created.ID), map[string]interface{}{
+		"Detach": false,
+		"Tty":    false,
+	})
+	if err != nil {
+		return ``, ``, 0, err
+	}
+	defer res.Body.Close()
+	var stdout, stderr bytes.Buffer
+	if err = demuxDockerStream(res.Body, &stdout, &stderr); err != nil {
+		return ``, ``, 0, err
+	}
+	var inspect struct {
+		ExitCode int
+	}
+	err = e.Do(http.MethodGet, fmt.Sprintf("/exec/%s/json", created.ID), nil, &inspect)
+	return stdout.String(), stderr.String(), inspect.ExitCode, err
+}
+
+// demuxDockerStream : split the multiplexed stream where every frame is
+// prefixed by [stream, 0, 0, 0, size uint32 big endian]
+func demuxDockerStream(r io.Reader, stdout, stderr io.Writer) error {
+	header := make([]byte, 8)
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		size := int64(binary.BigEndian.Uint32(header[4:]))
+		var dst io.Writer = stdout
+		if header[0] == 2 {
+			dst = stderr
+		}
+		if _, err := io.CopyN(dst, r, size); err != nil {
+			return err
+		}
+	}
+}
+
+// Docker : Driver for handling executions inside a container
+type Docker struct {
+	driverBase
+	// Container name or id
+	Container string
+	// Socket of the docker engine, defaults to DefaultDockerSocket
+	Socket string
+	// UseCLI runs `docker exec` instead of using the engine API
+	UseCLI bool
+	// set environmental vars for container e.g []string{"DEBUG=1"}
+	EnvVars []string
+	engine  *DockerEngine
+}
+
+func (d *Docker) String() string {
+	return fmt.Sprintf("docker (%s)", d.Container)
+}
+
+// DockerEngine : engine the container runs on
+func (d *Docker) DockerEngine() (*DockerEngine, error) {
+	if d.engine == nil {
+		d.engine = NewDockerSocketEngine(d.Socket)
+	}
+	return d.engine, nil
+}
+
+func (d *Docker) ReadFile(path string) (string, error) {
+	log.Debugf("Reading container content %s", path)
+	return d.RunCommand(fmt.Sprintf(`cat %s`, path))
+}
+
+func (d *Docker) RunCommand(command string) (string, error) {
+	log.Debugf("Running container command %s", command)
+	cmd := []string{"sh", "-c", command}
+	if d.UseCLI {
+		return d.runCLI(cmd)
+	}
+	engine, _ := d.DockerEngine()
+	stdout, stderr, exitCode, err := engine.Exec(d.Container, cmd, d.EnvVars)
+	if err != nil {
+		return ``, &DockerRunError{
+			content:   err.Error(),
+			container: d.Container,
+		}
+	}
+	if exitCode != 0 {
+		return ``, &DockerRunError{
+			content:   fmt.Sprintf("exit status %d: %s", exitCode, strings.TrimSpace(stderr)),
+			container: d.Container,
+		}
+	}
+	return stdout, nil
+}
+
+func (d *Docker) runCLI(cmd []string) (string, error) {
+	args := []string{"exec"}
+	for _, env := range d.EnvVars {
+		args = append(args, "-e", env)
+	}
+	args = append(args, d.Container)
+	args = append(args, cmd...)
+	ctx, cancel := context.WithTimeout(context.Background(), dockerTimeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, "docker", args...).Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			err = fmt.Errorf("%s: %s", err, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return ``, &DockerRunError{
+			content:   err.Error(),
+			container: d.Container,
+		}
+	}
+	return string(out), nil
+}
+
+func (d *Docker) GetDetails() (SystemDetails, error) {
+	if d.Info == nil {
+		uname, err := d.RunCommand(`uname`)
+		if err != nil {
+			return SystemDetails{}, err
+		}
+		details := &SystemDetails{
+			Name:  strings.TrimSpace(uname),
+			Extra: d.Container,
+		}
+		switch details.Name {
+		case "Linux":
+			details.IsLinux = true
+		case "Darwin":
+			details.IsDarwin = true
+		}
+		d.Info = details
+	}
+	return *d.Info, nil
+}
@@ -0,0 +1,94 @@
+//go:build !windows
+// +build !windows
+
+package driver
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// newFakeDockerEngine : serves the exec endpoints of the docker engine
+// API on a unix socket, commands are answered from outputs
+func newFakeDockerEngine(t *testing.T, container string, outputs map[string]string) string {
+	var (
+		mu    sync.Mutex
+		execs = make(map[string]string)
+		codes = make(map[string]int)
+	)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/containers/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != fmt.Sprintf("/containers/%s/exec", container) {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"message": "No such container"}`)
+			return
+		}
+		var body struct{ Cmd []string }
+		json.NewDecoder(r.Body).Decode(&body)
+		mu.Lock()
+		id := fmt.Sprintf("exec%d", len(execs))
+		execs[id] = body.Cmd[len(body.Cmd)-1]
+		mu.Unlock()
+		fmt.Fprintf(w, `{"Id": "%s"}`, id)
+	})
+	mux.HandleFunc("/exec/", func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(r.URL.Path, "/")
+		id := parts[2]
+		mu.Lock()
+		defer mu.Unlock()
+		if parts[3] == "json" {
+			fmt.Fprintf(w, `{"ExitCode": %d, "Running": false}`, codes[id])
+			return
+		}
+		w.Header().Set("Content-Type", "application/vnd.docker.multiplexed-stream")
+		stream, content := byte(1), outputs[execs[id]]
+		if _, ok := outputs[execs[id]]; !ok {
+			stream, content, codes[id] = 2, "sh: not found\n", 127
+		}
+		header := make([]byte, 8)
+		header[0] = stream
+		binary.BigEndian.PutUint32(header[4:], uint32(len(content)))
+		w.Write(header)
+		w.Write([]byte(content))
+	})
+	socket := filepath.Join(t.TempDir(), "docker.sock")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &http.Server{Handler: mux}
+	go server.Serve(listener)
+	t.Cleanup(func() { server.Close() })
+	return socket
+}
+
+func TestDockerRunCommand(t *testing.T) {
+	socket := newFakeDockerEngine(t, "web", map[string]string{
+		"uname":             "Linux\n",
+		"cat /proc/loadavg": "0.25 0.23 0.14 3/671 9362\n",
+	})
+	d := &Docker{Container: "web", Socket: socket}
+	details, err := d.GetDetails()
+	if err != nil || !details.IsLinux || details.Extra != "web" {
+		t.Errorf("Expected linux container details, got %#v %v", details, err)
+	}
+	output, err := d.ReadFile("/proc/loadavg")
+	if err != nil || !strings.HasPrefix(output, "0.25") {
+		t.Errorf("Could not read file in container, got %s %v", output, err)
+	}
+	_, err = d.RunCommand("missing-command")
+	if err == nil || !strings.Contains(err.Error(), "127") {
+		t.Errorf("Expected exit status 127 error, got %v", err)
+	}
+	missing := &Docker{Container: "missing", Socket: socket}
+	if _, err = missing.RunCommand("uname"); err == nil {
+		t.Error("Expected error for missing container")
+	}
+}
@@ -1,6 +1,10 @@
 package driver
 
-import "github.com/bisohns/saido/config"
+import (
+	"time"
+
+	"github.com/bisohns/saido/config"
+)
 
 // SystemInfo gives more insight into system details
 type SystemDetails struct {
@@ -8,8 +12,18 @@ type SystemDetails struct {
 	IsLinux   bool
 	IsDarwin  bool
 	IsWeb     bool
-	Name      string
-	Extra     string
+	// IsKubernetes is set for drivers that can query the kubernetes API
+	IsKubernetes bool
+	// IsNodeExporter is set for drivers scraping a prometheus node_exporter
+	IsNodeExporter bool
+	// IsSNMP is set for drivers querying an SNMP agent
+	IsSNMP bool
+	// IsPortCheck is set for drivers probing endpoints from saido
+	IsPortCheck bool
+	// IsDNS is set for drivers resolving queries against a resolver
+	IsDNS bool
+	Name  string
+	Extra string
 }
 
 type driverBase struct {
@@ -29,19 +43,120 @@ type Driver interface {
 	GetDetails() (SystemDetails, error)
 }
 
+func toSSH(conn config.Connection) *SSH {
+	var proxyJump []*SSH
+	for _, hop := range conn.ProxyJump {
+		proxyJump = append(proxyJump, toSSH(hop))
+	}
+	return &SSH{
+		User:            conn.Username,
+		Host:            conn.Host,
+		Port:            int(conn.Port),
+		KeyFile:         conn.PrivateKeyPath,
+		KeyPass:         conn.PrivateKeyPassPhrase,
+		Password:        conn.Password,
+		CertFile:        conn.CertificatePath,
+		AuthMethods:     conn.AuthMethods,
+		AgentForwarding: conn.AgentForwarding,
+		ProxyJump:       proxyJump,
+		DockerSocket:    conn.Socket,
+		Batch:           conn.Batch,
+		CheckKnownHosts: false,
+	}
+}
+
 func ToDriver(conn config.Connection) Driver {
 	switch conn.Type {
 	case "ssh":
-		return &SSH{
-			User:            conn.Username,
-			Host:            conn.Host,
-			Port:            int(conn.Port),
-			KeyFile:         conn.PrivateKeyPath,
-			KeyPass:         conn.PrivateKeyPassPhrase,
-			Password:        conn.Password,
-			CheckKnownHosts: false,
+		return toSSH(conn)
+	case "agent":
+		return &Agent{
+			Host:               conn.Host,
+			Port:               int(conn.Port),
+			Token:              conn.Token,
+			TLS:                conn.TLS,
+			InsecureSkipVerify: conn.InsecureSkipVerify,
+		}
+	case "snmp":
+		return &SNMP{
+			Host:          conn.Host,
+			Port:          int(conn.Port),
+			Version:       conn.SNMPVersion,
+			Community:     conn.Community,
+			Username:      conn.Username,
+			SecurityLevel: conn.SecurityLevel,
+			AuthProtocol:  conn.AuthProtocol,
+			AuthPassword:  conn.AuthPassword,
+			PrivProtocol:  conn.PrivProtocol,
+			PrivPassword:  conn.PrivPassword,
+			ContextName:   conn.ContextName,
+		}
+	case "portcheck":
+		var targets []PortTarget
+		for _, target := range conn.Targets {
+			targets = append(targets, PortTarget{
+				Name:               target.Name,
+				Address:            target.Address,
+				Protocol:           target.Protocol,
+				TLS:                target.TLS,
+				ServerName:         target.ServerName,
+				InsecureSkipVerify: target.InsecureSkipVerify,
+				Send:               target.Send,
+				Expect:             target.Expect,
+			})
+		}
+		return &PortCheck{
+			Name:    conn.Host,
+			Targets: targets,
+			Timeout: time.Duration(conn.Timeout) * time.Second,
+		}
+	case "dns":
+		var queries []DNSQuery
+		for _, query := range conn.Queries {
+			queries = append(queries, DNSQuery{
+				Name:   query.Name,
+				Type:   query.Type,
+				Expect: query.Expect,
+			})
+		}
+		return &DNS{
+			Host:    conn.Host,
+			Port:    int(conn.Port),
+			Queries: queries,
+			Timeout: time.Duration(conn.Timeout) * time.Second,
+		}
+	case "node_exporter":
+		return &NodeExporter{
+			Host:               conn.Host,
+			Port:               int(conn.Port),
+			Path:               conn.Path,
+			TLS:                conn.TLS,
+			InsecureSkipVerify: conn.InsecureSkipVerify,
+			Username:           conn.Username,
+			Password:           conn.Password,
+		}
+	case "kubernetes":
+		return &Kubernetes{
+			Kubeconfig: conn.Kubeconfig,
+			Context:    conn.Context,
+			Namespace:  conn.Namespace,
+			Pod:        conn.Pod,
+			Container:  conn.Container,
+			UseKubectl: conn.Kubectl,
+		}
+	case "docker":
+		container := conn.Container
+		if container == "" {
+			container = conn.Host
+		}
+		return &Docker{
+			Container: container,
+			Socket:    conn.Socket,
+			UseCLI:    conn.DockerCLI,
 		}
 	default:
-		return &Local{}
+		return &Local{
+			DockerSocket: conn.Socket,
+		}
 	}
 }
@@ -0,0 +1,421 @@
+package driver
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
+)
+
+var kubernetesTimeout = 30 * time.Second
+
+type KubernetesRunError struct {
+	content string
+	pod     string
+}
+
+func (e *KubernetesRunError) Error() string {
+	return fmt.Sprintf("Kubernetes Run Error on %s: %s", e.pod, e.content)
+}
+
+// KubernetesProvider : drivers that can query the kubernetes API
+type KubernetesProvider interface {
+	// KubernetesGet : GET path on the API server decoding JSON into out
+	KubernetesGet(path string, out interface{}) error
+	// KubernetesNamespace : namespace of the connection
+	KubernetesNamespace() string
+}
+
+// kubeconfig : subset of kubeconfig used to reach the API server
+type kubeconfig struct {
+	CurrentContext string `yaml:"current-context"`
+	Clusters       []struct {
+		Name    string
+		Cluster struct {
+			Server                   string
+			CertificateAuthority     string `yaml:"certificate-authority"`
+			CertificateAuthorityData string `yaml:"certificate-authority-data"`
+			InsecureSkipTLSVerify    bool   `yaml:"insecure-skip-tls-verify"`
+		}
+	}
+	Users []struct {
+		Name string
+		User struct {
+			Token                 string
+			TokenFile             string `yaml:"tokenFile"`
+			Username              string
+			Password              string
+			ClientCertificate     string `yaml:"client-certificate"`
+			ClientCertificateData string `yaml:"client-certificate-data"`
+			ClientKey             string `yaml:"client-key"`
+			ClientKeyData         string `yaml:"client-key-data"`
+		}
+	}
+	Contexts []struct {
+		Name    string
+		Context struct {
+			Cluster   string
+			User      string
+			Namespace string
+		}
+	}
+}
+
+// kubernetesAPI : resolved API server and credentials of a context
+type kubernetesAPI struct {
+	server    string
+	namespace string
+	header    http.Header
+	tlsConfig *tls.Config
+	client    *http.Client
+}
+
+func defaultKubeconfig() string {
+	if env := os.Getenv("KUBECONFIG"); env != "" {
+		return filepath.SplitList(env)[0]
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".kube", "config")
+}
+
+// readKubeData : inline base64 data takes precedence over file paths
+// which are relative to the kubeconfig
+func readKubeData(data string, path string, configDir string) ([]byte, error) {
+	if data != "" {
+		return base64.StdEncoding.DecodeString(data)
+	}
+	if path == "" {
+		return nil, nil
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(configDir, path)
+	}
+	return ioutil.ReadFile(path)
+}
+
+func loadKubernetesAPI(configPath string, contextName string) (*kubernetesAPI, error) {
+	content, err := ioutil.ReadFile(configPath)
+	if err != nil {
+		return nil, err
+	}
+	var conf kubeconfig
+	if err = yaml.Unmarshal(content, &conf); err != nil {
+		return nil, err
+	}
+	if contextName == "" {
+		contextName = conf.CurrentContext
+	}
+	api := &kubernetesAPI{
+		header:    make(http.Header),
+		tlsConfig: &tls.Config{},
+	}
+	var clusterName, userName string
+	found := false
+	for _, c := range conf.Contexts {
+		if c.Name == contextName {
+			clusterName, userName = c.Context.Cluster, c.Context.User
+			api.namespace = c.Context.Namespace
+			found = true
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("Could not find context %s in %s", contextName, configPath)
+	}
+	configDir := filepath.Dir(configPath)
+	for _, c := range conf.Clusters {
+		if c.Name != clusterName {
+			continue
+		}
+		api.server = strings.TrimSuffix(c.Cluster.Server, "/")
+		api.tlsConfig.InsecureSkipVerify = c.Cluster.InsecureSkipTLSVerify
+		ca, err := readKubeData(c.Cluster.CertificateAuthorityData, c.Cluster.CertificateAuthority, configDir)
+		if err != nil {
+			return nil, err
+		}
+		if ca != nil {
+			api.tlsConfig.RootCAs = x509.NewCertPool()
+			api.tlsConfig.RootCAs.AppendCertsFromPEM(ca)
+		}
+	}
+	if api.server == "" {
+		return nil, fmt.Errorf("Could not find server for cluster %s in %s", clusterName, configPath)
+	}
+	for _, u := range conf.Users {
+		if u.Name != userName {
+			continue
+		}
+		token := u.User.Token
+		if token == "" && u.User.TokenFile != "" {
+			tokenContent, err := ioutil.ReadFile(u.User.TokenFile)
+			if err != nil {
+				return nil, err
+			}
+			token = strings.TrimSpace(string(tokenContent))
+		}
+		if token != "" {
+			api.header.Set("Authorization", "Bearer "+token)
+		} else if u.User.Username != "" {
+			basic := base64.StdEncoding.EncodeToString([]byte(u.User.Username + ":" + u.User.Password))
+			api.header.Set("Authorization", "Basic "+basic)
+		}
+		cert, err := readKubeData(u.User.ClientCertificateData, u.User.ClientCertificate, configDir)
+		if err != nil {
+			return nil, err
+		}
+		key, err := readKubeData(u.User.ClientKeyData, u.User.ClientKey, configDir)
+		if err != nil {
+			return nil, err
+		}
+		if cert != nil && key != nil {
+			pair, err := tls.X509KeyPair(cert, key)
+			if err != nil {
+				return nil, err
+			}
+			api.tlsConfig.Certificates = []tls.Certificate{pair}
+		}
+	}
+	api.client = &http.Client{
+		Timeout: kubernetesTimeout,
+		Transport: &http.Transport{
+			TLSClientConfig: api.tlsConfig,
+		},
+	}
+	return api, nil
+}
+
+func (api *kubernetesAPI) get(path string, out interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, api.server+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header = api.header.Clone()
+	req.Header.Set("Accept", "application/json")
+	res, err := api.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		var status struct {
+			Message string
+		}
+		json.NewDecoder(res.Body).Decode(&status)
+		return fmt.Errorf("kubernetes API returned %d for %s: %s", res.StatusCode, path, status.Message)
+	}
+	return json.NewDecoder(res.Body).Decode(out)
+}
+
+// exec : run command in a pod container using the websocket exec protocol
+// where every message is prefixed by its channel (1 stdout, 2 stderr, 3 status)
+func (api *kubernetesAPI) exec(namespace, pod, container string, command []string) (string, string, error) {
+	query := url.Values{}
+	for _, arg := range command {
+		query.Add("command", arg)
+	}
+	query.Set("stdout", "true")
+	query.Set("stderr", "true")
+	if container != "" {
+		query.Set("container", container)
+	}
+	execURL := fmt.Sprintf("%s/api/v1/namespaces/%s/pods/%s/exec?%s", api.server, namespace, pod, query.Encode())
+	execURL = strings.Replace(execURL, "http", "ws", 1)
+	dialer := websocket.Dialer{
+		TLSClientConfig:  api.tlsConfig,
+		Subprotocols:     []string{"v4.channel.k8s.io"},
+		HandshakeTimeout: kubernetesTimeout,
+	}
+	conn, _, err := dialer.Dial(execURL, api.header)
+	if err != nil {
+		return ``, ``, err
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(kubernetesTimeout))
+	var stdout, stderr bytes.Buffer
+	var status struct {
+		Status  string
+		Message string
+	}
+	for {
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			if websocket.IsCloseError(err, websocket.CloseNormalClosure) || status.Status != "" {
+				break
+			}
+			return ``, ``, err
+		}
+		if len(message) == 0 {
+			continue
+		}
+		switch message[0] {
+		case 1:
+			stdout.Write(message[1:])
+		case 2:
+			stderr.Write(message[1:])
+		case 3:
+			json.Unmarshal(message[1:], &status)
+		}
+	}
+	if status.Status != "" && status.Status != "Success" {
+		return stdout.String(), stderr.String(), fmt.Errorf("%s: %s", status.Message, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), stderr.String(), nil
+}
+
+// Kubernetes : Driver for handling kubernetes API queries and executions
+// inside a pod
+type Kubernetes struct {
+	driverBase
+	// Kubeconfig path, defaults to $KUBECONFIG or ~/.kube/config
+	Kubeconfig string
+	// Context of kubeconfig to use, defaults to current-context
+	Context string
+	// Namespace defaults to namespace of the context or `default`
+	Namespace string
+	// Pod to run commands in, commands are not supported without a pod
+	Pod string
+	// Container of pod, defaults to first container
+	Container string
+	// UseKubectl shells out to kubectl instead of using the API directly
+	UseKubectl bool
+	client     *kubernetesAPI
+}
+
+func (d *Kubernetes) String() string {
+	return fmt.Sprintf("kubernetes (%s/%s)", d.Context, d.Pod)
+}
+
+func (d *Kubernetes) kubeconfig() string {
+	if d.Kubeconfig != "" {
+		return d.Kubeconfig
+	}
+	return defaultKubeconfig()
+}
+
+func (d *Kubernetes) connect() (*kubernetesAPI, error) {
+	if d.client == nil {
+		api, err := loadKubernetesAPI(d.kubeconfig(), d.Context)
+		if err != nil {
+			return nil, err
+		}
+		d.client = api
+	}
+	return d.client, nil
+}
+
+func (d *Kubernetes) KubernetesNamespace() string {
+	if d.Namespace != "" {
+		return d.Namespace
+	}
+	if api, err := d.connect(); err == nil && api.namespace != "" {
+		return api.namespace
+	}
+	return "default"
+}
+
+func (d *Kubernetes) kubectl(args ...string) (string, error) {
+	base := []string{"--kubeconfig", d.kubeconfig()}
+	if d.Context != "" {
+		base = append(base, "--context", d.Context)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), kubernetesTimeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, "kubectl", append(base, args...)...).Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			err = fmt.Errorf("%s: %s", err, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return ``, err
+	}
+	return string(out), nil
+}
+
+func (d *Kubernetes) KubernetesGet(path string, out interface{}) error {
+	if d.UseKubectl {
+		output, err := d.kubectl("get", "--raw", path)
+		if err != nil {
+			return err
+		}
+		return json.Unmarshal([]byte(output), out)
+	}
+	api, err := d.connect()
+	if err != nil {
+		return err
+	}
+	return api.get(path, out)
+}
+
+func (d *Kubernetes) ReadFile(path string) (string, error) {
+	log.Debugf("Reading pod content %s", path)
+	return d.RunCommand(fmt.Sprintf(`cat %s`, path))
+}
+
+func (d *Kubernetes) RunCommand(command string) (string, error) {
+	if d.Pod == "" {
+		return ``, errors.New("Cannot run commands on kubernetes without a pod")
+	}
+	log.Debugf("Running pod command %s", command)
+	var (
+		output string
+		err    error
+	)
+	cmd := []string{"sh", "-c", command}
+	if d.UseKubectl {
+		args := []string{"exec", "-n", d.KubernetesNamespace(), d.Pod}
+		if d.Container != "" {
+			args = append(args, "-c", d.Container)
+		}
+		output, err = d.kubectl(append(append(args, "--"), cmd...)...)
+	} else {
+		var api *kubernetesAPI
+		api, err = d.connect()
+		if err == nil {
+			output, _, err = api.exec(d.KubernetesNamespace(), d.Pod, d.Container, cmd)
+		}
+	}
+	if err != nil {
+		return ``, &KubernetesRunError{
+			content: err.Error(),
+			pod:     d.Pod,
+		}
+	}
+	return output, nil
+}
+
+func (d *Kubernetes) GetDetails() (SystemDetails, error) {
+	if d.Info == nil {
+		details := &SystemDetails{
+			Name:         "kubernetes",
+			Extra:        d.Context,
+			IsKubernetes: true,
+		}
+		// the platform of a pod is that of its container
+		if d.Pod != "" {
+			uname, err := d.RunCommand(`uname`)
+			if err != nil {
+				return SystemDetails{}, err
+			}
+			details.Name = strings.TrimSpace(uname)
+			details.Extra = d.Pod
+			details.IsLinux = details.Name == "Linux"
+		}
+		d.Info = details
+	}
+	return *d.Info, nil
+}
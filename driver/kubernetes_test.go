@@ -0,0 +1,133 @@
+package driver
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+)
+
+// newFakeKubernetesAPI : API server answering exec with "ran <command>"
+// and returning a kubeconfig pointing to it
+func newFakeKubernetesAPI(t *testing.T) string {
+	upgrader := websocket.Upgrader{
+		Subprotocols: []string{"v4.channel.k8s.io"},
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/namespaces/monitoring/pods", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"message": "Unauthorized"}`)
+			return
+		}
+		fmt.Fprint(w, `{"items": [{"metadata": {"name": "agent"}}]}`)
+	})
+	mux.HandleFunc("/api/v1/namespaces/monitoring/pods/agent/exec", func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		command := r.URL.Query()["command"]
+		status := map[string]interface{}{"status": "Success"}
+		if command[len(command)-1] == "false" {
+			conn.WriteMessage(websocket.BinaryMessage, append([]byte{2}, "failed"...))
+			status = map[string]interface{}{"status": "Failure", "message": "command terminated with non-zero exit code"}
+		} else {
+			out := fmt.Sprintf("ran %s in %s\n", command[len(command)-1], r.URL.Query().Get("container"))
+			conn.WriteMessage(websocket.BinaryMessage, append([]byte{1}, out...))
+		}
+		content, _ := json.Marshal(status)
+		conn.WriteMessage(websocket.BinaryMessage, append([]byte{3}, content...))
+		conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	kubeconfig := filepath.Join(t.TempDir(), "config")
+	content := fmt.Sprintf(`apiVersion: v1
+kind: Config
+current-context: test
+clusters:
+- name: test
+  cluster:
+    server: %s
+users:
+- name: test
+  user:
+    token: secret
+contexts:
+- name: test
+  context:
+    cluster: test
+    user: test
+    namespace: monitoring
+`, server.URL)
+	if err := ioutil.WriteFile(kubeconfig, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return kubeconfig
+}
+
+func TestKubernetesGetOnFakeAPI(t *testing.T) {
+	d := &Kubernetes{Kubeconfig: newFakeKubernetesAPI(t)}
+	if ns := d.KubernetesNamespace(); ns != "monitoring" {
+		t.Errorf("Expected namespace of context, found %s", ns)
+	}
+	var list struct {
+		Items []struct {
+			Metadata struct{ Name string }
+		}
+	}
+	err := d.KubernetesGet("/api/v1/namespaces/monitoring/pods", &list)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Items) != 1 || list.Items[0].Metadata.Name != "agent" {
+		t.Errorf("Unexpected pod list %v", list)
+	}
+	details, err := d.GetDetails()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !details.IsKubernetes || details.IsLinux {
+		t.Errorf("Unexpected details without pod %v", details)
+	}
+	if _, err = d.RunCommand(`uname`); err == nil {
+		t.Error("Expected error running command without pod")
+	}
+}
+
+func TestKubernetesExecOnFakeAPI(t *testing.T) {
+	d := &Kubernetes{
+		Kubeconfig: newFakeKubernetesAPI(t),
+		Pod:        "agent",
+		Container:  "sidecar",
+	}
+	output, err := d.RunCommand(`uptime`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(output) != "ran uptime in sidecar" {
+		t.Errorf("Unexpected output %s", output)
+	}
+	_, err = d.RunCommand(`false`)
+	if _, ok := err.(*KubernetesRunError); !ok {
+		t.Errorf("Expected KubernetesRunError, found %v", err)
+	}
+	if err != nil && !strings.Contains(err.Error(), "failed") {
+		t.Errorf("Expected stderr in error, found %s", err)
+	}
+}
+
+func TestKubernetesMissingContext(t *testing.T) {
+	d := &Kubernetes{Kubeconfig: newFakeKubernetesAPI(t), Context: "prod"}
+	if err := d.KubernetesGet("/api/v1/nodes", &struct{}{}); err == nil {
+		t.Error("Expected error for missing context")
+	}
+}
@@ -14,6 +14,21 @@ import (
 type Local struct {
 	driverBase
 	EnvVars []string
+	// DockerSocket of the local docker engine, defaults to DefaultDockerSocket
+	DockerSocket string
+	engine       *DockerEngine
+}
+
+func (d *Local) String() string {
+	return "local"
+}
+
+// DockerEngine : engine listening on the local docker socket
+func (d *Local) DockerEngine() (*DockerEngine, error) {
+	if d.engine == nil {
+		d.engine = NewDockerSocketEngine(d.DockerSocket)
+	}
+	return d.engine, nil
 }
 
 func (d *Local) ReadFile(path string) (string, error) {
@@ -30,6 +45,16 @@ func (d *Local) ReadFile(path string) (string, error) {
 // `echo something | awk $var`, turn into a file to be saved
 // under ./shell/
 func (d *Local) RunCommand(command string) (string, error) {
+	out, err := d.RunCommandWithInput(command, "")
+	if err != nil {
+		return ``, err
+	}
+	return out, nil
+}
+
+// RunCommandWithInput : run command writing input to its stdin, stderr
+// is returned alongside errors to help diagnose them
+func (d *Local) RunCommandWithInput(command string, input string) (string, error) {
 	// FIXME: If command contains a shell variable $ or glob
 	// type pattern, it would not be executed, see
 	// https://pkg.go.dev/os/exec for more information
@@ -53,10 +78,16 @@ func (d *Local) RunCommand(command string) (string, error) {
 			cmd.Env = append(cmd.Env, v)
 		}
 	}
+	if input != "" {
+		cmd.Stdin = strings.NewReader(input)
+	}
 	_ = cmd.Wait()
 	out, err := cmd.Output()
 	if err != nil {
-		return ``, err
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return string(out) + string(exitErr.Stderr), err
+		}
+		return string(out), err
 	}
 	return string(out), nil
 }
@@ -0,0 +1,155 @@
+package driver
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// DefaultNodeExporterPort : port node_exporter listens on
+	DefaultNodeExporterPort = 9100
+	nodeExporterTimeout     = 30 * time.Second
+	// nodeExporterCacheTTL : scrapes are shared by inspectors of a poll
+	nodeExporterCacheTTL = time.Second
+	nodeUnameLabel       = regexp.MustCompile(`(sysname|release|nodename)="([^"]*)"`)
+)
+
+type NodeExporterError struct {
+	content string
+	target  string
+}
+
+func (e *NodeExporterError) Error() string {
+	return fmt.Sprintf("Node Exporter Error on %s: %s", e.target, e.content)
+}
+
+// NodeExporterProvider : drivers exposing prometheus node_exporter series
+type NodeExporterProvider interface {
+	// Scrape : text exposition of the exporter metrics
+	Scrape() (string, error)
+}
+
+// NodeExporter : Driver for scraping a prometheus node_exporter
+type NodeExporter struct {
+	driverBase
+	Host string
+	// Port defaults to DefaultNodeExporterPort
+	Port int
+	// Path defaults to /metrics
+	Path string
+	// TLS scrapes over https
+	TLS                bool
+	InsecureSkipVerify bool
+	// Username and Password for basic auth e.g behind a reverse proxy
+	Username string
+	Password string
+	client   *http.Client
+	mu       sync.Mutex
+	scraped  string
+	scrapeAt time.Time
+}
+
+func (d *NodeExporter) String() string {
+	return fmt.Sprintf("node_exporter (%s)", d.address())
+}
+
+func (d *NodeExporter) address() string {
+	scheme := "http"
+	if d.TLS {
+		scheme = "https"
+	}
+	port := d.Port
+	if port == 0 {
+		port = DefaultNodeExporterPort
+	}
+	path := d.Path
+	if path == "" {
+		path = "/metrics"
+	}
+	return fmt.Sprintf("%s://%s:%d%s", scheme, d.Host, port, path)
+}
+
+func (d *NodeExporter) Scrape() (string, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.scraped != "" && time.Since(d.scrapeAt) < nodeExporterCacheTTL {
+		return d.scraped, nil
+	}
+	if d.client == nil {
+		d.client = &http.Client{
+			Timeout: nodeExporterTimeout,
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: d.InsecureSkipVerify},
+			},
+		}
+	}
+	req, err := http.NewRequest(http.MethodGet, d.address(), nil)
+	if err != nil {
+		return ``, err
+	}
+	// exporters may negotiate protobuf or openmetrics otherwise
+	req.Header.Set("Accept", "text/plain;version=0.0.4")
+	if d.Username != "" {
+		req.SetBasicAuth(d.Username, d.Password)
+	}
+	res, err := d.client.Do(req)
+	if err != nil {
+		return ``, &NodeExporterError{content: err.Error(), target: d.address()}
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return ``, &NodeExporterError{content: res.Status, target: d.address()}
+	}
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return ``, &NodeExporterError{content: err.Error(), target: d.address()}
+	}
+	d.scraped = string(body)
+	d.scrapeAt = time.Now()
+	return d.scraped, nil
+}
+
+func (d *NodeExporter) ReadFile(path string) (string, error) {
+	return ``, errors.New("Cannot read files through node_exporter")
+}
+
+func (d *NodeExporter) RunCommand(command string) (string, error) {
+	return ``, errors.New("Cannot run commands through node_exporter")
+}
+
+// GetDetails : platform is read from the node_uname_info series, the
+// platform flags stay unset as commands cannot be run on the host
+func (d *NodeExporter) GetDetails() (SystemDetails, error) {
+	if d.Info == nil {
+		output, err := d.Scrape()
+		if err != nil {
+			return SystemDetails{}, err
+		}
+		details := &SystemDetails{
+			Name:           "node_exporter",
+			IsNodeExporter: true,
+		}
+		for _, line := range strings.Split(output, "\n") {
+			if !strings.HasPrefix(line, "node_uname_info{") {
+				continue
+			}
+			for _, match := range nodeUnameLabel.FindAllStringSubmatch(line, -1) {
+				switch match[1] {
+				case "sysname":
+					details.Name = match[2]
+				case "release":
+					details.Extra = match[2]
+				}
+			}
+		}
+		d.Info = details
+	}
+	return *d.Info, nil
+}
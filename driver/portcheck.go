@@ -0,0 +1,252 @@
+package driver
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultPortCheckTimeout : time to wait for every probe
+var DefaultPortCheckTimeout = 5 * time.Second
+
+// maxBanner : bytes of a banner or udp response that are read
+const maxBanner = 512
+
+// PortTarget : endpoint probed from saido
+type PortTarget struct {
+	// Name defaults to Address
+	Name string
+	// Address is host:port
+	Address string
+	// Protocol is tcp (default) or udp
+	Protocol           string
+	TLS                bool
+	ServerName         string
+	InsecureSkipVerify bool
+	// Send is written after connecting, Go escapes e.g \x00 are allowed
+	Send string
+	// Expect must be contained in the banner or udp response
+	Expect string
+}
+
+// PortResult : outcome of probing a PortTarget, latencies are in seconds
+type PortResult struct {
+	Name     string
+	Address  string
+	Protocol string
+	Success  bool
+	// Latency is the tcp handshake or the udp round trip
+	Latency float64
+	// HandshakeLatency is the TLS handshake after connecting
+	HandshakeLatency float64 `json:",omitempty"`
+	Banner           string  `json:",omitempty"`
+	Error            string  `json:",omitempty"`
+}
+
+// PortChecker : drivers that probe endpoints from saido itself
+type PortChecker interface {
+	CheckPorts() []PortResult
+}
+
+// PortCheck : Driver for checking reachability of endpoints from the
+// outside in, nothing runs on the endpoints themselves
+type PortCheck struct {
+	driverBase
+	// Name of the group of targets e.g datastores
+	Name    string
+	Targets []PortTarget
+	// Timeout defaults to DefaultPortCheckTimeout
+	Timeout time.Duration
+}
+
+func (d *PortCheck) String() string {
+	return fmt.Sprintf("portcheck (%s)", d.Name)
+}
+
+func (d *PortCheck) timeout() time.Duration {
+	if d.Timeout == 0 {
+		return DefaultPortCheckTimeout
+	}
+	return d.Timeout
+}
+
+// unescape : allow binary payloads e.g \x00 in the configuration
+func unescape(payload string) string {
+	unquoted, err := strconv.Unquote(`"` + strings.ReplaceAll(payload, `"`, `\"`) + `"`)
+	if err != nil {
+		return payload
+	}
+	return unquoted
+}
+
+// readBanner : read until expect is found, the peer closes or timeout,
+// without an expectation the first data read is the banner
+func readBanner(conn net.Conn, expect string, deadline time.Time) (string, error) {
+	conn.SetReadDeadline(deadline)
+	var banner []byte
+	buf := make([]byte, maxBanner)
+	for len(banner) < maxBanner {
+		n, err := conn.Read(buf[:maxBanner-len(banner)])
+		banner = append(banner, buf[:n]...)
+		if expect == `` && len(banner) > 0 || expect != `` && strings.Contains(string(banner), expect) {
+			break
+		}
+		if err == io.EOF || expect == `` && isTimeout(err) {
+			break
+		}
+		if err != nil {
+			return string(banner), err
+		}
+	}
+	return string(banner), nil
+}
+
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+func (d *PortCheck) checkTCP(target PortTarget, result *PortResult) error {
+	timeout := d.timeout()
+	start := time.Now()
+	conn, err := net.DialTimeout("tcp", target.Address, timeout)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	result.Latency = time.Since(start).Seconds()
+	deadline := start.Add(timeout)
+	if target.TLS {
+		serverName := target.ServerName
+		if serverName == `` {
+			serverName, _, _ = net.SplitHostPort(target.Address)
+		}
+		tlsConn := tls.Client(conn, &tls.Config{
+			ServerName:         serverName,
+			InsecureSkipVerify: target.InsecureSkipVerify,
+		})
+		tlsConn.SetDeadline(deadline)
+		handshake := time.Now()
+		if err = tlsConn.Handshake(); err != nil {
+			return err
+		}
+		result.HandshakeLatency = time.Since(handshake).Seconds()
+		conn = tlsConn
+	}
+	if target.Send == `` && target.Expect == `` {
+		return nil
+	}
+	if target.Send != `` {
+		conn.SetWriteDeadline(deadline)
+		if _, err = conn.Write([]byte(unescape(target.Send))); err != nil {
+			return err
+		}
+	}
+	banner, err := readBanner(conn, target.Expect, deadline)
+	result.Banner = strings.TrimSpace(banner)
+	if err != nil {
+		return err
+	}
+	if !strings.Contains(banner, target.Expect) {
+		return fmt.Errorf("Expected %q in banner", target.Expect)
+	}
+	return nil
+}
+
+// checkUDP : udp is connectionless so a probe without an expectation
+// only fails when the port is reported unreachable before timeout
+func (d *PortCheck) checkUDP(target PortTarget, result *PortResult) error {
+	timeout := d.timeout()
+	start := time.Now()
+	conn, err := net.DialTimeout("udp", target.Address, timeout)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	deadline := start.Add(timeout)
+	conn.SetDeadline(deadline)
+	if _, err = conn.Write([]byte(unescape(target.Send))); err != nil {
+		return err
+	}
+	buf := make([]byte, maxBanner)
+	n, err := conn.Read(buf)
+	result.Latency = time.Since(start).Seconds()
+	if err != nil {
+		if target.Expect == `` && isTimeout(err) {
+			return nil
+		}
+		return err
+	}
+	result.Banner = strings.TrimSpace(string(buf[:n]))
+	if !strings.Contains(string(buf[:n]), target.Expect) {
+		return fmt.Errorf("Expected %q in response", target.Expect)
+	}
+	return nil
+}
+
+func (d *PortCheck) check(target PortTarget) PortResult {
+	result := PortResult{
+		Name:     target.Name,
+		Address:  target.Address,
+		Protocol: target.Protocol,
+	}
+	if result.Name == `` {
+		result.Name = target.Address
+	}
+	if result.Protocol == `` {
+		result.Protocol = `tcp`
+	}
+	var err error
+	if result.Protocol == `udp` {
+		err = d.checkUDP(target, &result)
+	} else {
+		err = d.checkTCP(target, &result)
+	}
+	if err != nil {
+		result.Error = err.Error()
+	} else {
+		result.Success = true
+	}
+	return result
+}
+
+// CheckPorts : probe all targets concurrently, results keep the order of
+// the targets
+func (d *PortCheck) CheckPorts() []PortResult {
+	results := make([]PortResult, len(d.Targets))
+	var wg sync.WaitGroup
+	for index, target := range d.Targets {
+		wg.Add(1)
+		go func(index int, target PortTarget) {
+			defer wg.Done()
+			results[index] = d.check(target)
+		}(index, target)
+	}
+	wg.Wait()
+	return results
+}
+
+func (d *PortCheck) ReadFile(path string) (string, error) {
+	return ``, errors.New("Cannot read files on portcheck driver")
+}
+
+func (d *PortCheck) RunCommand(command string) (string, error) {
+	return ``, errors.New("Cannot run commands on portcheck driver")
+}
+
+func (d *PortCheck) GetDetails() (SystemDetails, error) {
+	if d.Info == nil {
+		d.Info = &SystemDetails{
+			Name:        "portcheck",
+			Extra:       d.Name,
+			IsPortCheck: true,
+		}
+	}
+	return *d.Info, nil
+}
@@ -0,0 +1,146 @@
+package driver
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// listenTCP : serves every connection with handle on a random port
+func listenTCP(t *testing.T, handle func(net.Conn)) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				handle(conn)
+			}()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+// closedPort : address nothing is listening on
+func closedPort(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := listener.Addr().String()
+	listener.Close()
+	return address
+}
+
+func TestPortCheckTCP(t *testing.T) {
+	banner := listenTCP(t, func(conn net.Conn) {
+		conn.Write([]byte("SSH-2.0-OpenSSH_9.0\r\n"))
+	})
+	redis := listenTCP(t, func(conn net.Conn) {
+		buf := make([]byte, 64)
+		n, _ := conn.Read(buf)
+		if string(buf[:n]) == "PING\r\n" {
+			conn.Write([]byte("+PONG\r\n"))
+		}
+	})
+	tlsServer := httptest.NewTLSServer(http.NotFoundHandler())
+	t.Cleanup(tlsServer.Close)
+	tlsAddress := strings.TrimPrefix(tlsServer.URL, "https://")
+	d := &PortCheck{
+		Name:    "datastores",
+		Timeout: time.Second,
+		Targets: []PortTarget{
+			{Address: banner},
+			{Name: "ssh", Address: banner, Expect: "SSH-2.0"},
+			{Name: "redis", Address: redis, Send: `PING\r\n`, Expect: "+PONG"},
+			{Name: "wrong banner", Address: banner, Expect: "220 "},
+			{Name: "closed", Address: closedPort(t)},
+			{Name: "tls", Address: tlsAddress, TLS: true, InsecureSkipVerify: true},
+			{Name: "untrusted tls", Address: tlsAddress, TLS: true},
+		},
+	}
+	results := d.CheckPorts()
+	expected := map[string]bool{
+		banner:          true,
+		"ssh":           true,
+		"redis":         true,
+		"wrong banner":  false,
+		"closed":        false,
+		"tls":           true,
+		"untrusted tls": false,
+	}
+	for _, result := range results {
+		if result.Success != expected[result.Name] {
+			t.Errorf("Expected success %t for %s, found %+v", expected[result.Name], result.Name, result)
+		}
+		if result.Protocol != "tcp" {
+			t.Errorf("Expected tcp protocol by default, found %s", result.Protocol)
+		}
+	}
+	if results[1].Banner != "SSH-2.0-OpenSSH_9.0" || results[2].Banner != "+PONG" {
+		t.Errorf("Unexpected banners %q and %q", results[1].Banner, results[2].Banner)
+	}
+	if results[5].HandshakeLatency <= 0 || results[5].Latency <= 0 {
+		t.Errorf("Expected latencies for tls, found %+v", results[5])
+	}
+	if results[4].Error == "" {
+		t.Error("Expected error for closed port")
+	}
+}
+
+func TestPortCheckUDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	go func() {
+		buf := make([]byte, 64)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			if buf[0] == 0 {
+				conn.WriteTo(append([]byte("echo "), buf[1:n]...), addr)
+			}
+		}
+	}()
+	closed, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closed.Close()
+	d := &PortCheck{
+		Timeout: 200 * time.Millisecond,
+		Targets: []PortTarget{
+			{Name: "echo", Address: conn.LocalAddr().String(), Protocol: "udp", Send: `\x00ping`, Expect: "echo ping"},
+			{Name: "silent", Address: conn.LocalAddr().String(), Protocol: "udp", Send: "ping"},
+			{Name: "no reply", Address: conn.LocalAddr().String(), Protocol: "udp", Send: "ping", Expect: "pong"},
+			{Name: "unreachable", Address: closed.LocalAddr().String(), Protocol: "udp", Send: "ping"},
+		},
+	}
+	results := d.CheckPorts()
+	if !results[0].Success || results[0].Banner != "echo ping" {
+		t.Errorf("Expected echo to succeed, found %+v", results[0])
+	}
+	if !results[1].Success {
+		t.Errorf("Expected silent udp port to be open, found %+v", results[1])
+	}
+	if results[2].Success {
+		t.Errorf("Expected missing response to fail, found %+v", results[2])
+	}
+	if results[3].Success {
+		t.Errorf("Expected port unreachable to fail, found %+v", results[3])
+	}
+}
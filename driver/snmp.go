@@ -0,0 +1,316 @@
+package driver
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gosnmp/gosnmp"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	// DefaultSNMPPort : port SNMP agents listen on
+	DefaultSNMPPort = 161
+	snmpTimeout     = 5 * time.Second
+	// SysDescrOID : sysDescr.0 of SNMPv2-MIB
+	SysDescrOID = "1.3.6.1.2.1.1.1.0"
+	// SysNameOID : sysName.0 of SNMPv2-MIB
+	SysNameOID = "1.3.6.1.2.1.1.5.0"
+)
+
+type SNMPError struct {
+	content string
+	target  string
+}
+
+func (e *SNMPError) Error() string {
+	return fmt.Sprintf("SNMP Error on %s: %s", e.target, e.content)
+}
+
+// SNMPVariable : value of an OID, integers and counters are converted to
+// int64 or uint64, octet strings to string
+type SNMPVariable struct {
+	OID   string
+	Type  string
+	Value interface{}
+}
+
+// Found : false for missing objects, instances and end of the MIB
+func (v SNMPVariable) Found() bool {
+	switch v.Type {
+	case "NoSuchObject", "NoSuchInstance", "EndOfMibView", "Null":
+		return false
+	}
+	return true
+}
+
+// SNMPProvider : drivers that can query an SNMP agent
+type SNMPProvider interface {
+	SNMPGet(oids []string) ([]SNMPVariable, error)
+	SNMPWalk(root string) ([]SNMPVariable, error)
+}
+
+// SNMP : Driver for querying network devices over SNMP
+type SNMP struct {
+	driverBase
+	Host string
+	// Port defaults to DefaultSNMPPort
+	Port int
+	// Version is one of 1, 2c (default) or 3
+	Version   string
+	Community string
+	// Username and the following are used by version 3 only
+	Username string
+	// SecurityLevel is one of noAuthNoPriv, authNoPriv or authPriv
+	SecurityLevel string
+	// AuthProtocol is one of MD5, SHA, SHA224, SHA256, SHA384, SHA512
+	AuthProtocol string
+	AuthPassword string
+	// PrivProtocol is one of DES, AES, AES192, AES256, AES192C, AES256C
+	PrivProtocol string
+	PrivPassword string
+	ContextName  string
+	mu           sync.Mutex
+	client       *gosnmp.GoSNMP
+}
+
+func (d *SNMP) String() string {
+	return fmt.Sprintf("snmp (%s)", d.target())
+}
+
+func (d *SNMP) target() string {
+	port := d.Port
+	if port == 0 {
+		port = DefaultSNMPPort
+	}
+	return net.JoinHostPort(d.Host, strconv.Itoa(port))
+}
+
+var snmpAuthProtocols = map[string]gosnmp.SnmpV3AuthProtocol{
+	"":       gosnmp.NoAuth,
+	"MD5":    gosnmp.MD5,
+	"SHA":    gosnmp.SHA,
+	"SHA224": gosnmp.SHA224,
+	"SHA256": gosnmp.SHA256,
+	"SHA384": gosnmp.SHA384,
+	"SHA512": gosnmp.SHA512,
+}
+
+var snmpPrivProtocols = map[string]gosnmp.SnmpV3PrivProtocol{
+	"":        gosnmp.NoPriv,
+	"DES":     gosnmp.DES,
+	"AES":     gosnmp.AES,
+	"AES192":  gosnmp.AES192,
+	"AES256":  gosnmp.AES256,
+	"AES192C": gosnmp.AES192C,
+	"AES256C": gosnmp.AES256C,
+}
+
+var snmpSecurityLevels = map[string]gosnmp.SnmpV3MsgFlags{
+	"":             gosnmp.NoAuthNoPriv,
+	"noAuthNoPriv": gosnmp.NoAuthNoPriv,
+	"authNoPriv":   gosnmp.AuthNoPriv,
+	"authPriv":     gosnmp.AuthPriv,
+}
+
+func (d *SNMP) config() (*gosnmp.GoSNMP, error) {
+	port := d.Port
+	if port == 0 {
+		port = DefaultSNMPPort
+	}
+	client := &gosnmp.GoSNMP{
+		Target:             d.Host,
+		Port:               uint16(port),
+		Transport:          "udp",
+		Community:          d.Community,
+		Timeout:            snmpTimeout,
+		Retries:            2,
+		ExponentialTimeout: true,
+		MaxOids:            gosnmp.MaxOids,
+		MaxRepetitions:     25,
+		ContextName:        d.ContextName,
+	}
+	if client.Community == "" {
+		client.Community = "public"
+	}
+	switch d.Version {
+	case "1":
+		client.Version = gosnmp.Version1
+	case "", "2c":
+		client.Version = gosnmp.Version2c
+	case "3":
+		level, ok := snmpSecurityLevels[d.SecurityLevel]
+		if !ok {
+			return nil, fmt.Errorf("Unsupported SNMP security level %s", d.SecurityLevel)
+		}
+		auth, ok := snmpAuthProtocols[strings.ToUpper(d.AuthProtocol)]
+		if !ok {
+			return nil, fmt.Errorf("Unsupported SNMP auth protocol %s", d.AuthProtocol)
+		}
+		priv, ok := snmpPrivProtocols[strings.ToUpper(d.PrivProtocol)]
+		if !ok {
+			return nil, fmt.Errorf("Unsupported SNMP privacy protocol %s", d.PrivProtocol)
+		}
+		client.Version = gosnmp.Version3
+		client.SecurityModel = gosnmp.UserSecurityModel
+		client.MsgFlags = level
+		client.SecurityParameters = &gosnmp.UsmSecurityParameters{
+			UserName:                 d.Username,
+			AuthenticationProtocol:   auth,
+			AuthenticationPassphrase: d.AuthPassword,
+			PrivacyProtocol:          priv,
+			PrivacyPassphrase:        d.PrivPassword,
+		}
+	default:
+		return nil, fmt.Errorf("Unsupported SNMP version %s", d.Version)
+	}
+	return client, nil
+}
+
+// connect : returns client, the socket is only opened once as SNMP
+// runs over connectionless UDP
+func (d *SNMP) connect() (*gosnmp.GoSNMP, error) {
+	if d.client != nil {
+		return d.client, nil
+	}
+	client, err := d.config()
+	if err != nil {
+		return nil, err
+	}
+	if err = client.Connect(); err != nil {
+		return nil, &SNMPError{content: err.Error(), target: d.target()}
+	}
+	d.client = client
+	return client, nil
+}
+
+func snmpVariable(pdu gosnmp.SnmpPDU) SNMPVariable {
+	variable := SNMPVariable{
+		OID:  strings.TrimPrefix(pdu.Name, "."),
+		Type: pdu.Type.String(),
+	}
+	switch pdu.Type {
+	case gosnmp.OctetString:
+		if value, ok := pdu.Value.([]byte); ok {
+			variable.Value = string(value)
+		}
+	case gosnmp.Integer:
+		variable.Value = gosnmp.ToBigInt(pdu.Value).Int64()
+	case gosnmp.Counter32, gosnmp.Gauge32, gosnmp.TimeTicks, gosnmp.Counter64, gosnmp.Uinteger32:
+		variable.Value = gosnmp.ToBigInt(pdu.Value).Uint64()
+	case gosnmp.ObjectIdentifier:
+		variable.Value = strings.TrimPrefix(fmt.Sprintf("%v", pdu.Value), ".")
+	case gosnmp.NoSuchObject, gosnmp.NoSuchInstance, gosnmp.EndOfMibView, gosnmp.Null:
+		variable.Value = nil
+	default:
+		variable.Value = pdu.Value
+	}
+	return variable
+}
+
+func (d *SNMP) SNMPGet(oids []string) ([]SNMPVariable, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	client, err := d.connect()
+	if err != nil {
+		return nil, err
+	}
+	variables := []SNMPVariable{}
+	// agents reject requests with more than MaxOids variables
+	for start := 0; start < len(oids); start += client.MaxOids {
+		end := start + client.MaxOids
+		if end > len(oids) {
+			end = len(oids)
+		}
+		result, err := client.Get(oids[start:end])
+		if err != nil {
+			return nil, &SNMPError{content: err.Error(), target: d.target()}
+		}
+		if result.Error != gosnmp.NoError {
+			return nil, &SNMPError{content: result.Error.String(), target: d.target()}
+		}
+		for _, pdu := range result.Variables {
+			variables = append(variables, snmpVariable(pdu))
+		}
+	}
+	return variables, nil
+}
+
+func (d *SNMP) SNMPWalk(root string) ([]SNMPVariable, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	client, err := d.connect()
+	if err != nil {
+		return nil, err
+	}
+	var pdus []gosnmp.SnmpPDU
+	// GetBulk is not available in version 1
+	if client.Version == gosnmp.Version1 {
+		pdus, err = client.WalkAll(root)
+	} else {
+		pdus, err = client.BulkWalkAll(root)
+	}
+	if err != nil {
+		return nil, &SNMPError{content: err.Error(), target: d.target()}
+	}
+	variables := make([]SNMPVariable, 0, len(pdus))
+	for _, pdu := range pdus {
+		variables = append(variables, snmpVariable(pdu))
+	}
+	return variables, nil
+}
+
+func (d *SNMP) ReadFile(path string) (string, error) {
+	return ``, errors.New("Cannot read files over SNMP")
+}
+
+func (d *SNMP) RunCommand(command string) (string, error) {
+	return ``, errors.New("Cannot run commands over SNMP")
+}
+
+func (d *SNMP) GetDetails() (SystemDetails, error) {
+	if d.Info == nil {
+		variables, err := d.SNMPGet([]string{SysDescrOID, SysNameOID})
+		if err != nil {
+			return SystemDetails{}, err
+		}
+		details := &SystemDetails{
+			Name:   "snmp",
+			IsSNMP: true,
+		}
+		for _, variable := range variables {
+			value, _ := variable.Value.(string)
+			switch variable.OID {
+			case SysNameOID:
+				details.Name = value
+			case SysDescrOID:
+				details.Extra = value
+			}
+		}
+		log.Debugf("Found SNMP agent %s: %s", details.Name, details.Extra)
+		d.Info = details
+	}
+	return *d.Info, nil
+}
+
+// compareOIDs : numeric comparison of dotted OIDs
+func compareOIDs(a, b string) int {
+	partsA := strings.Split(strings.TrimPrefix(a, "."), ".")
+	partsB := strings.Split(strings.TrimPrefix(b, "."), ".")
+	for index := 0; index < len(partsA) && index < len(partsB); index++ {
+		numA, _ := strconv.ParseUint(partsA[index], 10, 64)
+		numB, _ := strconv.ParseUint(partsB[index], 10, 64)
+		if numA != numB {
+			if numA < numB {
+				return -1
+			}
+			return 1
+		}
+	}
+	return len(partsA) - len(partsB)
+}
@@ -0,0 +1,179 @@
+package driver
+
+import (
+	"net"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gosnmp/gosnmp"
+)
+
+// fakeSNMPAgent : in-process v2c responder answering get, getnext and
+// getbulk requests from a fixed table
+type fakeSNMPAgent struct {
+	conn      net.PacketConn
+	community string
+	oids      []string
+	values    map[string]gosnmp.SnmpPDU
+}
+
+func newFakeSNMPAgent(t *testing.T, community string, pdus []gosnmp.SnmpPDU) int {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	agent := &fakeSNMPAgent{
+		conn:      conn,
+		community: community,
+		values:    make(map[string]gosnmp.SnmpPDU),
+	}
+	for _, pdu := range pdus {
+		oid := strings.TrimPrefix(pdu.Name, ".")
+		agent.oids = append(agent.oids, oid)
+		agent.values[oid] = pdu
+	}
+	sort.Slice(agent.oids, func(a, b int) bool {
+		return compareOIDs(agent.oids[a], agent.oids[b]) < 0
+	})
+	go agent.serve()
+	t.Cleanup(func() { conn.Close() })
+	return conn.LocalAddr().(*net.UDPAddr).Port
+}
+
+func (agent *fakeSNMPAgent) get(oid string) gosnmp.SnmpPDU {
+	oid = strings.TrimPrefix(oid, ".")
+	if pdu, ok := agent.values[oid]; ok {
+		return pdu
+	}
+	return gosnmp.SnmpPDU{Name: oid, Type: gosnmp.NoSuchObject}
+}
+
+func (agent *fakeSNMPAgent) next(oid string) gosnmp.SnmpPDU {
+	oid = strings.TrimPrefix(oid, ".")
+	for _, candidate := range agent.oids {
+		if compareOIDs(candidate, oid) > 0 {
+			return agent.values[candidate]
+		}
+	}
+	return gosnmp.SnmpPDU{Name: oid, Type: gosnmp.EndOfMibView}
+}
+
+func (agent *fakeSNMPAgent) serve() {
+	decoder := &gosnmp.GoSNMP{Version: gosnmp.Version2c}
+	buf := make([]byte, 65535)
+	for {
+		n, addr, err := agent.conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		request, err := decoder.SnmpDecodePacket(buf[:n])
+		// agents silently drop requests with the wrong community
+		if err != nil || request.Community != agent.community {
+			continue
+		}
+		var variables []gosnmp.SnmpPDU
+		for _, variable := range request.Variables {
+			switch request.PDUType {
+			case gosnmp.GetRequest:
+				variables = append(variables, agent.get(variable.Name))
+			case gosnmp.GetNextRequest:
+				variables = append(variables, agent.next(variable.Name))
+			case gosnmp.GetBulkRequest:
+				oid := variable.Name
+				for repetition := uint32(0); repetition < request.MaxRepetitions; repetition++ {
+					pdu := agent.next(oid)
+					variables = append(variables, pdu)
+					if pdu.Type == gosnmp.EndOfMibView {
+						break
+					}
+					oid = pdu.Name
+				}
+			}
+		}
+		response := &gosnmp.SnmpPacket{
+			Version:   request.Version,
+			Community: request.Community,
+			PDUType:   gosnmp.GetResponse,
+			RequestID: request.RequestID,
+			Variables: variables,
+		}
+		out, err := response.MarshalMsg()
+		if err != nil {
+			continue
+		}
+		agent.conn.WriteTo(out, addr)
+	}
+}
+
+var fakeSNMPTable = []gosnmp.SnmpPDU{
+	{Name: ".1.3.6.1.2.1.1.1.0", Type: gosnmp.OctetString, Value: "Fake switch 1.0"},
+	{Name: ".1.3.6.1.2.1.1.3.0", Type: gosnmp.TimeTicks, Value: uint32(123456)},
+	{Name: ".1.3.6.1.2.1.1.5.0", Type: gosnmp.OctetString, Value: "core-sw-1"},
+	{Name: ".1.3.6.1.2.1.2.2.1.2.1", Type: gosnmp.OctetString, Value: "GigabitEthernet0/1"},
+	{Name: ".1.3.6.1.2.1.2.2.1.2.2", Type: gosnmp.OctetString, Value: "GigabitEthernet0/2"},
+	{Name: ".1.3.6.1.2.1.2.2.1.8.1", Type: gosnmp.Integer, Value: 1},
+	{Name: ".1.3.6.1.2.1.2.2.1.8.2", Type: gosnmp.Integer, Value: 2},
+	{Name: ".1.3.6.1.2.1.31.1.1.1.6.1", Type: gosnmp.Counter64, Value: uint64(1 << 40)},
+}
+
+func TestSNMPOnFakeAgent(t *testing.T) {
+	d := &SNMP{
+		Host:      "127.0.0.1",
+		Port:      newFakeSNMPAgent(t, "secret", fakeSNMPTable),
+		Community: "secret",
+	}
+	details, err := d.GetDetails()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !details.IsSNMP || details.Name != "core-sw-1" || details.Extra != "Fake switch 1.0" {
+		t.Errorf("Unexpected details %v", details)
+	}
+	variables, err := d.SNMPGet([]string{"1.3.6.1.2.1.1.3.0", "1.3.6.1.2.1.1.4.0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if variables[0].Value != uint64(123456) || variables[0].Type != "TimeTicks" {
+		t.Errorf("Unexpected uptime %v", variables[0])
+	}
+	if variables[1].Found() {
+		t.Errorf("Expected missing object, found %v", variables[1])
+	}
+	walked, err := d.SNMPWalk("1.3.6.1.2.1.2.2.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(walked) != 4 || walked[0].Value != "GigabitEthernet0/1" || walked[3].Value != int64(2) {
+		t.Errorf("Unexpected walk %v", walked)
+	}
+	counters, err := d.SNMPWalk("1.3.6.1.2.1.31.1.1.1.6")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(counters) != 1 || counters[0].Value != uint64(1<<40) {
+		t.Errorf("Unexpected 64 bit counter %v", counters)
+	}
+}
+
+func TestSNMPWrongCommunity(t *testing.T) {
+	timeout := snmpTimeout
+	snmpTimeout = 100 * time.Millisecond
+	defer func() { snmpTimeout = timeout }()
+	d := &SNMP{
+		Host:      "127.0.0.1",
+		Port:      newFakeSNMPAgent(t, "secret", fakeSNMPTable),
+		Community: "public",
+	}
+	if _, err := d.GetDetails(); err == nil {
+		t.Error("Expected timeout with wrong community")
+	}
+}
+
+func TestSNMPInvalidVersion(t *testing.T) {
+	d := &SNMP{Host: "127.0.0.1", Version: "3", SecurityLevel: "authPriv", AuthProtocol: "CRC"}
+	if _, err := d.SNMPGet([]string{SysNameOID}); err == nil {
+		t.Error("Expected error for unsupported auth protocol")
+	}
+}
@@ -1,18 +1,44 @@
 package driver
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/melbahja/goph"
 	log "github.com/sirupsen/logrus"
 	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
 )
 
 var (
 	port = 22
+	// sshTimeout : timeout of dialing and handshaking with a host
+	sshTimeout = goph.DefaultTimeout
+	// jump host clients shared across hosts keyed by chain
+	jumpMu      sync.Mutex
+	jumpClients = make(map[string]*ssh.Client)
+	// errBrokenHop : a jump host could not tunnel as its connection is
+	// broken, unlike targets it rejects or cannot reach
+	errBrokenHop = errors.New("connection to jump host is broken")
+	// connections to local ssh-agents keyed by socket, shared by every
+	// driver and reconnect as signers of an agent sign through it
+	agentsMu sync.Mutex
+	agents   = make(map[string]*agentConn)
 )
 
+// agentConn : client of a local ssh-agent and its connection
+type agentConn struct {
+	client agent.ExtendedAgent
+	conn   net.Conn
+}
+
 type SSHConnectError struct {
 	content string
 	client  string
@@ -44,60 +70,447 @@ type SSH struct {
 	KeyFile string
 	// Pass key for key file
 	KeyPass string
-	// Password based login
+	// Password based login, also used to answer keyboard-interactive prompts
 	Password string
+	// Path to OpenSSH user certificate signed by a CA
+	CertFile string
+	// Ordered auth methods to try e.g []string{"agent", "certificate", "password"}
+	// defaults to password, key or agent depending on what is set
+	AuthMethods []string
+	// Forward the local ssh-agent (SSH_AUTH_SOCK) to the remote host
+	AgentForwarding bool
+	// Jump hosts to connect through in order, the first hop is dialed directly
+	ProxyJump []*SSH
 	// Check known hosts (only disable for tests
 	CheckKnownHosts bool
 	// set environmental vars for server e.g []string{"DEBUG=1", "FAKE=echo"}
 	EnvVars       []string
 	SessionClient *goph.Client
+	// DockerSocket of the remote docker engine, defaults to DefaultDockerSocket
+	DockerSocket string
+	engine       *DockerEngine
+	// Batch runs the commands of a poll in one session on unix hosts,
+	// commands run with become are not batched
+	Batch bool
+	batch commandBatch
 }
 
 func (d *SSH) String() string {
 	return fmt.Sprintf("%s (%s)", d.User, d.Host)
 }
 
+func (d *SSH) address() string {
+	return fmt.Sprintf("%s@%s:%d", d.User, d.Host, d.port())
+}
+
+func (d *SSH) port() int {
+	if d.Port != 0 {
+		return d.Port
+	}
+	return port
+}
+
+func (d *SSH) config() (*goph.Config, error) {
+	var callback ssh.HostKeyCallback
+	auth, err := d.Auth()
+	if err != nil {
+		return nil, err
+	}
+	if d.CheckKnownHosts {
+		callback, err = goph.DefaultKnownHosts()
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		callback = ssh.InsecureIgnoreHostKey()
+	}
+	return &goph.Config{
+		User:     d.User,
+		Addr:     d.Host,
+		Port:     uint(d.port()),
+		Auth:     auth,
+		Timeout:  sshTimeout,
+		Callback: callback,
+	}, nil
+}
+
 // set the goph Client
 func (d *SSH) Client() (*goph.Client, error) {
 	if d.SessionClient == nil {
 		log.Infof("re-establishing connection with %s ...", d.Host)
-		var err error
 		var client *goph.Client
-		var auth goph.Auth
-		var callback ssh.HostKeyCallback
-		if d.Port != 0 {
-			port = d.Port
+		conf, err := d.config()
+		if err != nil {
+			return nil, err
 		}
-		if d.Password != "" {
-			auth = goph.Password(d.Password)
+		if len(d.ProxyJump) == 0 {
+			client, err = goph.NewConn(conf)
 		} else {
-			auth, err = goph.Key(d.KeyFile, d.KeyPass)
+			client, err = d.dialThroughJumpHosts(conf)
+		}
+		if err == nil && d.AgentForwarding {
+			if err = agent.ForwardToRemote(client.Client, os.Getenv("SSH_AUTH_SOCK")); err != nil {
+				client.Close()
+				return nil, err
+			}
+		}
+		if err == nil {
+			d.SessionClient = client
+		}
+		return client, err
+	}
+	return d.SessionClient, nil
+}
+
+func (d *SSH) dialThroughJumpHosts(conf *goph.Config) (*goph.Client, error) {
+	bastion, err := jumpClient(d.ProxyJump)
+	if err != nil {
+		return nil, err
+	}
+	client, err := dialSSH(bastion, conf)
+	if err != nil {
+		// only a stale bastion is dropped to be re-established on the next
+		// attempt, tunnels of other hosts through it are kept otherwise
+		if errors.Is(err, errBrokenHop) {
+			keys := jumpKeys(d.ProxyJump)
+			dropJumpClient(keys[len(keys)-1], bastion)
+		}
+		return nil, err
+	}
+	return &goph.Client{
+		Client: client,
+		Config: conf,
+	}, nil
+}
+
+func jumpKeys(hops []*SSH) []string {
+	var keys []string
+	key := ""
+	for _, hop := range hops {
+		key = fmt.Sprintf("%s>%s", key, hop.address())
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// jumpClient : returns the client of the last hop in the chain, each hop
+// is dialed through the one before it. Clients are shared between every
+// host that goes through the same chain
+func jumpClient(hops []*SSH) (*ssh.Client, error) {
+	jumpMu.Lock()
+	defer jumpMu.Unlock()
+	var parent *ssh.Client
+	for index, key := range jumpKeys(hops) {
+		if client, ok := jumpClients[key]; ok {
+			parent = client
+			continue
+		}
+		log.Infof("connecting to jump host %s ...", hops[index].address())
+		conf, err := hops[index].config()
+		if err != nil {
+			return nil, err
+		}
+		client, err := dialSSH(parent, conf)
+		if err != nil {
+			if index > 0 && errors.Is(err, errBrokenHop) {
+				closeJumpClient(jumpKeys(hops)[index-1], parent)
+			}
+			return nil, &SSHConnectError{
+				content: err.Error(),
+				client:  hops[index].Host,
+			}
+		}
+		jumpClients[key] = client
+		parent = client
+	}
+	return parent, nil
+}
+
+// dropJumpClient : close client of the hop at key unless it was already
+// replaced, hosts tunneled through it reconnect on their next poll
+func dropJumpClient(key string, client *ssh.Client) {
+	jumpMu.Lock()
+	defer jumpMu.Unlock()
+	closeJumpClient(key, client)
+}
+
+// closeJumpClient : dropJumpClient with jumpMu held
+func closeJumpClient(key string, client *ssh.Client) {
+	if jumpClients[key] == client {
+		client.Close()
+		delete(jumpClients, key)
+	}
+}
+
+// dialSSH : dial directly when there is no parent otherwise tunnel
+// the connection through the parent
+func dialSSH(parent *ssh.Client, conf *goph.Config) (*ssh.Client, error) {
+	if parent == nil {
+		return goph.Dial("tcp", conf)
+	}
+	addr := net.JoinHostPort(conf.Addr, fmt.Sprint(conf.Port))
+	conn, err := tunnel(parent, addr, conf.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	// tunneled connections do not support deadlines, so the connection is
+	// closed to end a handshake that does not finish within the timeout
+	timer := time.AfterFunc(conf.Timeout, func() {
+		conn.Close()
+	})
+	clientConn, chans, reqs, err := ssh.NewClientConn(conn, addr, &ssh.ClientConfig{
+		User:            conf.User,
+		Auth:            conf.Auth,
+		Timeout:         conf.Timeout,
+		HostKeyCallback: conf.Callback,
+	})
+	if !timer.Stop() {
+		if err == nil {
+			clientConn.Close()
+		}
+		return nil, fmt.Errorf("ssh handshake with %s timed out after %s", addr, conf.Timeout)
+	}
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return ssh.NewClient(clientConn, chans, reqs), nil
+}
+
+// tunnel : connection to addr through parent, errors other than parent
+// rejecting the connection are errBrokenHop
+func tunnel(parent *ssh.Client, addr string, timeout time.Duration) (net.Conn, error) {
+	type dialed struct {
+		conn net.Conn
+		err  error
+	}
+	result := make(chan dialed, 1)
+	go func() {
+		conn, err := parent.Dial("tcp", addr)
+		result <- dialed{conn, err}
+	}()
+	select {
+	case tunneled := <-result:
+		var rejected *ssh.OpenChannelError
+		if tunneled.err != nil && !errors.As(tunneled.err, &rejected) {
+			return nil, fmt.Errorf("%w: %s", errBrokenHop, tunneled.err)
+		}
+		return tunneled.conn, tunneled.err
+	case <-time.After(timeout):
+		go func() {
+			if tunneled := <-result; tunneled.conn != nil {
+				tunneled.conn.Close()
+			}
+		}()
+		return nil, fmt.Errorf("%w: tunneling to %s timed out after %s", errBrokenHop, addr, timeout)
+	}
+}
+
+func (d *SSH) authMethods() []string {
+	if len(d.AuthMethods) != 0 {
+		return d.AuthMethods
+	}
+	if d.Password != "" {
+		return []string{"password"}
+	}
+	if d.CertFile != "" {
+		return []string{"certificate"}
+	}
+	if d.KeyFile == "" && goph.HasAgent() {
+		return []string{"agent"}
+	}
+	return []string{"key"}
+}
+
+// Auth : build the auth methods to be tried in order. All public key based
+// methods (key, certificate, agent) are tried as a single method as the
+// ssh client only attempts `publickey` once
+func (d *SSH) Auth() (goph.Auth, error) {
+	var (
+		auth          goph.Auth
+		signers       []ssh.Signer
+		publicKeySlot = -1
+	)
+	for _, method := range d.authMethods() {
+		switch method {
+		case "password":
+			auth = append(auth, ssh.Password(d.Password))
+		case "keyboard_interactive":
+			auth = append(auth, ssh.KeyboardInteractive(d.keyboardInteractive))
+		case "key", "certificate", "agent":
+			methodSigners, err := d.signers(method)
 			if err != nil {
 				return nil, err
 			}
+			signers = append(signers, methodSigners...)
+			if publicKeySlot == -1 {
+				publicKeySlot = len(auth)
+				auth = append(auth, nil)
+			}
+		default:
+			return nil, fmt.Errorf("Unsupported ssh auth method %s", method)
+		}
+	}
+	if publicKeySlot != -1 {
+		auth[publicKeySlot] = ssh.PublicKeys(signers...)
+	}
+	return auth, nil
+}
+
+func (d *SSH) signers(method string) ([]ssh.Signer, error) {
+	switch method {
+	case "key":
+		signer, err := goph.GetSigner(d.KeyFile, d.KeyPass)
+		if err != nil {
+			return nil, err
+		}
+		return []ssh.Signer{signer}, nil
+	case "agent":
+		return d.agentSigners()
+	}
+	cert, err := readCertificate(d.CertFile)
+	if err != nil {
+		return nil, err
+	}
+	// certificate is either backed by the key file or by a key in the agent
+	if d.KeyFile != "" {
+		signer, err := goph.GetSigner(d.KeyFile, d.KeyPass)
+		if err != nil {
+			return nil, err
 		}
-		if d.CheckKnownHosts {
-			callback, err = goph.DefaultKnownHosts()
+		certSigner, err := ssh.NewCertSigner(cert, signer)
+		if err != nil {
+			return nil, err
+		}
+		return []ssh.Signer{certSigner}, nil
+	}
+	agentKeys, err := d.agentSigners()
+	if err != nil {
+		return nil, err
+	}
+	certKey := cert.Key.Marshal()
+	for _, signer := range agentKeys {
+		if string(signer.PublicKey().Marshal()) == string(certKey) {
+			certSigner, err := ssh.NewCertSigner(cert, signer)
 			if err != nil {
 				return nil, err
 			}
+			return []ssh.Signer{certSigner}, nil
+		}
+	}
+	return nil, fmt.Errorf("Could not find key for certificate %s in ssh agent", d.CertFile)
+}
+
+// keyboardInteractive : answer every prompt that does not echo with
+// the password, prompts that echo are answered with the username
+func (d *SSH) keyboardInteractive(user, instruction string, questions []string, echos []bool) ([]string, error) {
+	answers := make([]string, len(questions))
+	for index := range questions {
+		if echos[index] {
+			answers[index] = d.User
 		} else {
-			callback = ssh.InsecureIgnoreHostKey()
-		}
-		client, err = goph.NewConn(&goph.Config{
-			User:     d.User,
-			Addr:     d.Host,
-			Port:     uint(port),
-			Auth:     auth,
-			Timeout:  goph.DefaultTimeout,
-			Callback: callback,
-		})
-		if err == nil {
-			d.SessionClient = client
+			answers[index] = d.Password
 		}
-		return client, err
 	}
-	return d.SessionClient, nil
+	return answers, nil
+}
+
+// agentSigners : keys of the local ssh-agent, the agent is dialed once
+// and dialed again only after it failed e.g when it was restarted
+func (d *SSH) agentSigners() ([]ssh.Signer, error) {
+	if !goph.HasAgent() {
+		return nil, errors.New("SSH_AUTH_SOCK is not set, could not find ssh agent")
+	}
+	socket := os.Getenv("SSH_AUTH_SOCK")
+	agentsMu.Lock()
+	defer agentsMu.Unlock()
+	shared, ok := agents[socket]
+	if !ok {
+		conn, err := net.Dial("unix", socket)
+		if err != nil {
+			return nil, fmt.Errorf("could not find ssh agent: %w", err)
+		}
+		shared = &agentConn{client: agent.NewClient(conn), conn: conn}
+		agents[socket] = shared
+	}
+	signers, err := shared.client.Signers()
+	if err != nil {
+		shared.conn.Close()
+		delete(agents, socket)
+		return nil, err
+	}
+	return signers, nil
+}
+
+func readCertificate(path string) (*ssh.Certificate, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	pub, _, _, _, err := ssh.ParseAuthorizedKey(content)
+	if err != nil {
+		return nil, err
+	}
+	cert, ok := pub.(*ssh.Certificate)
+	if !ok {
+		return nil, fmt.Errorf("%s is not an OpenSSH certificate", path)
+	}
+	return cert, nil
+}
+
+// run : execute command in a new session, requesting agent forwarding
+// for the session if enabled and writing stdin if any
+func (d *SSH) run(client *goph.Client, command string, stdin string) ([]byte, error) {
+	if !d.AgentForwarding && stdin == "" {
+		return client.Run(command)
+	}
+	sess, err := client.NewSession()
+	if err != nil {
+		return nil, err
+	}
+	defer sess.Close()
+	if d.AgentForwarding {
+		if err = agent.RequestAgentForwarding(sess); err != nil {
+			return nil, err
+		}
+	}
+	if stdin != "" {
+		sess.Stdin = strings.NewReader(stdin)
+	}
+	return sess.CombinedOutput(command)
+}
+
+// DockerEngine : engine on the remote socket forwarded through the
+// ssh connection
+func (d *SSH) DockerEngine() (*DockerEngine, error) {
+	if d.engine != nil {
+		return d.engine, nil
+	}
+	socket := d.DockerSocket
+	if socket == "" {
+		socket = DefaultDockerSocket
+	}
+	d.engine = NewDockerEngine(func(ctx context.Context) (net.Conn, error) {
+		client, err := d.Client()
+		if err != nil {
+			return nil, &SSHConnectError{
+				content: err.Error(),
+				client:  d.Host,
+			}
+		}
+		conn, err := client.Dial("unix", socket)
+		// the host refuses channels to missing sockets, other errors are
+		// of a dropped session
+		var rejected *ssh.OpenChannelError
+		if err != nil && !errors.As(err, &rejected) {
+			return nil, &SSHConnectError{
+				content: err.Error(),
+				client:  d.Host,
+			}
+		}
+		return conn, err
+	})
+	return d.engine, nil
 }
 
 func (d *SSH) ReadFile(path string) (string, error) {
@@ -107,6 +520,28 @@ func (d *SSH) ReadFile(path string) (string, error) {
 }
 
 func (d *SSH) RunCommand(command string) (string, error) {
+	out, err := d.RunCommandWithInput(command, "")
+	if err != nil {
+		return ``, err
+	}
+	return out, nil
+}
+
+// RunCommandWithInput : run command writing input to its stdin, output
+// is returned alongside errors to help diagnose them. Commands without
+// input are served from the batch of the poll
+func (d *SSH) RunCommandWithInput(command string, input string) (string, error) {
+	if d.Batch && input == "" {
+		if result, ok := d.batch.result(command); ok {
+			log.Debugf("Serving batched command %s", command)
+			return result.output, result.err
+		}
+	}
+	return d.exec(command, input)
+}
+
+// exec : run command on the host outside of batches
+func (d *SSH) exec(command string, input string) (string, error) {
 	// TODO: Ensure clients of all SSH drivers are closed on context end
 	// i.e d.SessionClient.Close()
 	log.Debugf("Running remote command %s", command)
@@ -122,7 +557,7 @@ func (d *SSH) RunCommand(command string) (string, error) {
 		envline := strings.Join(d.EnvVars, ";")
 		command = strings.Join([]string{envline, command}, ";")
 	}
-	out, err := client.Run(command)
+	out, err := d.run(client, command, input)
 	if err != nil {
 		// Connection has to be rebooted cuz EOF
 		if strings.Contains(fmt.Sprintf("%s", err), "EOF") {
@@ -131,7 +566,7 @@ func (d *SSH) RunCommand(command string) (string, error) {
 				client:  d.Host,
 			}
 		}
-		return ``, err
+		return string(out), err
 	}
 	return string(out), nil
 }
@@ -170,3 +605,32 @@ func (d *SSH) GetDetails() (SystemDetails, error) {
 	}
 	return *d.Info, nil
 }
+
+// StartBatch : run the commands of the last poll in one session, the
+// sections that could not be read are run alone when requested
+func (d *SSH) StartBatch() (func(), error) {
+	noop := func() {}
+	if !d.Batch {
+		return noop, nil
+	}
+	details, err := d.GetDetails()
+	if err != nil {
+		return noop, err
+	}
+	if details.IsWindows {
+		return noop, nil
+	}
+	commands, ok := d.batch.start()
+	if !ok {
+		log.Debugf("Batch of %s is still open for the last poll", d.Host)
+		return noop, nil
+	}
+	if len(commands) == 0 {
+		return d.batch.end, nil
+	}
+	log.Debugf("Running %d batched commands on %s", len(commands), d.Host)
+	marker := batchMarker()
+	out, err := d.exec(batchScript(marker, commands), "")
+	d.batch.fill(splitBatch(marker, commands, out))
+	return d.batch.end, err
+}
@@ -1,14 +1,25 @@
 package driver
 
 import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
 	"fmt"
+	"io"
+	"net"
 	"os"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/bisohns/saido/config"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
 )
 
 func SkipNonLinuxOnCI() bool {
@@ -63,3 +74,308 @@ func TestSSHSystemDetails(t *testing.T) {
 		t.Errorf("Expected linux server for ssh test got %#v", details)
 	}
 }
+
+func TestSSHAuthWithCertificateInAgent(t *testing.T) {
+	_, caKey, _ := ed25519.GenerateKey(rand.Reader)
+	_, userKey, _ := ed25519.GenerateKey(rand.Reader)
+	caSigner, _ := ssh.NewSignerFromKey(caKey)
+	userSigner, _ := ssh.NewSignerFromKey(userKey)
+	cert := &ssh.Certificate{
+		Key:             userSigner.PublicKey(),
+		CertType:        ssh.UserCert,
+		ValidPrincipals: []string{"ci-dev"},
+		ValidBefore:     ssh.CertTimeInfinity,
+	}
+	if err := cert.SignCert(rand.Reader, caSigner); err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	certPath := filepath.Join(dir, "id_ed25519-cert.pub")
+	os.WriteFile(certPath, ssh.MarshalAuthorizedKey(cert), 0600)
+
+	keyring := agent.NewKeyring()
+	keyring.Add(agent.AddedKey{PrivateKey: userKey})
+	sock := filepath.Join(dir, "agent.sock")
+	listener, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	var dialed int32
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			atomic.AddInt32(&dialed, 1)
+			go agent.ServeAgent(keyring, conn)
+		}
+	}()
+	t.Setenv("SSH_AUTH_SOCK", sock)
+
+	addr, _ := newTestSSHServer(t, caSigner.PublicKey())
+	host, portStr, _ := net.SplitHostPort(addr)
+	serverPort, _ := strconv.Atoi(portStr)
+	// the server only accepts the certificate, keyboard-interactive is
+	// tried after publickey
+	newDriver := func() *SSH {
+		return &SSH{
+			User:        "ci-dev",
+			Host:        host,
+			Port:        serverPort,
+			CertFile:    certPath,
+			Password:    "secret",
+			AuthMethods: []string{"certificate", "agent", "keyboard_interactive"},
+		}
+	}
+	d := newDriver()
+	auth, err := d.Auth()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// certificate and agent are merged into a single publickey method
+	if len(auth) != 2 {
+		t.Errorf("Expected 2 auth methods, got %d", len(auth))
+	}
+	// drivers replaced on reconnect reuse the connection to the agent
+	for reconnect := 0; reconnect < 3; reconnect++ {
+		output, err := newDriver().RunCommand(`uname`)
+		if err != nil || output != "ran uname" {
+			t.Fatalf("Expected certificate in agent to authenticate, got %s %v", output, err)
+		}
+	}
+	if count := atomic.LoadInt32(&dialed); count != 1 {
+		t.Errorf("Expected agent to be dialed once, dialed %d times", count)
+	}
+	d.AuthMethods = []string{"unknown"}
+	if _, err = d.Auth(); err == nil {
+		t.Error("Expected error for unknown auth method")
+	}
+}
+
+// newTestSSHServer : in-process ssh server accepting password `secret`
+// and certificates of authorities, that echoes exec commands and supports
+// direct-tcpip forwarding
+func newTestSSHServer(t *testing.T, authorities ...ssh.PublicKey) (string, *int32) {
+	var connections int32
+	_, hostKey, _ := ed25519.GenerateKey(rand.Reader)
+	hostSigner, _ := ssh.NewSignerFromKey(hostKey)
+	serverConfig := &ssh.ServerConfig{
+		PasswordCallback: func(c ssh.ConnMetadata, pass []byte) (*ssh.Permissions, error) {
+			if string(pass) == "secret" {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("password rejected for %s", c.User())
+		},
+	}
+	checker := &ssh.CertChecker{
+		IsUserAuthority: func(auth ssh.PublicKey) bool {
+			for _, authority := range authorities {
+				if bytes.Equal(authority.Marshal(), auth.Marshal()) {
+					return true
+				}
+			}
+			return false
+		},
+	}
+	serverConfig.PublicKeyCallback = checker.Authenticate
+	serverConfig.AddHostKey(hostSigner)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			atomic.AddInt32(&connections, 1)
+			go serveTestSSHConn(conn, serverConfig)
+		}
+	}()
+	return listener.Addr().String(), &connections
+}
+
+func serveTestSSHConn(conn net.Conn, serverConfig *ssh.ServerConfig) {
+	_, chans, reqs, err := ssh.NewServerConn(conn, serverConfig)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+	for newChannel := range chans {
+		switch newChannel.ChannelType() {
+		case "direct-tcpip":
+			var target struct {
+				Host       string
+				Port       uint32
+				OriginHost string
+				OriginPort uint32
+			}
+			ssh.Unmarshal(newChannel.ExtraData(), &target)
+			targetConn, err := net.Dial("tcp", net.JoinHostPort(target.Host, fmt.Sprint(target.Port)))
+			if err != nil {
+				newChannel.Reject(ssh.ConnectionFailed, err.Error())
+				continue
+			}
+			channel, requests, _ := newChannel.Accept()
+			go ssh.DiscardRequests(requests)
+			go func() {
+				defer channel.Close()
+				io.Copy(channel, targetConn)
+			}()
+			go func() {
+				defer targetConn.Close()
+				io.Copy(targetConn, channel)
+			}()
+		case "session":
+			channel, requests, _ := newChannel.Accept()
+			go func() {
+				defer channel.Close()
+				for req := range requests {
+					if req.Type != "exec" {
+						req.Reply(false, nil)
+						continue
+					}
+					var exec struct{ Command string }
+					ssh.Unmarshal(req.Payload, &exec)
+					req.Reply(true, nil)
+					fmt.Fprintf(channel, "ran %s", exec.Command)
+					channel.SendRequest("exit-status", false, ssh.Marshal(struct{ Status uint32 }{0}))
+					return
+				}
+			}()
+		default:
+			newChannel.Reject(ssh.UnknownChannelType, "unsupported channel")
+		}
+	}
+}
+
+func TestSSHProxyJump(t *testing.T) {
+	addr, connections := newTestSSHServer(t)
+	host, portStr, _ := net.SplitHostPort(addr)
+	serverPort, _ := strconv.Atoi(portStr)
+	bastion := config.Connection{
+		Username: "jump",
+		Password: "secret",
+		Host:     host,
+		Port:     int32(serverPort),
+	}
+	// every host in the group is reached through the same bastion
+	for _, user := range []string{"first", "second"} {
+		d := ToDriver(config.Connection{
+			Type:      "ssh",
+			Username:  user,
+			Password:  "secret",
+			Host:      host,
+			Port:      int32(serverPort),
+			ProxyJump: []config.Connection{bastion},
+		})
+		output, err := d.RunCommand(`uname`)
+		if err != nil || output != "ran uname" {
+			t.Errorf("Expected command to run through jump host, got %s %v", output, err)
+		}
+	}
+	// one connection for the bastion and one per target
+	if count := atomic.LoadInt32(connections); count != 3 {
+		t.Errorf("Expected bastion connection to be shared, got %d connections", count)
+	}
+}
+
+func TestSSHProxyJumpFailures(t *testing.T) {
+	addr, connections := newTestSSHServer(t)
+	host, portStr, _ := net.SplitHostPort(addr)
+	serverPort, _ := strconv.Atoi(portStr)
+	bastion := config.Connection{
+		Username: "failures",
+		Password: "secret",
+		Host:     host,
+		Port:     int32(serverPort),
+	}
+	target := func(user string, password string, port int) Driver {
+		return ToDriver(config.Connection{
+			Type:      "ssh",
+			Username:  user,
+			Password:  password,
+			Host:      host,
+			Port:      int32(port),
+			ProxyJump: []config.Connection{bastion},
+		})
+	}
+	d := target("first", "secret", serverPort)
+	if _, err := d.RunCommand(`uname`); err != nil {
+		t.Fatal(err)
+	}
+	// a target rejecting auth keeps the tunnels of other hosts open
+	if _, err := target("second", "wrong", serverPort).RunCommand(`uname`); err == nil {
+		t.Error("Expected rejected password to fail")
+	}
+	if output, err := d.RunCommand(`uname`); err != nil || output != "ran uname" {
+		t.Errorf("Expected tunnel through bastion to be kept, got %s %v", output, err)
+	}
+
+	// a target that never completes its handshake times out
+	silent, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer silent.Close()
+	go func() {
+		for {
+			conn, err := silent.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+	_, silentPort, _ := net.SplitHostPort(silent.Addr().String())
+	port, _ := strconv.Atoi(silentPort)
+	defer func(timeout time.Duration) { sshTimeout = timeout }(sshTimeout)
+	sshTimeout = 500 * time.Millisecond
+	start := time.Now()
+	if _, err := target("third", "secret", port).RunCommand(`uname`); err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("Expected handshake to time out, found %v", err)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Errorf("Expected handshake to be bounded by the timeout, took %s", time.Since(start))
+	}
+
+	// a broken bastion is dropped and dialed again on the next attempt
+	jumpMu.Lock()
+	for key, client := range jumpClients {
+		if strings.Contains(key, "failures@") {
+			client.Close()
+		}
+	}
+	jumpMu.Unlock()
+	if _, err := target("fourth", "secret", serverPort).RunCommand(`uname`); err == nil {
+		t.Error("Expected broken bastion to fail")
+	}
+	if output, err := target("fourth", "secret", serverPort).RunCommand(`uname`); err != nil || output != "ran uname" {
+		t.Errorf("Expected bastion to be dialed again, got %s %v", output, err)
+	}
+	// bastion twice, first, second and fourth
+	if count := atomic.LoadInt32(connections); count != 5 {
+		t.Errorf("Expected 5 connections, got %d", count)
+	}
+}
+
+func TestSSHDockerEngineConnectErrors(t *testing.T) {
+	addr, _ := newTestSSHServer(t)
+	host, portStr, _ := net.SplitHostPort(addr)
+	serverPort, _ := strconv.Atoi(portStr)
+	d := &SSH{User: "docker", Password: "secret", Host: host, Port: serverPort}
+	engine, _ := d.DockerEngine()
+	var connectErr *SSHConnectError
+	// the test server has no docker socket to forward to
+	if err := engine.Do("GET", "/containers/json", nil, nil); err == nil || errors.As(err, &connectErr) {
+		t.Errorf("Expected missing socket to not be a connect error, found %v", err)
+	}
+	d.SessionClient.Close()
+	if err := engine.Do("GET", "/containers/json", nil, nil); !errors.As(err, &connectErr) {
+		t.Errorf("Expected dropped session to be a connect error, found %v", err)
+	}
+}
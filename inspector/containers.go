@@ -0,0 +1,269 @@
+package inspector
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/bisohns/saido/driver"
+	log "github.com/sirupsen/logrus"
+)
+
+// ContainerMetrics : Metrics used by Containers
+type ContainerMetrics struct {
+	ContainerID   string `desc:"ID of the container"`
+	ContainerName string `desc:"Name of the container"`
+	Image         string `desc:"Image the container runs"`
+	// State e.g running, exited, restarting
+	State string `desc:"State e.g running, exited, restarting"`
+	// Health e.g healthy, unhealthy, starting or empty without healthcheck
+	Health       string `desc:"Health e.g healthy, unhealthy, starting or empty without healthcheck"`
+	RestartCount int    `kind:"counter" desc:"Times the engine restarted the container"`
+	// Percentage of host CPU used
+	CPU        float64 `unit:"%" desc:"Host CPU used"`
+	MemUsage   float64 `unit:"bytes" desc:"Memory used"`
+	Limit      float64 `unit:"bytes" desc:"Memory limit"`
+	MemPercent float64 `unit:"%" desc:"Memory used of the limit"`
+	NetworkRx  float64 `unit:"bytes" kind:"counter" desc:"Bytes received"`
+	NetworkTx  float64 `unit:"bytes" kind:"counter" desc:"Bytes transmitted"`
+	BlockRead  float64 `unit:"bytes" kind:"counter" desc:"Bytes read from block devices"`
+	BlockWrite float64 `unit:"bytes" kind:"counter" desc:"Bytes written to block devices"`
+	Pids       int     `desc:"Processes running in the container"`
+}
+
+// containerSample : raw engine responses for a single container
+type containerSample struct {
+	ID      string `json:"Id"`
+	Names   []string
+	Image   string
+	State   string
+	Inspect *containerInspect `json:",omitempty"`
+	Stats   *containerStats   `json:",omitempty"`
+}
+
+type containerInspect struct {
+	RestartCount int
+	State        struct {
+		Status string
+		Health *struct {
+			Status string
+		}
+	}
+}
+
+type containerCPUStats struct {
+	CPUUsage struct {
+		TotalUsage  uint64   `json:"total_usage"`
+		PercpuUsage []uint64 `json:"percpu_usage"`
+	} `json:"cpu_usage"`
+	SystemUsage uint64 `json:"system_cpu_usage"`
+	OnlineCPUs  uint32 `json:"online_cpus"`
+}
+
+type containerStats struct {
+	CPUStats    containerCPUStats `json:"cpu_stats"`
+	PreCPUStats containerCPUStats `json:"precpu_stats"`
+	MemoryStats struct {
+		Usage uint64            `json:"usage"`
+		Limit uint64            `json:"limit"`
+		Stats map[string]uint64 `json:"stats"`
+	} `json:"memory_stats"`
+	Networks map[string]struct {
+		RxBytes uint64 `json:"rx_bytes"`
+		TxBytes uint64 `json:"tx_bytes"`
+	} `json:"networks"`
+	BlkioStats struct {
+		IoServiceBytesRecursive []struct {
+			Op    string `json:"op"`
+			Value uint64 `json:"value"`
+		} `json:"io_service_bytes_recursive"`
+	} `json:"blkio_stats"`
+	PidsStats struct {
+		Current int `json:"current"`
+	} `json:"pids_stats"`
+}
+
+// Containers : Reading container state and stats from the docker engine API
+type Containers struct {
+	Driver *driver.Driver
+	// Query for listing containers
+	Query string
+	// The values read from the engine are in B
+	RawByteSize string
+	// We want do display memory and io values in MB
+	DisplayByteSize string
+	// Values of metrics being read
+	Values []ContainerMetrics
+}
+
+// Parse : run custom parsing on the samples collected from the engine
+/*
+[{"Id": "3f4e...", "Names": ["/redis"], "Image": "redis", "State": "running",
+  "Inspect": {"RestartCount": 0, "State": {"Status": "running", "Health": {"Status": "healthy"}}},
+  "Stats": {"cpu_stats": {...}, "precpu_stats": {...}, "memory_stats": {...}}}]
+*/
+func (i *Containers) Parse(output string) error {
+	var samples []containerSample
+	values := []ContainerMetrics{}
+	log.Debug("Parsing output string in Containers inspector")
+	if err := json.Unmarshal([]byte(output), &samples); err != nil {
+		i.Values = values
+		return &ParseError{inspector: "Containers", content: err.Error()}
+	}
+	for _, sample := range samples {
+		values = append(values, i.createMetric(sample))
+	}
+	i.Values = values
+	return nil
+}
+
+func (i Containers) bytes(value uint64) float64 {
+	return byteSizeOf(float64(value), i.RawByteSize).format(i.DisplayByteSize)
+}
+
+func (i Containers) createMetric(sample containerSample) ContainerMetrics {
+	metric := ContainerMetrics{
+		ContainerID: sample.ID,
+		Image:       sample.Image,
+		State:       sample.State,
+	}
+	if len(sample.Names) > 0 {
+		metric.ContainerName = strings.TrimPrefix(sample.Names[0], "/")
+	}
+	if sample.Inspect != nil {
+		metric.RestartCount = sample.Inspect.RestartCount
+		if sample.Inspect.State.Health != nil {
+			metric.Health = sample.Inspect.State.Health.Status
+		}
+	}
+	stats := sample.Stats
+	if stats == nil {
+		return metric
+	}
+	metric.CPU = containerCPUPercent(stats)
+	// page cache is not counted as used memory, same as `docker stats`
+	used := stats.MemoryStats.Usage
+	cache, ok := stats.MemoryStats.Stats["inactive_file"]
+	if !ok {
+		cache = stats.MemoryStats.Stats["cache"]
+	}
+	if cache < used {
+		used -= cache
+	}
+	metric.MemUsage = i.bytes(used)
+	metric.Limit = i.bytes(stats.MemoryStats.Limit)
+	if stats.MemoryStats.Limit != 0 {
+		metric.MemPercent = float64(used) / float64(stats.MemoryStats.Limit) * 100
+	}
+	var rx, tx, read, write uint64
+	for _, network := range stats.Networks {
+		rx += network.RxBytes
+		tx += network.TxBytes
+	}
+	for _, entry := range stats.BlkioStats.IoServiceBytesRecursive {
+		switch strings.ToLower(entry.Op) {
+		case "read":
+			read += entry.Value
+		case "write":
+			write += entry.Value
+		}
+	}
+	metric.NetworkRx = i.bytes(rx)
+	metric.NetworkTx = i.bytes(tx)
+	metric.BlockRead = i.bytes(read)
+	metric.BlockWrite = i.bytes(write)
+	metric.Pids = stats.PidsStats.Current
+	return metric
+}
+
+// containerCPUPercent : same computation as `docker stats` using the
+// previous sample the engine returns alongside the current one
+func containerCPUPercent(stats *containerStats) float64 {
+	cpuDelta := float64(stats.CPUStats.CPUUsage.TotalUsage) - float64(stats.PreCPUStats.CPUUsage.TotalUsage)
+	systemDelta := float64(stats.CPUStats.SystemUsage) - float64(stats.PreCPUStats.SystemUsage)
+	onlineCPUs := float64(stats.CPUStats.OnlineCPUs)
+	if onlineCPUs == 0 {
+		onlineCPUs = float64(len(stats.CPUStats.CPUUsage.PercpuUsage))
+	}
+	if cpuDelta <= 0 || systemDelta <= 0 {
+		return 0
+	}
+	return (cpuDelta / systemDelta) * onlineCPUs * 100
+}
+
+func (i *Containers) SetDriver(driver *driver.Driver) {
+	i.Driver = driver
+}
+
+// fetch : collect container list, inspect and stats from the engine
+func (i Containers) fetch(query string) (string, error) {
+	provider, ok := (*i.Driver).(driver.DockerEngineProvider)
+	if !ok {
+		return ``, errors.New("Driver cannot reach a docker engine")
+	}
+	engine, err := provider.DockerEngine()
+	if err != nil {
+		return ``, err
+	}
+	var samples []containerSample
+	err = engine.Do(http.MethodGet, fmt.Sprintf("/containers/json?%s", query), nil, &samples)
+	if err != nil {
+		return ``, err
+	}
+	for index := range samples {
+		sample := &samples[index]
+		var inspect containerInspect
+		err = engine.Do(http.MethodGet, fmt.Sprintf("/containers/%s/json", sample.ID), nil, &inspect)
+		if err != nil {
+			log.Errorf("Could not inspect container %s: %s", sample.ID, err)
+			continue
+		}
+		sample.Inspect = &inspect
+		if sample.State != "running" {
+			continue
+		}
+		var stats containerStats
+		err = engine.Do(http.MethodGet, fmt.Sprintf("/containers/%s/stats?stream=false", sample.ID), nil, &stats)
+		if err != nil {
+			log.Errorf("Could not get stats for container %s: %s", sample.ID, err)
+			continue
+		}
+		sample.Stats = &stats
+	}
+	output, err := json.Marshal(samples)
+	return string(output), err
+}
+
+func (i Containers) driverExec() driver.Command {
+	return i.fetch
+}
+
+func (i *Containers) Execute() (*Result, error) {
+	output, err := i.driverExec()(i.Query)
+	if err == nil {
+		err = i.Parse(output)
+		return newResult(i.Values, i.DisplayByteSize, err)
+	}
+	return nil, err
+}
+
+// NewContainers : Initialize a new Containers instance
+func NewContainers(driver *driver.Driver, _ ...string) (Inspector, error) {
+	var containers Inspector
+	details, err := (*driver).GetDetails()
+	if err != nil {
+		return nil, err
+	}
+	if !(details.IsLinux || details.IsDarwin || details.IsWindows) {
+		return nil, errors.New("Cannot use Containers on drivers outside (linux, darwin, windows)")
+	}
+	containers = &Containers{
+		Query:           `all=1`,
+		RawByteSize:     `B`,
+		DisplayByteSize: defaultDisplayByteSize,
+	}
+	containers.SetDriver(driver)
+	return containers, nil
+}
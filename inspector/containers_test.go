@@ -0,0 +1,96 @@
+//go:build !windows
+// +build !windows
+
+package inspector
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/bisohns/saido/driver"
+)
+
+const fakeContainerStats = `{
+  "cpu_stats": {"cpu_usage": {"total_usage": 300000000}, "system_cpu_usage": 2000000000, "online_cpus": 2},
+  "precpu_stats": {"cpu_usage": {"total_usage": 100000000}, "system_cpu_usage": 1000000000},
+  "memory_stats": {"usage": 20971520, "limit": 104857600, "stats": {"inactive_file": 10485760}},
+  "networks": {"eth0": {"rx_bytes": 1048576, "tx_bytes": 2097152}},
+  "blkio_stats": {"io_service_bytes_recursive": [{"op": "read", "value": 3145728}, {"op": "write", "value": 1048576}]},
+  "pids_stats": {"current": 4}
+}`
+
+func newFakeContainerEngine(t *testing.T) string {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/containers/json", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[
+		  {"Id": "abc", "Names": ["/redis cache"], "Image": "redis:7", "State": "running"},
+		  {"Id": "def", "Names": ["/worker"], "Image": "worker", "State": "exited"}
+		]`)
+	})
+	mux.HandleFunc("/containers/abc/json", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"RestartCount": 2, "State": {"Status": "running", "Health": {"Status": "healthy"}}}`)
+	})
+	mux.HandleFunc("/containers/def/json", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"RestartCount": 5, "State": {"Status": "exited"}}`)
+	})
+	mux.HandleFunc("/containers/abc/stats", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, fakeContainerStats)
+	})
+	socket := filepath.Join(t.TempDir(), "docker.sock")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &http.Server{Handler: mux}
+	go server.Serve(listener)
+	t.Cleanup(func() { server.Close() })
+	return socket
+}
+
+func TestContainersOnFakeEngine(t *testing.T) {
+	var d driver.Driver = &driver.Local{DockerSocket: newFakeContainerEngine(t)}
+	i, err := NewContainers(&d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = i.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	values := i.(*Containers).Values
+	if len(values) != 2 {
+		t.Fatalf("Expected 2 containers, got %d", len(values))
+	}
+	redis := values[0]
+	if redis.ContainerName != "redis cache" || redis.Health != "healthy" || redis.RestartCount != 2 {
+		t.Errorf("Unexpected container details %#v", redis)
+	}
+	if redis.CPU != 40 || redis.MemUsage != 10 || redis.MemPercent != 10 {
+		t.Errorf("Unexpected cpu or memory %#v", redis)
+	}
+	if redis.NetworkRx != 1 || redis.NetworkTx != 2 || redis.BlockRead != 3 || redis.BlockWrite != 1 || redis.Pids != 4 {
+		t.Errorf("Unexpected io %#v", redis)
+	}
+	if values[1].State != "exited" || values[1].RestartCount != 5 || values[1].CPU != 0 {
+		t.Errorf("Unexpected stopped container %#v", values[1])
+	}
+}
+
+func TestContainersThroughBecome(t *testing.T) {
+	var d driver.Driver = &driver.Privileged{
+		Driver: &driver.Local{DockerSocket: newFakeContainerEngine(t)},
+		Become: driver.Become{Method: "sudo"},
+	}
+	i, err := NewContainers(&d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = i.Execute(); err != nil {
+		t.Fatalf("Expected engine of the wrapped driver to be used, found %s", err)
+	}
+	if values := i.(*Containers).Values; len(values) != 2 {
+		t.Errorf("Expected 2 containers, got %d", len(values))
+	}
+}
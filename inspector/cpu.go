@@ -0,0 +1,149 @@
+package inspector
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/bisohns/saido/driver"
+	log "github.com/sirupsen/logrus"
+)
+
+// CPUMetrics : Metrics used by CPU, times are in seconds summed over
+// all cores since boot
+type CPUMetrics struct {
+	Cores   int     `desc:"Number of cores"`
+	User    float64 `unit:"s" kind:"counter" desc:"Time spent in user mode"`
+	Nice    float64 `unit:"s" kind:"counter" desc:"Time spent in user mode with low priority"`
+	System  float64 `unit:"s" kind:"counter" desc:"Time spent in system mode"`
+	Idle    float64 `unit:"s" kind:"counter" desc:"Time spent idle"`
+	IOWait  float64 `unit:"s" kind:"counter" desc:"Time spent waiting for I/O"`
+	IRQ     float64 `unit:"s" kind:"counter" desc:"Time spent servicing interrupts"`
+	SoftIRQ float64 `unit:"s" kind:"counter" desc:"Time spent servicing softirqs"`
+	Steal   float64 `unit:"s" kind:"counter" desc:"Time stolen by other virtual machines"`
+	// % of time CPU has not been idle since boot
+	UsagePercent float64 `unit:"%" desc:"Time not idle since boot"`
+	// % of time CPU has not been idle since the last poll, 0 on the first
+	RecentUsagePercent float64 `unit:"%" desc:"Time not idle since the last poll"`
+}
+
+func newCPUMetrics(cores int, modes map[string]float64, rates *rates) *CPUMetrics {
+	metrics := &CPUMetrics{
+		Cores:   cores,
+		User:    modes["user"],
+		Nice:    modes["nice"],
+		System:  modes["system"],
+		Idle:    modes["idle"],
+		IOWait:  modes["iowait"],
+		IRQ:     modes["irq"],
+		SoftIRQ: modes["softirq"],
+		Steal:   modes["steal"],
+	}
+	var total float64
+	for _, seconds := range modes {
+		total += seconds
+	}
+	busy := total - metrics.Idle - metrics.IOWait
+	if total > 0 {
+		metrics.UsagePercent = busy / total * 100
+	}
+	busyRate, totalRate := rates.rate("busy", busy), rates.rate("total", total)
+	if totalRate > 0 {
+		metrics.RecentUsagePercent = busyRate / totalRate * 100
+	}
+	return metrics
+}
+
+// CPULinux : Parsing the /proc/stat output for cpu time
+type CPULinux struct {
+	Driver   *driver.Driver
+	FilePath string
+	// ClockTicks is USER_HZ that /proc/stat times are reported in
+	ClockTicks float64
+	Values     *CPUMetrics
+	rates      *rates
+	hostState
+}
+
+// Parse : run custom parsing on output of the command
+/*
+cpu  10132153 290696 3084719 46828483 16683 0 25195 0 0 0
+cpu0 1393280 32966 572056 13343292 6130 0 17875 0 0 0
+cpu1 1335787 29410 415405 13430811 3406 0 2412 0 0 0
+intr 1462898 ...
+*/
+func (i *CPULinux) Parse(output string) error {
+	log.Debug("Parsing output string in CPULinux inspector")
+	var (
+		cores int
+		modes = make(map[string]float64)
+	)
+	errs := newParseErrors("CPULinux")
+	names := []string{"user", "nice", "system", "idle", "iowait", "irq", "softirq", "steal"}
+	for _, line := range strings.Split(output, "\n") {
+		columns := strings.Fields(line)
+		if len(columns) < 1 || !strings.HasPrefix(columns[0], "cpu") {
+			continue
+		}
+		if columns[0] != "cpu" {
+			cores++
+			continue
+		}
+		for index, name := range names {
+			if index+1 >= len(columns) {
+				break
+			}
+			ticks, err := strconv.ParseFloat(columns[index+1], 64)
+			if err != nil {
+				errs.add("could not parse %s time: %s", name, err)
+				continue
+			}
+			modes[name] = ticks / i.ClockTicks
+		}
+	}
+	i.Values = newCPUMetrics(cores, modes, i.rates)
+	return errs.err()
+}
+
+func (i *CPULinux) SetDriver(driver *driver.Driver) {
+	details, _ := (*driver).GetDetails()
+	if !details.IsLinux {
+		panic("Cannot use CPULinux on drivers outside (linux)")
+	}
+	i.Driver = driver
+}
+
+func (i CPULinux) driverExec() driver.Command {
+	return (*i.Driver).ReadFile
+}
+
+func (i *CPULinux) Execute() (*Result, error) {
+	output, err := i.driverExec()(i.FilePath)
+	if err == nil {
+		i.rates = newRates(i.stateKey)
+		err = i.Parse(output)
+		return newResult(i.Values, ``, err)
+	}
+	return nil, err
+}
+
+// NewCPU : Initialize a new CPU instance
+func NewCPU(driver *driver.Driver, _ ...string) (Inspector, error) {
+	var cpu Inspector
+	details, err := (*driver).GetDetails()
+	if err != nil {
+		return nil, err
+	}
+	if details.IsNodeExporter {
+		cpu = &CPUNodeExporter{}
+	} else if details.IsLinux {
+		cpu = &CPULinux{
+			FilePath:   `/proc/stat`,
+			ClockTicks: 100,
+		}
+	} else {
+		return nil, errors.New("Cannot use CPU on drivers outside (linux, node_exporter)")
+	}
+	cpu.SetDriver(driver)
+	return cpu, nil
+}
@@ -1,7 +1,6 @@
 package inspector
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
 
@@ -11,8 +10,8 @@ import (
 
 // CustomMetrics : Metrics used by Custom
 type CustomMetrics struct {
-	Output  string
-	Command string
+	Output  string `desc:"Output of the command"`
+	Command string `desc:"Command that was run"`
 }
 
 // Custom : Parsing the custom command output for disk monitoring
@@ -23,9 +22,10 @@ type Custom struct {
 }
 
 // Parse : run custom parsing on output of the command
-func (i *Custom) Parse(output string) {
+func (i *Custom) Parse(output string) error {
 	log.Debug("Parsing output string in Custom inspector")
 	i.Values = i.createMetric(output)
+	return nil
 }
 
 func (i Custom) createMetric(output string) CustomMetrics {
@@ -47,13 +47,13 @@ func (i Custom) driverExec() driver.Command {
 	return (*i.Driver).RunCommand
 }
 
-func (i *Custom) Execute() ([]byte, error) {
+func (i *Custom) Execute() (*Result, error) {
 	output, err := i.driverExec()(i.Command)
 	if err == nil {
-		i.Parse(output)
-		return json.Marshal(i.Values)
+		err = i.Parse(output)
+		return newResult(i.Values, ``, err)
 	}
-	return []byte(""), err
+	return nil, err
 }
 
 // NewCustom : Initialize a new Custom instance
@@ -0,0 +1,319 @@
+package inspector
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/bisohns/saido/config"
+	"github.com/bisohns/saido/driver"
+	log "github.com/sirupsen/logrus"
+)
+
+// Declared : inspector declared in yaml, fields are read from the output
+// of a command or file by a regex, columns or json parser
+type Declared struct {
+	Driver     *driver.Driver
+	Definition config.InspectorDefinition
+	// Command is run when set, File is read otherwise
+	Command string
+	File    string
+	pattern *regexp.Regexp
+	// Values is a map of fields or a list of them when the parser reads
+	// rows
+	Values interface{}
+}
+
+// declaredRow : fields of one match, line or object
+type declaredRow = map[string]interface{}
+
+// Parse : run the parser of the definition on output
+func (i *Declared) Parse(output string) error {
+	log.Debugf("Parsing output string in %s inspector", i.Definition.Name)
+	errs := newParseErrors(i.Definition.Name)
+	var rows []declaredRow
+	switch i.Definition.Parser.Type {
+	case "regex":
+		rows = i.parseRegex(output, errs)
+	case "columns":
+		rows = i.parseColumns(output, errs)
+	case "json":
+		rows = i.parseJSON(output, errs)
+	}
+	if i.Definition.Parser.List {
+		if rows == nil {
+			rows = []declaredRow{}
+		}
+		i.Values = rows
+	} else if len(rows) > 0 {
+		i.Values = rows[0]
+	} else {
+		i.Values = declaredRow{}
+	}
+	return errs.err()
+}
+
+// value : text of field converted to its type
+func (i *Declared) value(field config.InspectorField, text string, errs *parseErrors) interface{} {
+	text = strings.TrimSpace(text)
+	switch field.Type {
+	case "number":
+		return errs.float(text, field.Name)
+	case "bool":
+		parsed, err := strconv.ParseBool(text)
+		if err != nil {
+			errs.add("could not parse %s: %s", field.Name, err)
+		}
+		return parsed
+	}
+	return text
+}
+
+func (i *Declared) parseRegex(output string, errs *parseErrors) []declaredRow {
+	limit := 1
+	if i.Definition.Parser.List {
+		limit = -1
+	}
+	matches := i.pattern.FindAllStringSubmatch(output, limit)
+	if len(matches) == 0 && !i.Definition.Parser.List {
+		errs.add("no match of %s", i.pattern)
+	}
+	rows := []declaredRow{}
+	for _, match := range matches {
+		row := declaredRow{}
+		for _, field := range i.Definition.Fields {
+			row[field.Name] = i.value(field, match[i.pattern.SubexpIndex(field.Name)], errs)
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+func (i *Declared) parseColumns(output string, errs *parseErrors) []declaredRow {
+	parser := i.Definition.Parser
+	fields := make(map[string]config.InspectorField)
+	for _, field := range i.Definition.Fields {
+		fields[field.Name] = field
+	}
+	rows := []declaredRow{}
+	lines := strings.Split(strings.ReplaceAll(output, "\r\n", "\n"), "\n")
+	for index, line := range lines {
+		if index < parser.Skip || strings.TrimSpace(line) == `` {
+			continue
+		}
+		var columns []string
+		if parser.Separator == `` {
+			columns = strings.Fields(line)
+		} else {
+			columns = strings.Split(line, parser.Separator)
+		}
+		if len(columns) < len(parser.Columns) {
+			errs.add("expected %d columns on line %d, found %d", len(parser.Columns), index+1, len(columns))
+			continue
+		}
+		row := declaredRow{}
+		for column, name := range parser.Columns {
+			if field, ok := fields[name]; ok {
+				row[name] = i.value(field, columns[column], errs)
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// lookupJSON : value at a dot separated path, numbers index lists
+func lookupJSON(value interface{}, path string) (interface{}, bool) {
+	if path == `` {
+		return value, true
+	}
+	for _, key := range strings.Split(path, ".") {
+		switch current := value.(type) {
+		case map[string]interface{}:
+			next, ok := current[key]
+			if !ok {
+				return nil, false
+			}
+			value = next
+		case []interface{}:
+			index, err := strconv.Atoi(key)
+			if err != nil || index < 0 || index >= len(current) {
+				return nil, false
+			}
+			value = current[index]
+		default:
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+func (i *Declared) parseJSON(output string, errs *parseErrors) []declaredRow {
+	parser := i.Definition.Parser
+	var decoded interface{}
+	if err := json.Unmarshal([]byte(output), &decoded); err != nil {
+		errs.add("could not decode json: %s", err)
+		return nil
+	}
+	found, ok := lookupJSON(decoded, parser.Path)
+	if !ok {
+		errs.add("could not find %s", parser.Path)
+		return nil
+	}
+	objects := []interface{}{found}
+	if parser.List {
+		list, ok := found.([]interface{})
+		if !ok {
+			errs.add("expected a list at %q", parser.Path)
+			return nil
+		}
+		objects = list
+	}
+	rows := []declaredRow{}
+	for _, object := range objects {
+		row := declaredRow{}
+		for _, field := range i.Definition.Fields {
+			path := field.Path
+			if path == `` {
+				path = field.Name
+			}
+			value, ok := lookupJSON(object, path)
+			if !ok {
+				errs.add("could not find %s", path)
+				continue
+			}
+			// json numbers and bools are converted when declared otherwise
+			switch typed := value.(type) {
+			case string:
+				row[field.Name] = i.value(field, typed, errs)
+			case float64:
+				if field.Type == "number" {
+					row[field.Name] = typed
+				} else {
+					row[field.Name] = i.value(field, strconv.FormatFloat(typed, 'f', -1, 64), errs)
+				}
+			case bool:
+				if field.Type == "bool" {
+					row[field.Name] = typed
+				} else {
+					row[field.Name] = i.value(field, strconv.FormatBool(typed), errs)
+				}
+			default:
+				errs.add("%s is not a %s", path, field.Type)
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+func (i *Declared) SetDriver(driver *driver.Driver) {
+	details, _ := (*driver).GetDetails()
+	platform := platformOf(details)
+	if i.Definition.Commands[platform] == `` && i.Definition.Files[platform] == `` {
+		panic(fmt.Sprintf("Cannot use %s on drivers outside (%s)", i.Definition.Name, strings.Join(declaredPlatforms(i.Definition), ", ")))
+	}
+	i.Driver = driver
+}
+
+func (i Declared) driverExec() driver.Command {
+	if i.Command != `` {
+		return (*i.Driver).RunCommand
+	}
+	return (*i.Driver).ReadFile
+}
+
+func (i *Declared) Execute() (*Result, error) {
+	target := i.Command
+	if target == `` {
+		target = i.File
+	}
+	output, err := i.driverExec()(target)
+	if err == nil {
+		err = i.Parse(output)
+		return &Result{Units: declaredUnits(i.Definition), Values: i.Values}, err
+	}
+	return nil, err
+}
+
+// declaredUnits : units of fields which have one
+func declaredUnits(definition config.InspectorDefinition) map[string]string {
+	units := make(map[string]string)
+	for _, field := range definition.Fields {
+		if field.Unit != `` {
+			units[field.Name] = field.Unit
+		}
+	}
+	return units
+}
+
+// NewDeclared : Initialize an inspector from its definition for the
+// platform of driver
+func NewDeclared(driver *driver.Driver, definition config.InspectorDefinition) (Inspector, error) {
+	details, err := (*driver).GetDetails()
+	if err != nil {
+		return nil, err
+	}
+	platform := platformOf(details)
+	declared := &Declared{
+		Definition: definition,
+		Command:    definition.Commands[platform],
+		File:       definition.Files[platform],
+	}
+	if declared.Command == `` && declared.File == `` {
+		return nil, fmt.Errorf("Cannot use %s on drivers outside (%s)", definition.Name, strings.Join(declaredPlatforms(definition), ", "))
+	}
+	if definition.Parser.Type == "regex" {
+		if declared.pattern, err = regexp.Compile(definition.Parser.Pattern); err != nil {
+			return nil, err
+		}
+	}
+	declared.SetDriver(driver)
+	return declared, nil
+}
+
+// declaredPlatforms : platforms with a command or file
+func declaredPlatforms(definition config.InspectorDefinition) []string {
+	platforms := []string{}
+	for _, platform := range config.InspectorPlatforms {
+		if definition.Commands[platform] != `` || definition.Files[platform] != `` {
+			platforms = append(platforms, platform)
+		}
+	}
+	return platforms
+}
+
+// Register : add a declared inspector to the inspectors that can be
+// initialized, must be called before polling starts
+func Register(definition config.InspectorDefinition) error {
+	name := definition.Name
+	if name == `` {
+		return errors.New("Must specify name of declared inspectors")
+	}
+	if _, ok := inspectorMap[name]; ok || registryName(name) != name {
+		return fmt.Errorf("Cannot declare inspector %s as it is built in", name)
+	}
+	fields := []Field{}
+	for _, field := range definition.Fields {
+		fields = append(fields, Field{
+			Name:        field.Name,
+			Type:        field.Type,
+			Unit:        field.Unit,
+			Kind:        field.Kind,
+			Description: field.Description,
+		})
+	}
+	inspectorMap[name] = registration{
+		New: func(driver *driver.Driver, custom ...string) (Inspector, error) {
+			return NewDeclared(driver, definition)
+		},
+		Description: definition.Description,
+		Platforms:   declaredPlatforms(definition),
+		List:        definition.Parser.List,
+		Fields:      fields,
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package inspector
+
+import (
+	"testing"
+
+	"github.com/bisohns/saido/config"
+	"github.com/bisohns/saido/driver"
+)
+
+const fakeChronyTracking = `Reference ID    : A29FC87B (time.cloudflare.com)
+Stratum         : 4
+System time     : 0.000123456 seconds fast of NTP time
+Leap status     : Normal
+`
+
+const fakeSensors = `{"sensors": [
+	{"name": "cpu", "temp": {"current": 48.5}, "alarm": false},
+	{"name": "gpu", "temp": {"current": "61"}, "alarm": "true"}
+]}`
+
+func TestDeclared(t *testing.T) {
+	fake := &fakeProcesses{}
+	var d driver.Driver = fake
+	declare := func(definition config.InspectorDefinition, output string) interface{} {
+		t.Helper()
+		if err := Register(definition); err != nil {
+			t.Fatal(err)
+		}
+		defer delete(inspectorMap, definition.Name)
+		fake.output = output
+		i, err := Init(definition.Name, &d)
+		if err != nil {
+			t.Fatal(err)
+		}
+		result, err := i.Execute()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result.Values
+	}
+
+	ntp := declare(config.InspectorDefinition{
+		Name:     "ntp",
+		Commands: map[string]string{"linux": "chronyc tracking"},
+		Parser: config.InspectorParser{
+			Type:    "regex",
+			Pattern: `System time\s+:\s+(?P<offset>[\d.]+) seconds (?P<direction>fast|slow)`,
+		},
+		Fields: []config.InspectorField{
+			{Name: "offset", Type: "number", Unit: "s"},
+			{Name: "direction", Type: "string"},
+		},
+	}, fakeChronyTracking).(map[string]interface{})
+	if ntp["offset"] != 0.000123456 || ntp["direction"] != "fast" {
+		t.Errorf("Unexpected ntp %+v", ntp)
+	}
+
+	users := declare(config.InspectorDefinition{
+		Name:     "users",
+		Commands: map[string]string{"linux": "ps axu"},
+		Parser:   config.InspectorParser{Type: "columns", Skip: 1, List: true, Columns: []string{"user", "pid", "cpu"}},
+		Fields: []config.InspectorField{
+			{Name: "user", Type: "string"},
+			{Name: "cpu", Type: "number", Unit: "%"},
+		},
+	}, fakePsAxu).([]map[string]interface{})
+	if len(users) != 6 || users[5]["user"] != "app" || users[5]["cpu"] != 12.0 || users[5]["pid"] != nil {
+		t.Errorf("Unexpected users %+v", users)
+	}
+
+	sensors := declare(config.InspectorDefinition{
+		Name:     "sensors",
+		Commands: map[string]string{"linux": "sensors -j"},
+		Parser:   config.InspectorParser{Type: "json", Path: "sensors", List: true},
+		Fields: []config.InspectorField{
+			{Name: "name", Type: "string"},
+			{Name: "temp", Type: "number", Path: "temp.current"},
+			{Name: "alarm", Type: "bool"},
+		},
+	}, fakeSensors).([]map[string]interface{})
+	if len(sensors) != 2 || sensors[0]["temp"] != 48.5 || sensors[1]["temp"] != 61.0 || sensors[1]["alarm"] != true {
+		t.Errorf("Unexpected sensors %+v", sensors)
+	}
+}
+
+func TestDeclaredParseError(t *testing.T) {
+	i := &Declared{Definition: config.InspectorDefinition{
+		Name:   "sensors",
+		Parser: config.InspectorParser{Type: "json"},
+		Fields: []config.InspectorField{{Name: "temp", Type: "number", Path: "temp"}},
+	}}
+	if err := i.Parse(`{"temp": "hot"}`); err == nil {
+		t.Error("Expected error for a temperature that is not a number")
+	}
+	if err := i.Parse(`not json`); err == nil {
+		t.Error("Expected error for output that is not json")
+	}
+}
+
+func TestRegister(t *testing.T) {
+	definition := config.InspectorDefinition{
+		Name:        "ntp",
+		Description: "Offset of the clock",
+		Files:       map[string]string{"linux": "/var/lib/ntp/offset"},
+		Parser:      config.InspectorParser{Type: "regex", Pattern: `(?P<offset>.*)`},
+		Fields:      []config.InspectorField{{Name: "offset", Type: "number", Unit: "s", Kind: "gauge"}},
+	}
+	if err := Register(definition); err != nil {
+		t.Fatal(err)
+	}
+	defer delete(inspectorMap, definition.Name)
+	if !Valid("ntp") {
+		t.Error("Expected declared inspector to be valid")
+	}
+	schema, ok := GetSchema("ntp")
+	if !ok || schema.List || len(schema.Platforms) != 1 || schema.Fields[0].Unit != "s" {
+		t.Errorf("Unexpected schema %+v", schema)
+	}
+	var dns driver.Driver = &fakeDNS{}
+	if _, err := Init("ntp", &dns); err == nil {
+		t.Error("Expected error for driver without a platform")
+	}
+	for _, name := range []string{"disk", "custom-ntp", "process-ntp", ""} {
+		definition.Name = name
+		if err := Register(definition); err == nil {
+			t.Errorf("Expected error registering %q", name)
+		}
+	}
+}
@@ -1,7 +1,6 @@
 package inspector
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
 	"strconv"
@@ -13,13 +12,13 @@ import (
 
 // DFMetrics : Metrics used by DF
 type DFMetrics struct {
-	FileSystem  string
-	Size        float64
-	Used        float64
-	Available   float64
-	PercentFull int
+	FileSystem  string  `desc:"Filesystem or drive"`
+	Size        float64 `unit:"bytes" desc:"Size of the filesystem"`
+	Used        float64 `unit:"bytes" desc:"Space used"`
+	Available   float64 `unit:"bytes" desc:"Space available"`
+	PercentFull int     `unit:"%" desc:"Space used of the size"`
 	// Optional Volume Name that may be available on Windows
-	VolumeName string
+	VolumeName string `desc:"Name of the volume, windows only"`
 }
 
 // DF : Parsing the `df` output for disk monitoring
@@ -57,9 +56,10 @@ For Darwin it looks something like
  tmpfs            1612500     2112   1610388   1% /run
 
 */
-func (i *DF) Parse(output string) {
+func (i *DF) Parse(output string) error {
 	var values []DFMetrics
 	log.Debug("Parsing output string in DF inspector")
+	errs := newParseErrors("DF")
 	lines := strings.Split(output, "\n")
 	for index, line := range lines {
 		// skip title line
@@ -76,7 +76,8 @@ func (i *DF) Parse(output string) {
 			}
 			percentInt, err := strconv.Atoi(percent)
 			if err != nil {
-				log.Fatalf(`Error Parsing Percent Full: %s `, err)
+				errs.add("could not parse percent full of %s: %s", columns[0], err)
+				continue
 			}
 			// find size
 			originalColumns := columns
@@ -91,21 +92,22 @@ func (i *DF) Parse(output string) {
 				}
 			}
 			if strings.HasPrefix(columns[0], i.DeviceStartsWith) {
-				values = append(values, i.createMetric(columns, percentInt))
+				values = append(values, i.createMetric(columns, percentInt, errs))
 			} else {
-				values = append(values, i.createMetric(columns, percentInt))
+				values = append(values, i.createMetric(columns, percentInt, errs))
 			}
 		}
 	}
 	i.Values = values
+	return errs.err()
 }
 
-func (i DF) createMetric(columns []string, percent int) DFMetrics {
+func (i DF) createMetric(columns []string, percent int, errs *parseErrors) DFMetrics {
 	return DFMetrics{
 		FileSystem:  columns[0],
-		Size:        NewByteSize(columns[1], i.RawByteSize).format(i.DisplayByteSize),
-		Used:        NewByteSize(columns[2], i.RawByteSize).format(i.DisplayByteSize),
-		Available:   NewByteSize(columns[3], i.RawByteSize).format(i.DisplayByteSize),
+		Size:        errs.bytes(columns[1], i.RawByteSize, i.DisplayByteSize),
+		Used:        errs.bytes(columns[2], i.RawByteSize, i.DisplayByteSize),
+		Available:   errs.bytes(columns[3], i.RawByteSize, i.DisplayByteSize),
 		PercentFull: percent,
 	}
 }
@@ -118,13 +120,13 @@ func (i DF) driverExec() driver.Command {
 	return (*i.Driver).RunCommand
 }
 
-func (i *DF) Execute() ([]byte, error) {
+func (i *DF) Execute() (*Result, error) {
 	output, err := i.driverExec()(i.Command)
 	if err == nil {
-		i.Parse(output)
-		return json.Marshal(i.Values)
+		err = i.Parse(output)
+		return newResult(i.Values, i.DisplayByteSize, err)
 	}
-	return []byte(""), err
+	return nil, err
 }
 
 // DFWin: parse `wmic logicaldisk` to satisfy Inspector interface
@@ -147,9 +149,10 @@ type DFWin struct {
 Node,DeviceID,DriveType,FreeSpace,ProviderName,Size,VolumeName
 IMANI,C:,3,191980253184,,288303964160,OS
 */
-func (i *DFWin) Parse(output string) {
+func (i *DFWin) Parse(output string) error {
 	var values []DFMetrics
 	log.Debug("Parsing output string in DF inspector")
+	errs := newParseErrors("DFWin")
 	lineChar := "\r"
 	output = strings.TrimPrefix(output, lineChar)
 	output = strings.TrimSuffix(output, lineChar)
@@ -162,9 +165,12 @@ func (i *DFWin) Parse(output string) {
 		columns := strings.Split(line, ",")
 		if len(columns) >= 7 {
 			available, err := strconv.Atoi(columns[3])
-			size, err := strconv.Atoi(columns[5])
+			size, sizeErr := strconv.Atoi(columns[5])
+			if err == nil {
+				err = sizeErr
+			}
 			if err != nil {
-				log.Errorf("Could not parse sizes for DFWin %e", err)
+				errs.add("could not parse sizes of %s: %s", columns[1], err)
 			} else {
 				used := size - available
 				percentInt := int((float64(used) / float64(size)) * 100)
@@ -176,22 +182,23 @@ func (i *DFWin) Parse(output string) {
 					columns[6],
 				}
 				if strings.HasPrefix(columns[1], i.DeviceStartsWith) {
-					values = append(values, i.createMetric(cols, percentInt))
+					values = append(values, i.createMetric(cols, percentInt, errs))
 				} else {
-					values = append(values, i.createMetric(cols, percentInt))
+					values = append(values, i.createMetric(cols, percentInt, errs))
 				}
 			}
 		}
 	}
 	i.Values = values
+	return errs.err()
 }
 
-func (i DFWin) createMetric(columns []string, percent int) DFMetrics {
+func (i DFWin) createMetric(columns []string, percent int, errs *parseErrors) DFMetrics {
 	return DFMetrics{
 		FileSystem:  columns[0],
-		Size:        NewByteSize(columns[1], i.RawByteSize).format(i.DisplayByteSize),
-		Used:        NewByteSize(columns[2], i.RawByteSize).format(i.DisplayByteSize),
-		Available:   NewByteSize(columns[3], i.RawByteSize).format(i.DisplayByteSize),
+		Size:        errs.bytes(columns[1], i.RawByteSize, i.DisplayByteSize),
+		Used:        errs.bytes(columns[2], i.RawByteSize, i.DisplayByteSize),
+		Available:   errs.bytes(columns[3], i.RawByteSize, i.DisplayByteSize),
 		VolumeName:  columns[4],
 		PercentFull: percent,
 	}
@@ -205,13 +212,13 @@ func (i DFWin) driverExec() driver.Command {
 	return (*i.Driver).RunCommand
 }
 
-func (i *DFWin) Execute() ([]byte, error) {
+func (i *DFWin) Execute() (*Result, error) {
 	output, err := i.driverExec()(i.Command)
 	if err == nil {
-		i.Parse(output)
-		return json.Marshal(i.Values)
+		err = i.Parse(output)
+		return newResult(i.Values, i.DisplayByteSize, err)
 	}
-	return []byte(""), err
+	return nil, err
 }
 
 // NewDF : Initialize a new DF instance
@@ -221,6 +228,14 @@ func NewDF(driver *driver.Driver, _ ...string) (Inspector, error) {
 	if err != nil {
 		return nil, err
 	}
+	if details.IsNodeExporter {
+		df = &DFNodeExporter{
+			RawByteSize:     `B`,
+			DisplayByteSize: defaultDisplayByteSize,
+		}
+		df.SetDriver(driver)
+		return df, nil
+	}
 	if !(details.IsLinux || details.IsDarwin || details.IsWindows) {
 		return nil, errors.New("Cannot use 'df' command on drivers outside (linux, darwin, windows)")
 	}
@@ -230,7 +245,7 @@ func NewDF(driver *driver.Driver, _ ...string) (Inspector, error) {
 			// always reported in posix standard of 1K-blocks
 			Command:         `df -a -k`,
 			RawByteSize:     `KB`,
-			DisplayByteSize: `MB`,
+			DisplayByteSize: defaultDisplayByteSize,
 		}
 	} else {
 		df = &DFWin{
@@ -238,7 +253,7 @@ func NewDF(driver *driver.Driver, _ ...string) (Inspector, error) {
 			// issues that arise on windows
 			Command:         `wmic logicaldisk list brief /format:csv`,
 			RawByteSize:     `B`,
-			DisplayByteSize: `MB`,
+			DisplayByteSize: defaultDisplayByteSize,
 		}
 	}
 	df.SetDriver(driver)
@@ -68,3 +68,17 @@ func TestDFOnSSH(t *testing.T) {
 		t.Error("Values are empty!")
 	}
 }
+
+func TestDFParseError(t *testing.T) {
+	i := &DF{RawByteSize: `KB`, DisplayByteSize: `KB`}
+	err := i.Parse(`Filesystem     1K-blocks     Used Available Use% Mounted on
+/dev/sda1       10240000  5120000   5120000  50% /
+overlay         10240000  5120000   5120000  ??% /var/lib/docker/overlay2
+`)
+	if _, ok := err.(*ParseError); !ok {
+		t.Fatalf("Expected parse error for bad percent, found %v", err)
+	}
+	if len(i.Values) != 1 || i.Values[0].FileSystem != "/dev/sda1" || i.Values[0].PercentFull != 50 {
+		t.Errorf("Expected parsed filesystems to be kept, found %+v", i.Values)
+	}
+}
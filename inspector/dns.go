@@ -0,0 +1,85 @@
+package inspector
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/bisohns/saido/driver"
+	log "github.com/sirupsen/logrus"
+)
+
+// DNSMetrics : Metrics used by DNS, latency is in seconds
+type DNSMetrics struct {
+	Name    string   `desc:"Name queried"`
+	Type    string   `desc:"Record type queried"`
+	Answers []string `desc:"Records returned"`
+	Latency float64  `unit:"s" desc:"Time taken to resolve"`
+	Success bool     `desc:"Name resolved with the expected answers"`
+	// Mismatch is set when answers differ from the expected answers
+	Mismatch   bool     `desc:"Answers differ from the expected answers"`
+	Missing    []string `json:",omitempty" desc:"Expected answers not returned"`
+	Unexpected []string `json:",omitempty" desc:"Answers returned but not expected"`
+	Error      string   `json:",omitempty" desc:"Error resolving the name"`
+}
+
+// DNS : Resolving the queries of a dns connection
+type DNS struct {
+	Driver *driver.Driver
+	Values []DNSMetrics
+}
+
+// Parse : run custom parsing on output of the queries
+func (i *DNS) Parse(output string) error {
+	log.Debug("Parsing output string in DNS inspector")
+	values := []DNSMetrics{}
+	if err := json.Unmarshal([]byte(output), &values); err != nil {
+		i.Values = values
+		return &ParseError{inspector: "DNS", content: err.Error()}
+	}
+	i.Values = values
+	return nil
+}
+
+func (i *DNS) SetDriver(driver *driver.Driver) {
+	details, _ := (*driver).GetDetails()
+	if !details.IsDNS {
+		panic("Cannot use DNS on drivers outside (dns)")
+	}
+	i.Driver = driver
+}
+
+func (i DNS) resolve(_ string) (string, error) {
+	resolver, ok := (*i.Driver).(driver.DNSResolver)
+	if !ok {
+		return ``, errors.New("Driver cannot resolve dns queries")
+	}
+	output, err := json.Marshal(resolver.Resolve())
+	return string(output), err
+}
+
+func (i DNS) driverExec() driver.Command {
+	return i.resolve
+}
+
+func (i *DNS) Execute() (*Result, error) {
+	output, err := i.driverExec()(``)
+	if err == nil {
+		err = i.Parse(output)
+		return newResult(i.Values, ``, err)
+	}
+	return nil, err
+}
+
+// NewDNS : Initialize a new DNS instance
+func NewDNS(driver *driver.Driver, _ ...string) (Inspector, error) {
+	details, err := (*driver).GetDetails()
+	if err != nil {
+		return nil, err
+	}
+	if !details.IsDNS {
+		return nil, errors.New("Cannot use DNS on drivers outside (dns)")
+	}
+	dns := &DNS{}
+	dns.SetDriver(driver)
+	return dns, nil
+}
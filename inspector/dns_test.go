@@ -0,0 +1,45 @@
+package inspector
+
+import (
+	"testing"
+
+	"github.com/bisohns/saido/driver"
+)
+
+// fakeDNS : returns fixed results for the dns inspector
+type fakeDNS struct {
+	driver.Local
+}
+
+func (d *fakeDNS) Resolve() []driver.DNSResult {
+	return []driver.DNSResult{
+		{Name: "db.internal", Type: "A", Answers: []string{"10.0.0.5"}, Latency: 0.002, Success: true},
+		{Name: "www.internal", Type: "CNAME", Answers: []string{"old.internal"}, Mismatch: true, Missing: []string{"web.internal"}, Unexpected: []string{"old.internal"}},
+	}
+}
+
+func (d *fakeDNS) GetDetails() (driver.SystemDetails, error) {
+	return driver.SystemDetails{Name: "dns", IsDNS: true}, nil
+}
+
+func TestDNSOnDNS(t *testing.T) {
+	var d driver.Driver = &fakeDNS{}
+	i, err := Init(`dns`, &d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := i.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	values := i.(*DNS).Values
+	if len(values) != 2 || !values[0].Success || values[0].Answers[0] != "10.0.0.5" {
+		t.Errorf("Unexpected results %+v", values)
+	}
+	if !values[1].Mismatch || values[1].Missing[0] != "web.internal" {
+		t.Errorf("Expected mismatch to be reported, found %+v", values[1])
+	}
+	var local driver.Driver = &driver.Local{}
+	if _, err := NewDNS(&local); err == nil {
+		t.Error("Expected error for dns on local driver")
+	}
+}
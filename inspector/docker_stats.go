@@ -1,7 +1,6 @@
 package inspector
 
 import (
-	"encoding/json"
 	"errors"
 	"strconv"
 	"strings"
@@ -12,13 +11,13 @@ import (
 
 // DockerStatsMetrics : Metrics used by DockerStats
 type DockerStatsMetrics struct {
-	ContainerID   string
-	ContainerName string
-	CPU           float64
-	MemUsage      float64
-	Limit         float64
-	MemPercent    float64
-	Pid           int
+	ContainerID   string  `desc:"ID of the container"`
+	ContainerName string  `desc:"Name of the container"`
+	CPU           float64 `unit:"%" desc:"Host CPU used"`
+	MemUsage      float64 `unit:"bytes" desc:"Memory used"`
+	Limit         float64 `unit:"bytes" desc:"Memory limit"`
+	MemPercent    float64 `unit:"%" desc:"Memory used of the limit"`
+	Pid           int     `desc:"Processes running in the container"`
 }
 
 // DockerStats : Parsing the `docker stats` output for container monitoring
@@ -37,8 +36,9 @@ CONTAINER  CPU %     MEM USAGE / LIMIT     MEM %     NET I/O             BLOCK I
 redis1     0.07%     796 KB / 64 MB        1.21%     788 B / 648 B       3.568 MB / 512 KB     2
 redis2     0.07%     2.746 MB / 64 MB      4.29%     1.266 KB / 648 B    12.4 MB / 0 B         3
 */
-func (i *DockerStats) Parse(output string) {
+func (i *DockerStats) Parse(output string) error {
 	var values []DockerStatsMetrics
+	errs := newParseErrors("DockerStats")
 	var splitChars string
 	details, _ := (*i.Driver).GetDetails()
 	if details.IsWindows {
@@ -57,11 +57,13 @@ func (i *DockerStats) Parse(output string) {
 		if len(columns) == 14 {
 			cpu, err := strconv.ParseFloat(strings.TrimSuffix(columns[2], "%"), 64)
 			if err != nil {
-				log.Fatal("Could not parse cpu for docker stats")
+				errs.add("could not parse cpu of %s: %s", columns[1], err)
+				continue
 			}
 			memory, err := strconv.ParseFloat(strings.TrimSuffix(columns[6], "%"), 64)
 			if err != nil {
-				log.Fatal("Could not parse cpu for docker stats")
+				errs.add("could not parse memory of %s: %s", columns[1], err)
+				continue
 			}
 			col := []string{
 				columns[3],
@@ -72,11 +74,12 @@ func (i *DockerStats) Parse(output string) {
 				log.Debug("Could not parse pid for docker stats, probably on windows")
 				pid = 0
 			}
-			value := i.createMetric(col, columns[0], columns[1], cpu, memory, pid)
+			value := i.createMetric(col, columns[0], columns[1], cpu, memory, pid, errs)
 			values = append(values, value)
 		}
 	}
 	i.Values = values
+	return errs.err()
 }
 
 func (i DockerStats) createMetric(
@@ -85,22 +88,14 @@ func (i DockerStats) createMetric(
 	containerName string,
 	cpu float64,
 	memory float64,
-	pid int) DockerStatsMetrics {
-	// Usually measured in KiB so we remove 3 characters
-	lastMem := len(columns[0]) - 3
-	lastLim := len(columns[1]) - 3
-	if lastMem <= 0 || lastLim <= 0 {
-		lastMem = len(columns[0]) - 1
-		lastLim = len(columns[1]) - 1
-	}
-	memusageSize := columns[0][lastMem:]
-	limitSize := columns[1][lastLim:]
+	pid int,
+	errs *parseErrors) DockerStatsMetrics {
 	return DockerStatsMetrics{
 		ContainerID:   containerID,
 		ContainerName: containerName,
 		CPU:           cpu,
-		MemUsage:      NewByteSize(columns[0][:lastMem], memusageSize).format(i.DisplayByteSize),
-		Limit:         NewByteSize(columns[1][:lastLim], limitSize).format(i.DisplayByteSize),
+		MemUsage:      errs.size(columns[0], i.DisplayByteSize),
+		Limit:         errs.size(columns[1], i.DisplayByteSize),
 		MemPercent:    memory,
 		Pid:           pid,
 	}
@@ -114,13 +109,13 @@ func (i DockerStats) driverExec() driver.Command {
 	return (*i.Driver).RunCommand
 }
 
-func (i *DockerStats) Execute() ([]byte, error) {
+func (i *DockerStats) Execute() (*Result, error) {
 	output, err := i.driverExec()(i.Command)
 	if err == nil {
-		i.Parse(output)
-		return json.Marshal(i.Values)
+		err = i.Parse(output)
+		return newResult(i.Values, i.DisplayByteSize, err)
 	}
-	return []byte(""), err
+	return nil, err
 }
 
 // NewDockerStats : Initialize a new DockerStats instance
@@ -135,7 +130,7 @@ func NewDockerStats(driver *driver.Driver, _ ...string) (Inspector, error) {
 	}
 	dockerstats = &DockerStats{
 		Command:         `docker stats --no-stream`,
-		DisplayByteSize: `MB`,
+		DisplayByteSize: defaultDisplayByteSize,
 	}
 	dockerstats.SetDriver(driver)
 	return dockerstats, nil
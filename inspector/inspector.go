@@ -3,7 +3,10 @@ package inspector
 import (
 	"errors"
 	"fmt"
+	"reflect"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/bisohns/saido/driver"
 )
@@ -13,45 +16,291 @@ var CustomCommand = `custom`
 
 // Inspector : defines a particular metric supported by a driver
 type Inspector interface {
-	Parse(output string)
+	// Parse keeps the values it could parse when returning an error
+	Parse(output string) error
 	SetDriver(driver *driver.Driver)
-	Execute() ([]byte, error)
+	Execute() (*Result, error)
 	driverExec() driver.Command
 }
 
+// Result : values read by an inspector, JSON is only produced when
+// results leave saido
+type Result struct {
+	Host   string
+	Metric string
+	// Time the inspector started executing
+	Time     time.Time
+	Duration time.Duration
+	// Units of fields of Values e.g {"MemTotal": "MB"}
+	Units  map[string]string
+	Values interface{}
+}
+
+// Collect : execute inspector i reading metric of host, byte sizes are
+// converted to the display units of metric
+func Collect(host string, metric string, i Inspector) (*Result, error) {
+	if keeper, ok := i.(stateful); ok {
+		keeper.setStateKey(host + "\x00" + metric)
+	}
+	start := time.Now()
+	result, err := i.Execute()
+	if result != nil {
+		result.Host = host
+		result.Metric = metric
+		result.Time = start
+		result.Duration = time.Since(start)
+		convertUnits(metric, result)
+	}
+	return result, err
+}
+
+// ParseError : unexpected output of a driver, Execute returns it along
+// with the values that could be parsed
+type ParseError struct {
+	inspector string
+	content   string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("Parse Error on %s: %s", e.inspector, e.content)
+}
+
+// parseErrors : collects errors of values skipped while parsing so the
+// rest of the output is still parsed
+type parseErrors struct {
+	inspector string
+	errs      []string
+}
+
+func newParseErrors(inspector string) *parseErrors {
+	return &parseErrors{inspector: inspector}
+}
+
+func (p *parseErrors) add(format string, args ...interface{}) {
+	p.errs = append(p.errs, fmt.Sprintf(format, args...))
+}
+
+// float : parsed value, unparsable values are recorded and 0
+func (p *parseErrors) float(value string, name string) float64 {
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		p.add("could not parse %s: %s", name, err)
+		return 0
+	}
+	return parsed
+}
+
+// int : parsed value, unparsable values are recorded and 0
+func (p *parseErrors) int(value string, name string) int {
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		p.add("could not parse %s: %s", name, err)
+		return 0
+	}
+	return parsed
+}
+
+// bytes : value in displayByteSize, unparsable values are recorded and 0
+func (p *parseErrors) bytes(byteCount string, rawByteSize string, displayByteSize string) float64 {
+	size, err := NewByteSize(byteCount, rawByteSize)
+	if err != nil {
+		p.add("could not parse size %s: %s", byteCount, err)
+		return 0
+	}
+	return size.format(displayByteSize)
+}
+
+// size : size printed with its unit in displayByteSize, unparsable
+// sizes are recorded and 0
+func (p *parseErrors) size(size string, displayByteSize string) float64 {
+	parsed, err := ParseByteSize(size)
+	if err != nil {
+		p.add("could not parse size %s: %s", size, err)
+		return 0
+	}
+	return parsed.format(displayByteSize)
+}
+
+func (p *parseErrors) err() error {
+	if len(p.errs) == 0 {
+		return nil
+	}
+	return &ParseError{inspector: p.inspector, content: strings.Join(p.errs, "; ")}
+}
+
+// newResult : values are returned along with parse errors as partial
+// results, byte sizes of values are in displayByteSize
+func newResult(values interface{}, displayByteSize string, parseErr error) (*Result, error) {
+	return &Result{
+		Units:  fieldUnits(values, displayByteSize),
+		Values: values,
+	}, parseErr
+}
+
+// fieldUnits : units of the `unit` tags of the fields of values, tagged
+// with bytes or bytes/s when in displayByteSize
+func fieldUnits(values interface{}, displayByteSize string) map[string]string {
+	units := make(map[string]string)
+	kind := reflect.TypeOf(values)
+	for kind != nil && (kind.Kind() == reflect.Ptr || kind.Kind() == reflect.Slice) {
+		kind = kind.Elem()
+	}
+	if kind == nil || kind.Kind() != reflect.Struct {
+		return units
+	}
+	for index := 0; index < kind.NumField(); index++ {
+		field := kind.Field(index)
+		unit, ok := field.Tag.Lookup("unit")
+		if !ok {
+			continue
+		}
+		if unit == "bytes" {
+			unit = displayByteSize
+		} else if unit == "bytes/s" {
+			unit = displayByteSize + "/s"
+		}
+		units[field.Name] = unit
+	}
+	return units
+}
+
 type NewInspector func(driver *driver.Driver, custom ...string) (Inspector, error)
 
-var inspectorMap = map[string]NewInspector{
-	`disk`:        NewDF,
-	`docker`:      NewDockerStats,
-	`uptime`:      NewUptime,
-	`memory`:      NewMemInfo,
-	`process`:     NewProcess,
-	`loadavg`:     NewLoadAvg,
-	`tcp`:         NewTcp,
-	CustomCommand: NewCustom,
+// registration : constructor of an inspector along with what it reads
+type registration struct {
+	New         NewInspector
+	Description string
+	// Platforms are the names of the systems of drivers supported
+	Platforms []string
+	// Values is a zero value of the values read
+	Values interface{}
+	// List and Fields describe values of declared inspectors which have
+	// no Values
+	List   bool
+	Fields []Field
+}
+
+// systemPlatforms : platforms of drivers running commands on a host
+var systemPlatforms = []string{"linux", "darwin", "windows"}
+
+// platformOf : name of the platform of a driver used by registrations
+func platformOf(details driver.SystemDetails) string {
+	switch {
+	case details.IsLinux:
+		return "linux"
+	case details.IsDarwin:
+		return "darwin"
+	case details.IsWindows:
+		return "windows"
+	case details.IsWeb:
+		return "web"
+	case details.IsKubernetes:
+		return "kubernetes"
+	case details.IsNodeExporter:
+		return "node_exporter"
+	case details.IsSNMP:
+		return "snmp"
+	case details.IsPortCheck:
+		return "portcheck"
+	case details.IsDNS:
+		return "dns"
+	}
+	return ``
+}
+
+var inspectorMap = map[string]registration{
+	`disk`: {New: NewDF, Values: []DFMetrics{},
+		Description: "Disk usage of every filesystem",
+		Platforms:   []string{"linux", "darwin", "windows", "node_exporter"}},
+	`docker`: {New: NewDockerStats, Values: []DockerStatsMetrics{},
+		Description: "Usage of docker containers from `docker stats`",
+		Platforms:   systemPlatforms},
+	`containers`: {New: NewContainers, Values: []ContainerMetrics{},
+		Description: "State and usage of containers from the docker engine API",
+		Platforms:   systemPlatforms},
+	`pods`: {New: NewPods, Values: []PodMetrics{},
+		Description: "Phase and restarts of pods",
+		Platforms:   []string{"kubernetes"}},
+	`nodes`: {New: NewNodes, Values: []NodeMetrics{},
+		Description: "Readiness and requested resources of nodes",
+		Platforms:   []string{"kubernetes"}},
+	`uptime`: {New: NewUptime, Values: UptimeMetrics{},
+		Description: "Time since boot and idle time",
+		Platforms:   []string{"linux", "darwin", "windows", "node_exporter", "snmp"}},
+	`memory`: {New: NewMemInfo, Values: MemInfoMetrics{},
+		Description: "Memory and swap usage",
+		Platforms:   []string{"linux", "darwin", "windows", "node_exporter"}},
+	`process`: {New: NewProcess, Values: []ProcessMetrics{},
+		Description: "Running processes, sorted, limited and grouped by the process view",
+		Platforms:   systemPlatforms},
+	trackedProcessCommand: {New: newTrackedProcess, Values: TrackedProcessMetrics{},
+		Description: "Processes matching a filter and their restarts",
+		Platforms:   systemPlatforms},
+	`loadavg`: {New: NewLoadAvg, Values: LoadAvgMetrics{},
+		Description: "Load averages, only the instantaneous load on windows",
+		Platforms:   []string{"linux", "darwin", "windows", "node_exporter"}},
+	`cpu`: {New: NewCPU, Values: CPUMetrics{},
+		Description: "Time spent by processors in every mode",
+		Platforms:   []string{"linux", "node_exporter"}},
+	`network`: {New: NewNetwork, Values: []NetworkMetrics{},
+		Description: "Traffic of every network interface",
+		Platforms:   []string{"linux", "node_exporter"}},
+	`interfaces`: {New: NewInterfaces, Values: []InterfaceMetrics{},
+		Description: "Status and traffic of interfaces of network devices",
+		Platforms:   []string{"snmp"}},
+	`tcp`: {New: NewTcp, Values: TcpMetrics{},
+		Description: "Status of local tcp ports",
+		Platforms:   systemPlatforms},
+	`portcheck`: {New: NewPortCheck, Values: []PortCheckMetrics{},
+		Description: "Reachability and latency of tcp, tls and udp targets",
+		Platforms:   []string{"portcheck"}},
+	`dns`: {New: NewDNS, Values: []DNSMetrics{},
+		Description: "Answers and latency of dns queries",
+		Platforms:   []string{"dns"}},
+	CustomCommand: {New: NewCustom, Values: CustomMetrics{},
+		Description: "Output of a command, names must start with custom",
+		Platforms:   systemPlatforms},
+	SNMPCommand: {New: NewSNMPOID, Values: []SNMPOIDMetrics{},
+		Description: "Values of configured OIDs, names must start with snmp",
+		Platforms:   []string{"snmp"}},
 	// NOTE: Inactive for now
-	`responsetime`: NewResponseTime,
+	`responsetime`: {New: NewResponseTime, Values: ResponseTimeMetrics{},
+		Description: "Response time of a web page",
+		Platforms:   []string{"web"}},
 }
 
 // Valid : checks if inspector is a valid inspector
 func Valid(name string) bool {
 	for key := range inspectorMap {
-		if name == key || strings.HasPrefix(name, CustomCommand) {
+		if name == key || strings.HasPrefix(name, CustomCommand) || strings.HasPrefix(name, SNMPCommand) ||
+			strings.HasPrefix(name, ProcessCommand+"-") {
 			return true
 		}
 	}
 	return false
 }
 
+// registryName : name of the inspectorMap entry of inspector name
+func registryName(name string) string {
+	if strings.HasPrefix(name, CustomCommand) {
+		return CustomCommand
+	} else if strings.HasPrefix(name, SNMPCommand) {
+		return SNMPCommand
+	} else if strings.HasPrefix(name, ProcessCommand+"-") {
+		return trackedProcessCommand
+	}
+	return name
+}
+
 // Init : initializes the specified inspector using name and driver
 func Init(name string, driver *driver.Driver, custom ...string) (Inspector, error) {
-	if strings.HasPrefix(name, CustomCommand) {
-		name = "custom"
+	// inspectors of agents are run by the agent itself
+	if isAgent(driver) && Valid(name) {
+		return NewRemote(name, driver, custom...)
 	}
-	val, ok := inspectorMap[name]
+	val, ok := inspectorMap[registryName(name)]
 	if ok {
-		inspector, err := val(driver, custom...)
+		inspector, err := val.New(driver, custom...)
 		if err != nil {
 			return nil, err
 		}
@@ -0,0 +1,71 @@
+package inspector
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/bisohns/saido/driver"
+)
+
+const fakeDockerStats = `CONTAINER ID   NAME      CPU %     MEM USAGE / LIMIT     MEM %     NET I/O         BLOCK I/O     PIDS
+3f4e5a6b7c8d   redis     0.07%     796KiB / 64MiB        1.21%     788B / 648B     3.57MB / 0B   2
+9a8b7c6d5e4f   web       n/a       2.5MiB / 64MiB        3.90%     1.2kB / 648B    12MB / 0B     3
+`
+
+func TestExecuteReturnsParsedValues(t *testing.T) {
+	var d driver.Driver = &fakeProcesses{output: fakeDockerStats}
+	i, err := NewDockerStats(&d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := i.Execute()
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("Expected parse error for bad cpu, found %v", err)
+	}
+	values := result.Values.([]DockerStatsMetrics)
+	if len(values) != 1 || values[0].ContainerName != "redis" || values[0].CPU != 0.07 {
+		t.Errorf("Expected redis to be kept, found %+v", values)
+	}
+}
+
+func TestResponseTimeParse(t *testing.T) {
+	i := &ResponseTime{}
+	if err := i.Parse("0.25\n"); err != nil || i.Values.Seconds != 0.25 {
+		t.Errorf("Unexpected response time %v: %v", i.Values, err)
+	}
+	if err := i.Parse(`timeout`); err == nil {
+		t.Error("Expected parse error for response time")
+	}
+}
+
+func TestCollect(t *testing.T) {
+	var d driver.Driver = &fakeProcesses{output: fakePsAxu}
+	i, err := NewProcess(&d, `top=1`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := Collect("web-1", "process", i)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Host != "web-1" || result.Metric != "process" || result.Time.IsZero() {
+		t.Errorf("Expected result to be stamped, found %+v", result)
+	}
+	if values := result.Values.([]ProcessMetrics); len(values) != 1 || values[0].Pid != 1200 {
+		t.Errorf("Unexpected values %+v", result.Values)
+	}
+	if result.Units["RSS"] != "KB" || result.Units["CPU"] != "%" {
+		t.Errorf("Unexpected units %v", result.Units)
+	}
+}
+
+func TestFieldUnits(t *testing.T) {
+	units := fieldUnits(&MemInfoMetrics{}, `GB`)
+	if len(units) != 5 || units["MemTotal"] != "GB" {
+		t.Errorf("Expected byte sizes in GB, found %v", units)
+	}
+	if units := fieldUnits(map[string]interface{}{}, `MB`); len(units) != 0 {
+		t.Errorf("Expected no units of untyped values, found %v", units)
+	}
+}
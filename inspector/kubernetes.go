@@ -0,0 +1,377 @@
+package inspector
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bisohns/saido/driver"
+	log "github.com/sirupsen/logrus"
+)
+
+// quantitySuffixes : multipliers of kubernetes resource quantities
+var quantitySuffixes = map[string]float64{
+	"n":  1e-9,
+	"u":  1e-6,
+	"m":  1e-3,
+	"":   1,
+	"k":  1e3,
+	"M":  1e6,
+	"G":  1e9,
+	"T":  1e12,
+	"P":  1e15,
+	"E":  1e18,
+	"Ki": 1 << 10,
+	"Mi": 1 << 20,
+	"Gi": 1 << 30,
+	"Ti": 1 << 40,
+	"Pi": 1 << 50,
+	"Ei": 1 << 60,
+}
+
+// parseQuantity : parse kubernetes quantities e.g 250m, 1.5, 128Mi, 1e3
+func parseQuantity(quantity string) (float64, error) {
+	quantity = strings.TrimSpace(quantity)
+	if quantity == "" {
+		return 0, nil
+	}
+	end := len(quantity)
+	for end > 0 && strings.ContainsRune("numkMGTPEi", rune(quantity[end-1])) {
+		end--
+	}
+	number, suffix := quantity[:end], quantity[end:]
+	multiplier, ok := quantitySuffixes[suffix]
+	if !ok {
+		return 0, fmt.Errorf("Invalid quantity suffix in %s", quantity)
+	}
+	value, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid quantity %s", quantity)
+	}
+	return value * multiplier, nil
+}
+
+type kubernetesContainer struct {
+	Name      string
+	Resources struct {
+		Requests map[string]string
+	}
+}
+
+type kubernetesPod struct {
+	Metadata struct {
+		Name      string
+		Namespace string
+	}
+	Spec struct {
+		NodeName   string
+		Containers []kubernetesContainer
+	}
+	Status struct {
+		Phase             string
+		ContainerStatuses []struct {
+			Name         string
+			Ready        bool
+			RestartCount int
+		}
+	}
+}
+
+type kubernetesNode struct {
+	Metadata struct {
+		Name string
+	}
+	Status struct {
+		Allocatable map[string]string
+		Conditions  []struct {
+			Type   string
+			Status string
+		}
+	}
+}
+
+type kubernetesPodList struct {
+	Items []kubernetesPod
+}
+
+type kubernetesNodeList struct {
+	Items []kubernetesNode
+}
+
+func kubernetesProvider(d *driver.Driver) (driver.KubernetesProvider, error) {
+	provider, ok := (*d).(driver.KubernetesProvider)
+	if !ok {
+		return nil, errors.New("Driver cannot reach a kubernetes API")
+	}
+	return provider, nil
+}
+
+// PodMetrics : Metrics used by Pods
+type PodMetrics struct {
+	Name      string `desc:"Name of the pod"`
+	Namespace string `desc:"Namespace of the pod"`
+	// Phase e.g Pending, Running, Succeeded, Failed, Unknown
+	Phase           string `desc:"Phase e.g Pending, Running, Succeeded, Failed, Unknown"`
+	Node            string `desc:"Node the pod is scheduled on"`
+	Restarts        int    `kind:"counter" desc:"Restarts of containers of the pod"`
+	ReadyContainers int    `desc:"Containers ready"`
+	Containers      int    `desc:"Containers of the pod"`
+	Ready           bool   `desc:"Every container is ready"`
+}
+
+// Pods : Reading pod state from the kubernetes API
+type Pods struct {
+	Driver *driver.Driver
+	// Namespace to list pods in, all namespaces when `*`
+	Namespace string
+	// Values of metrics being read
+	Values []PodMetrics
+}
+
+// Parse : run custom parsing on the pod list returned by the API
+func (i *Pods) Parse(output string) error {
+	var list kubernetesPodList
+	values := []PodMetrics{}
+	log.Debug("Parsing output string in Pods inspector")
+	if err := json.Unmarshal([]byte(output), &list); err != nil {
+		i.Values = values
+		return &ParseError{inspector: "Pods", content: err.Error()}
+	}
+	for _, pod := range list.Items {
+		metric := PodMetrics{
+			Name:       pod.Metadata.Name,
+			Namespace:  pod.Metadata.Namespace,
+			Phase:      pod.Status.Phase,
+			Node:       pod.Spec.NodeName,
+			Containers: len(pod.Spec.Containers),
+		}
+		for _, status := range pod.Status.ContainerStatuses {
+			metric.Restarts += status.RestartCount
+			if status.Ready {
+				metric.ReadyContainers++
+			}
+		}
+		metric.Ready = metric.Containers > 0 && metric.ReadyContainers == metric.Containers
+		values = append(values, metric)
+	}
+	i.Values = values
+	return nil
+}
+
+func (i *Pods) SetDriver(driver *driver.Driver) {
+	i.Driver = driver
+}
+
+// fetch : list pods of namespace
+func (i Pods) fetch(namespace string) (string, error) {
+	provider, err := kubernetesProvider(i.Driver)
+	if err != nil {
+		return ``, err
+	}
+	if namespace == "" {
+		namespace = provider.KubernetesNamespace()
+	}
+	path := fmt.Sprintf("/api/v1/namespaces/%s/pods", namespace)
+	if namespace == "*" {
+		path = "/api/v1/pods"
+	}
+	var list json.RawMessage
+	if err = provider.KubernetesGet(path, &list); err != nil {
+		return ``, err
+	}
+	return string(list), nil
+}
+
+func (i Pods) driverExec() driver.Command {
+	return i.fetch
+}
+
+func (i *Pods) Execute() (*Result, error) {
+	output, err := i.driverExec()(i.Namespace)
+	if err == nil {
+		err = i.Parse(output)
+		return newResult(i.Values, ``, err)
+	}
+	return nil, err
+}
+
+// NewPods : Initialize a new Pods instance
+func NewPods(driver *driver.Driver, _ ...string) (Inspector, error) {
+	var pods Inspector
+	details, err := (*driver).GetDetails()
+	if err != nil {
+		return nil, err
+	}
+	if !details.IsKubernetes {
+		return nil, errors.New("Cannot use Pods on drivers outside (kubernetes)")
+	}
+	pods = &Pods{}
+	pods.SetDriver(driver)
+	return pods, nil
+}
+
+// NodeMetrics : Metrics used by Nodes
+type NodeMetrics struct {
+	Name  string `desc:"Name of the node"`
+	Ready bool   `desc:"Node is ready"`
+	// Conditions e.g MemoryPressure: False
+	Conditions map[string]string `desc:"Status of conditions e.g MemoryPressure: False"`
+	// CPU in cores
+	CPUAllocatable float64 `unit:"cores" desc:"CPU allocatable to pods"`
+	CPURequested   float64 `unit:"cores" desc:"CPU requested by pods"`
+	CPUPercent     float64 `unit:"%" desc:"CPU requested of the allocatable"`
+	MemAllocatable float64 `unit:"bytes" desc:"Memory allocatable to pods"`
+	MemRequested   float64 `unit:"bytes" desc:"Memory requested by pods"`
+	MemPercent     float64 `unit:"%" desc:"Memory requested of the allocatable"`
+	Pods           int     `desc:"Pods scheduled on the node"`
+}
+
+// nodeSample : nodes and pods listed together so requests can be summed
+type nodeSample struct {
+	Nodes kubernetesNodeList
+	Pods  kubernetesPodList
+}
+
+// Nodes : Reading node conditions and resource requests from the kubernetes API
+type Nodes struct {
+	Driver *driver.Driver
+	// The memory values read from the API are in B
+	RawByteSize string
+	// We want do display memory values in MB
+	DisplayByteSize string
+	// Values of metrics being read
+	Values []NodeMetrics
+}
+
+func (i Nodes) bytes(value float64) float64 {
+	return byteSizeOf(float64(int64(value)), i.RawByteSize).format(i.DisplayByteSize)
+}
+
+// Parse : run custom parsing on the nodes and pods returned by the API
+func (i *Nodes) Parse(output string) error {
+	var sample nodeSample
+	values := []NodeMetrics{}
+	log.Debug("Parsing output string in Nodes inspector")
+	if err := json.Unmarshal([]byte(output), &sample); err != nil {
+		i.Values = values
+		return &ParseError{inspector: "Nodes", content: err.Error()}
+	}
+	errs := newParseErrors("Nodes")
+	// unparsable quantities are recorded and counted as 0
+	quantity := func(value string) float64 {
+		parsed, err := parseQuantity(value)
+		if err != nil {
+			errs.add("%s", err)
+		}
+		return parsed
+	}
+	type requested struct {
+		cpu, memory float64
+		pods        int
+	}
+	requests := make(map[string]*requested)
+	for _, pod := range sample.Pods.Items {
+		// finished pods no longer hold their requests
+		if pod.Spec.NodeName == "" || pod.Status.Phase == "Succeeded" || pod.Status.Phase == "Failed" {
+			continue
+		}
+		node, ok := requests[pod.Spec.NodeName]
+		if !ok {
+			node = &requested{}
+			requests[pod.Spec.NodeName] = node
+		}
+		node.pods++
+		for _, container := range pod.Spec.Containers {
+			node.cpu += quantity(container.Resources.Requests["cpu"])
+			node.memory += quantity(container.Resources.Requests["memory"])
+		}
+	}
+	for _, node := range sample.Nodes.Items {
+		metric := NodeMetrics{
+			Name:           node.Metadata.Name,
+			Conditions:     make(map[string]string),
+			CPUAllocatable: quantity(node.Status.Allocatable["cpu"]),
+		}
+		memAllocatable := quantity(node.Status.Allocatable["memory"])
+		for _, condition := range node.Status.Conditions {
+			metric.Conditions[condition.Type] = condition.Status
+			if condition.Type == "Ready" {
+				metric.Ready = condition.Status == "True"
+			}
+		}
+		if req, ok := requests[node.Metadata.Name]; ok {
+			metric.CPURequested = math.Round(req.cpu*1000) / 1000
+			metric.Pods = req.pods
+			metric.MemRequested = i.bytes(req.memory)
+			if metric.CPUAllocatable != 0 {
+				metric.CPUPercent = req.cpu / metric.CPUAllocatable * 100
+			}
+			if memAllocatable != 0 {
+				metric.MemPercent = req.memory / memAllocatable * 100
+			}
+		}
+		metric.MemAllocatable = i.bytes(memAllocatable)
+		values = append(values, metric)
+	}
+	sort.Slice(values, func(a, b int) bool {
+		return values[a].Name < values[b].Name
+	})
+	i.Values = values
+	return errs.err()
+}
+
+func (i *Nodes) SetDriver(driver *driver.Driver) {
+	i.Driver = driver
+}
+
+// fetch : list nodes and pods of every namespace
+func (i Nodes) fetch(_ string) (string, error) {
+	provider, err := kubernetesProvider(i.Driver)
+	if err != nil {
+		return ``, err
+	}
+	var sample nodeSample
+	if err = provider.KubernetesGet("/api/v1/nodes", &sample.Nodes); err != nil {
+		return ``, err
+	}
+	if err = provider.KubernetesGet("/api/v1/pods", &sample.Pods); err != nil {
+		return ``, err
+	}
+	output, err := json.Marshal(sample)
+	return string(output), err
+}
+
+func (i Nodes) driverExec() driver.Command {
+	return i.fetch
+}
+
+func (i *Nodes) Execute() (*Result, error) {
+	output, err := i.driverExec()(``)
+	if err == nil {
+		err = i.Parse(output)
+		return newResult(i.Values, i.DisplayByteSize, err)
+	}
+	return nil, err
+}
+
+// NewNodes : Initialize a new Nodes instance
+func NewNodes(driver *driver.Driver, _ ...string) (Inspector, error) {
+	var nodes Inspector
+	details, err := (*driver).GetDetails()
+	if err != nil {
+		return nil, err
+	}
+	if !details.IsKubernetes {
+		return nil, errors.New("Cannot use Nodes on drivers outside (kubernetes)")
+	}
+	nodes = &Nodes{
+		RawByteSize:     `B`,
+		DisplayByteSize: defaultDisplayByteSize,
+	}
+	nodes.SetDriver(driver)
+	return nodes, nil
+}
@@ -0,0 +1,140 @@
+package inspector
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/bisohns/saido/driver"
+)
+
+const fakeKubernetesPods = `{"items": [
+  {"metadata": {"name": "web-1", "namespace": "default"},
+   "spec": {"nodeName": "node-a", "containers": [
+     {"name": "web", "resources": {"requests": {"cpu": "250m", "memory": "128Mi"}}},
+     {"name": "proxy", "resources": {"requests": {"cpu": "0.25", "memory": "64Mi"}}}]},
+   "status": {"phase": "Running", "containerStatuses": [
+     {"name": "web", "ready": true, "restartCount": 3},
+     {"name": "proxy", "ready": false, "restartCount": 1}]}},
+  {"metadata": {"name": "job-1", "namespace": "default"},
+   "spec": {"nodeName": "node-a", "containers": [
+     {"name": "job", "resources": {"requests": {"cpu": "1", "memory": "1Gi"}}}]},
+   "status": {"phase": "Succeeded"}}
+]}`
+
+const fakeKubernetesNodes = `{"items": [
+  {"metadata": {"name": "node-a"},
+   "status": {"allocatable": {"cpu": "2", "memory": "1024Mi"},
+     "conditions": [{"type": "MemoryPressure", "status": "False"}, {"type": "Ready", "status": "True"}]}},
+  {"metadata": {"name": "node-b"},
+   "status": {"allocatable": {"cpu": "4", "memory": "2Gi"},
+     "conditions": [{"type": "Ready", "status": "Unknown"}]}}
+]}`
+
+// fakeKubernetes : serves fixed responses for kubernetes inspectors
+type fakeKubernetes struct {
+	driver.Local
+	paths map[string]string
+}
+
+func (d *fakeKubernetes) KubernetesGet(path string, out interface{}) error {
+	return json.Unmarshal([]byte(d.paths[path]), out)
+}
+
+func (d *fakeKubernetes) KubernetesNamespace() string {
+	return "default"
+}
+
+func (d *fakeKubernetes) GetDetails() (driver.SystemDetails, error) {
+	return driver.SystemDetails{Name: "kubernetes", IsKubernetes: true}, nil
+}
+
+func newFakeKubernetes() driver.Driver {
+	return &fakeKubernetes{
+		paths: map[string]string{
+			"/api/v1/namespaces/default/pods": fakeKubernetesPods,
+			"/api/v1/pods":                    fakeKubernetesPods,
+			"/api/v1/nodes":                   fakeKubernetesNodes,
+		},
+	}
+}
+
+func TestParseQuantity(t *testing.T) {
+	cases := map[string]float64{
+		"250m":  0.25,
+		"1.5":   1.5,
+		"128Mi": 128 * 1024 * 1024,
+		"2G":    2e9,
+		"1e3":   1000,
+		"":      0,
+	}
+	for quantity, expected := range cases {
+		value, err := parseQuantity(quantity)
+		if err != nil {
+			t.Error(err)
+		}
+		if value != expected {
+			t.Errorf("Expected %f for %s, found %f", expected, quantity, value)
+		}
+	}
+	if _, err := parseQuantity("12Xi"); err == nil {
+		t.Error("Expected error for invalid quantity")
+	}
+}
+
+func TestPodsOnFakeKubernetes(t *testing.T) {
+	d := newFakeKubernetes()
+	i, err := NewPods(&d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = i.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	pods := i.(*Pods)
+	if len(pods.Values) != 2 {
+		t.Fatalf("Expected 2 pods, found %d", len(pods.Values))
+	}
+	web := pods.Values[0]
+	if web.Restarts != 4 || web.ReadyContainers != 1 || web.Containers != 2 || web.Ready {
+		t.Errorf("Unexpected pod metrics %v", web)
+	}
+	if web.Node != "node-a" || web.Phase != "Running" {
+		t.Errorf("Unexpected pod placement %v", web)
+	}
+}
+
+func TestNodesOnFakeKubernetes(t *testing.T) {
+	d := newFakeKubernetes()
+	i, err := NewNodes(&d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = i.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	nodes := i.(*Nodes)
+	if len(nodes.Values) != 2 {
+		t.Fatalf("Expected 2 nodes, found %d", len(nodes.Values))
+	}
+	a, b := nodes.Values[0], nodes.Values[1]
+	if !a.Ready || a.Conditions["MemoryPressure"] != "False" {
+		t.Errorf("Unexpected conditions %v", a)
+	}
+	// succeeded job is not counted against the node
+	if a.Pods != 1 || a.CPURequested != 0.5 || a.CPUPercent != 25 {
+		t.Errorf("Unexpected cpu requests %v", a)
+	}
+	if a.MemPercent != 18.75 || a.MemAllocatable != 1024 {
+		t.Errorf("Unexpected memory requests %v", a)
+	}
+	if b.Ready || b.Pods != 0 || b.MemAllocatable != 2048 {
+		t.Errorf("Unexpected metrics for idle node %v", b)
+	}
+}
+
+func TestPodsRequireKubernetes(t *testing.T) {
+	var d driver.Driver = &driver.Local{}
+	if _, err := NewPods(&d); err == nil {
+		t.Error("Expected error on local driver")
+	}
+}
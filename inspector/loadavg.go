@@ -1,10 +1,8 @@
 package inspector
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
-	"strconv"
 	"strings"
 
 	"github.com/bisohns/saido/driver"
@@ -13,9 +11,9 @@ import (
 
 // LoadAvgMetrics : Metrics used by LoadAvg
 type LoadAvgMetrics struct {
-	Load1M  float64
-	Load5M  float64
-	Load15M float64
+	Load1M  float64 `desc:"Load average over 1 minute"`
+	Load5M  float64 `desc:"Load average over 5 minutes"`
+	Load15M float64 `desc:"Load average over 15 minutes"`
 }
 
 // LoadAvgLinux : Parsing the /proc/loadavg output for load average monitoring
@@ -39,22 +37,23 @@ type LoadAvgWin struct {
 	Values  *LoadAvgMetrics
 }
 
-func loadavgParseOutput(output string) *LoadAvgMetrics {
-	var err error
+func loadavgParseOutput(output string) (*LoadAvgMetrics, error) {
 	log.Debug("Parsing output string in LoadAvg inspector")
+	errs := newParseErrors("LoadAvg")
 	columns := strings.Fields(output)
-	Load1M, err := strconv.ParseFloat(columns[0], 64)
-	Load5M, err := strconv.ParseFloat(columns[1], 64)
-	Load15M, err := strconv.ParseFloat(columns[2], 64)
-	if err != nil {
-		log.Fatalf(`Error Parsing LoadAvg: %s `, err)
+	if len(columns) < 3 {
+		errs.add("expected 3 load averages in %q", output)
+		return &LoadAvgMetrics{}, errs.err()
 	}
+	Load1M := errs.float(columns[0], "1m load")
+	Load5M := errs.float(columns[1], "5m load")
+	Load15M := errs.float(columns[2], "15m load")
 
 	return &LoadAvgMetrics{
 		Load1M,
 		Load5M,
 		Load15M,
-	}
+	}, errs.err()
 }
 
 func (i *LoadAvgDarwin) SetDriver(driver *driver.Driver) {
@@ -73,18 +72,20 @@ func (i LoadAvgDarwin) driverExec() driver.Command {
 /*
 4.27, 5.04, 4.50
 */
-func (i *LoadAvgDarwin) Parse(output string) {
+func (i *LoadAvgDarwin) Parse(output string) error {
+	var err error
 	output = strings.ReplaceAll(output, ",", "")
-	i.Values = loadavgParseOutput(output)
+	i.Values, err = loadavgParseOutput(output)
+	return err
 }
 
-func (i *LoadAvgDarwin) Execute() ([]byte, error) {
+func (i *LoadAvgDarwin) Execute() (*Result, error) {
 	output, err := i.driverExec()(i.Command)
 	if err == nil {
-		i.Parse(output)
-		return json.Marshal(i.Values)
+		err = i.Parse(output)
+		return newResult(i.Values, ``, err)
 	} else {
-		return []byte(""), err
+		return nil, err
 	}
 }
 
@@ -92,8 +93,10 @@ func (i *LoadAvgDarwin) Execute() ([]byte, error) {
 /*
 0.25 0.23 0.14 3/671 9362
 */
-func (i *LoadAvgLinux) Parse(output string) {
-	i.Values = loadavgParseOutput(output)
+func (i *LoadAvgLinux) Parse(output string) error {
+	var err error
+	i.Values, err = loadavgParseOutput(output)
+	return err
 }
 
 func (i *LoadAvgLinux) SetDriver(driver *driver.Driver) {
@@ -108,25 +111,31 @@ func (i LoadAvgLinux) driverExec() driver.Command {
 	return (*i.Driver).ReadFile
 }
 
-func (i *LoadAvgLinux) Execute() ([]byte, error) {
+func (i *LoadAvgLinux) Execute() (*Result, error) {
 	output, err := i.driverExec()(i.FilePath)
 	if err == nil {
-		i.Parse(output)
-		return json.Marshal(i.Values)
+		err = i.Parse(output)
+		return newResult(i.Values, ``, err)
 	} else {
-		return []byte(""), err
+		return nil, err
 	}
 }
 
-func (i *LoadAvgWin) Parse(output string) {
+func (i *LoadAvgWin) Parse(output string) error {
+	var err error
 	output = strings.ReplaceAll(output, "\r", "")
 	output = strings.ReplaceAll(output, " ", "")
 	columns := strings.Split(output, "\n")
+	if len(columns) < 2 {
+		i.Values = &LoadAvgMetrics{}
+		return &ParseError{inspector: "LoadAvgWin", content: fmt.Sprintf("no load percentage in %q", output)}
+	}
 	// Only instantaneous metrics available so append the
 	// rest as zero
 	output = columns[1]
 	output = fmt.Sprintf("%s 0 0", output)
-	i.Values = loadavgParseOutput(output)
+	i.Values, err = loadavgParseOutput(output)
+	return err
 }
 
 func (i *LoadAvgWin) SetDriver(driver *driver.Driver) {
@@ -141,13 +150,13 @@ func (i LoadAvgWin) driverExec() driver.Command {
 	return (*i.Driver).RunCommand
 }
 
-func (i *LoadAvgWin) Execute() ([]byte, error) {
+func (i *LoadAvgWin) Execute() (*Result, error) {
 	output, err := i.driverExec()(i.Command)
 	if err == nil {
-		i.Parse(output)
-		return json.Marshal(i.Values)
+		err = i.Parse(output)
+		return newResult(i.Values, ``, err)
 	} else {
-		return []byte(""), err
+		return nil, err
 	}
 }
 
@@ -158,6 +167,11 @@ func NewLoadAvg(driver *driver.Driver, _ ...string) (Inspector, error) {
 	if err != nil {
 		return nil, err
 	}
+	if details.IsNodeExporter {
+		loadavg = &LoadAvgNodeExporter{}
+		loadavg.SetDriver(driver)
+		return loadavg, nil
+	}
 	if !(details.IsLinux || details.IsDarwin || details.IsWindows) {
 		return nil, errors.New("Cannot use LoadAvg on drivers outside (linux, darwin)")
 	}
@@ -1,7 +1,6 @@
 package inspector
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
 	"strconv"
@@ -13,11 +12,11 @@ import (
 
 // Metrics used by MemInfo
 type MemInfoMetrics struct {
-	MemTotal  float64
-	MemFree   float64
-	Cached    float64
-	SwapTotal float64
-	SwapFree  float64
+	MemTotal  float64 `unit:"bytes" desc:"Total memory"`
+	MemFree   float64 `unit:"bytes" desc:"Free memory"`
+	Cached    float64 `unit:"bytes" desc:"Memory used as cache"`
+	SwapTotal float64 `unit:"bytes" desc:"Total swap"`
+	SwapFree  float64 `unit:"bytes" desc:"Free swap"`
 }
 
 // MemInfoLinux : Parsing the `/proc/meminfo` file output for memory monitoring
@@ -60,7 +59,7 @@ type MemInfoWin struct {
 	Values *MemInfoMetrics
 }
 
-func memInfoParseOutput(output, rawByteSize, displayByteSize string) *MemInfoMetrics {
+func memInfoParseOutput(output, rawByteSize, displayByteSize string) (*MemInfoMetrics, error) {
 	log.Debug("Parsing output string in meminfo inspector")
 	memTotal := getMatching("MemTotal", output)
 	memFree := getMatching("MemFree", output)
@@ -91,14 +90,15 @@ func getMatching(metric string, rows string) string {
 	return `0`
 }
 
-func createMetric(columns []string, rawByteSize, displayByteSize string) *MemInfoMetrics {
+func createMetric(columns []string, rawByteSize, displayByteSize string) (*MemInfoMetrics, error) {
+	errs := newParseErrors("MemInfo")
 	return &MemInfoMetrics{
-		MemTotal:  NewByteSize(columns[0], rawByteSize).format(displayByteSize),
-		MemFree:   NewByteSize(columns[1], rawByteSize).format(displayByteSize),
-		Cached:    NewByteSize(columns[2], rawByteSize).format(displayByteSize),
-		SwapTotal: NewByteSize(columns[3], rawByteSize).format(displayByteSize),
-		SwapFree:  NewByteSize(columns[4], rawByteSize).format(displayByteSize),
-	}
+		MemTotal:  errs.bytes(columns[0], rawByteSize, displayByteSize),
+		MemFree:   errs.bytes(columns[1], rawByteSize, displayByteSize),
+		Cached:    errs.bytes(columns[2], rawByteSize, displayByteSize),
+		SwapTotal: errs.bytes(columns[3], rawByteSize, displayByteSize),
+		SwapFree:  errs.bytes(columns[4], rawByteSize, displayByteSize),
+	}, errs.err()
 }
 
 // Parse : run custom parsing on output of the command
@@ -112,9 +112,11 @@ Cached:          1567652 kB
 ...
 
 */
-func (i *MemInfoLinux) Parse(output string) {
+func (i *MemInfoLinux) Parse(output string) error {
+	var err error
 	log.Debug("Parsing output string in MemInfoLinux inspector")
-	i.Values = memInfoParseOutput(output, i.RawByteSize, i.DisplayByteSize)
+	i.Values, err = memInfoParseOutput(output, i.RawByteSize, i.DisplayByteSize)
+	return err
 }
 
 func (i *MemInfoLinux) SetDriver(driver *driver.Driver) {
@@ -129,13 +131,13 @@ func (i MemInfoLinux) driverExec() driver.Command {
 	return (*i.Driver).ReadFile
 }
 
-func (i *MemInfoLinux) Execute() ([]byte, error) {
+func (i *MemInfoLinux) Execute() (*Result, error) {
 	output, err := i.driverExec()(i.FilePath)
 	if err == nil {
-		i.Parse(output)
-		return json.Marshal(i.Values)
+		err = i.Parse(output)
+		return newResult(i.Values, i.DisplayByteSize, err)
 	}
-	return []byte(""), err
+	return nil, err
 }
 
 func parseIntoNewByteSize(input string, displayBytes string) (int, error) {
@@ -145,8 +147,11 @@ func parseIntoNewByteSize(input string, displayBytes string) (int, error) {
 	unit := string(input[len(input)-1])
 	modified := strings.TrimSuffix(input, unit)
 	unit = fmt.Sprintf("%sB", unit)
-	byteSize := NewByteSize(modified, unit).format("MB")
-	return int(byteSize), nil
+	byteSize, err := NewByteSize(modified, unit)
+	if err != nil {
+		return 0, fmt.Errorf("could not parse %s into new byte size: %s", input, err)
+	}
+	return int(byteSize.format("MB")), nil
 }
 
 // Parse : parsing meminfo for Darwin command
@@ -154,38 +159,46 @@ func parseIntoNewByteSize(input string, displayBytes string) (int, error) {
 7552M 640M
 5120.00M 1194.00M
 */
-func (i *MemInfoDarwin) Parse(output string) {
+func (i *MemInfoDarwin) Parse(output string) error {
 	var (
 		err          error
 		memUnusedInt int
 		memUsedInt   int
 	)
 	rows := strings.Split(output, "\n")
+	if len(rows) < 2 {
+		return &ParseError{inspector: "MemInfoDarwin", content: fmt.Sprintf("expected memory and swap in %q", output)}
+	}
 	physMemRaw := rows[0]
 	swapRaw := rows[1]
 	physMemCols := strings.Fields(physMemRaw)
 	swapCols := strings.Fields(swapRaw)
+	if len(physMemCols) < 2 || len(swapCols) < 2 {
+		return &ParseError{inspector: "MemInfoDarwin", content: fmt.Sprintf("expected memory and swap in %q", output)}
+	}
 	memUsedInt, err = parseIntoNewByteSize(physMemCols[0], i.DisplayByteSize)
-	memUnusedInt, err = parseIntoNewByteSize(physMemCols[1], i.DisplayByteSize)
+	if err == nil {
+		memUnusedInt, err = parseIntoNewByteSize(physMemCols[1], i.DisplayByteSize)
+	}
 	if err != nil {
-		log.Errorf("Error parsing memory on MemInfoDarwin %e", err)
-	} else {
-		memTotal := fmt.Sprintf("%d", memUsedInt+memUnusedInt)
-		swapTotal := strings.TrimSuffix(swapCols[0], "M")
-		swapFree := strings.TrimSuffix(swapCols[1], "M")
-		//TODO: Figure out where to get cached size
-		i.Values = createMetric(
-			[]string{
-				memTotal,
-				fmt.Sprintf("%d", memUnusedInt),
-				`0`,
-				swapTotal,
-				swapFree,
-			},
-			i.RawByteSize,
-			i.DisplayByteSize,
-		)
+		return &ParseError{inspector: "MemInfoDarwin", content: err.Error()}
 	}
+	memTotal := fmt.Sprintf("%d", memUsedInt+memUnusedInt)
+	swapTotal := strings.TrimSuffix(swapCols[0], "M")
+	swapFree := strings.TrimSuffix(swapCols[1], "M")
+	//TODO: Figure out where to get cached size
+	i.Values, err = createMetric(
+		[]string{
+			memTotal,
+			fmt.Sprintf("%d", memUnusedInt),
+			`0`,
+			swapTotal,
+			swapFree,
+		},
+		i.RawByteSize,
+		i.DisplayByteSize,
+	)
+	return err
 }
 
 func (i *MemInfoDarwin) SetDriver(driver *driver.Driver) {
@@ -200,10 +213,10 @@ func (i MemInfoDarwin) driverExec() driver.Command {
 	return (*i.Driver).RunCommand
 }
 
-func (i *MemInfoDarwin) Execute() ([]byte, error) {
+func (i *MemInfoDarwin) Execute() (*Result, error) {
 	physMemOutput, err := i.driverExec()(i.PhysMemCommand)
 	if err != nil {
-		return []byte(""), err
+		return nil, err
 	}
 	swapOutput, err := i.driverExec()(i.SwapCommand)
 
@@ -211,10 +224,10 @@ func (i *MemInfoDarwin) Execute() ([]byte, error) {
 		physMemOutput = strings.TrimSuffix(physMemOutput, "\n")
 		swapOutput = strings.TrimSuffix(swapOutput, "\n")
 		output := fmt.Sprintf("%s\n%s", physMemOutput, swapOutput)
-		i.Parse(output)
-		return json.Marshal(i.Values)
+		err = i.Parse(output)
+		return newResult(i.Values, i.DisplayByteSize, err)
 	}
-	return []byte(""), err
+	return nil, err
 }
 
 // Parse : run custom parsing on output of the command
@@ -226,9 +239,17 @@ Virtual Memory: Available: 5,010 MB
 Virtual Memory: In Use:    14,061 MB
 5120         12288
 */
-func (i *MemInfoWin) Parse(output string) {
+func (i *MemInfoWin) Parse(output string) error {
 	log.Debug("Parsing output string in MemInfoWin inspector")
 	var cachesize, totalMem, freeMem, totalVirt, freeVirt int64
+	errs := newParseErrors("MemInfoWin")
+	parseInt := func(value string, name string) int64 {
+		parsed, err := strconv.ParseInt(value, 0, 64)
+		if err != nil {
+			errs.add("could not parse %s: %s", name, err)
+		}
+		return parsed
+	}
 	output = strings.ReplaceAll(output, ",", "")
 	output = strings.ReplaceAll(output, "MB", "")
 	lines := strings.Split(output, "\n")
@@ -236,22 +257,25 @@ func (i *MemInfoWin) Parse(output string) {
 		line := fmt.Sprintf("%s", lines[ind])
 		fields := strings.Fields(line)
 		fieldLen := len(fields)
+		if fieldLen == 0 {
+			continue
+		}
 		switch ind {
 		case 0:
-			totalMem, _ = strconv.ParseInt(fields[fieldLen-1], 0, 64)
+			totalMem = parseInt(fields[fieldLen-1], "total memory")
 		case 1:
-			freeMem, _ = strconv.ParseInt(fields[fieldLen-1], 0, 64)
+			freeMem = parseInt(fields[fieldLen-1], "free memory")
 		case 2:
-			totalVirt, _ = strconv.ParseInt(fields[fieldLen-1], 0, 64)
+			totalVirt = parseInt(fields[fieldLen-1], "total virtual memory")
 		case 3:
-			freeVirt, _ = strconv.ParseInt(fields[fieldLen-1], 0, 64)
+			freeVirt = parseInt(fields[fieldLen-1], "free virtual memory")
 		case 5:
 			// Last line is L2 and L3 CacheSize
 			// sometimes L3 is not shown like on CI
 			var l3 int64 = 0
-			l2, _ := strconv.ParseInt(fields[0], 0, 64)
+			l2 := parseInt(fields[0], "L2 cache size")
 			if fieldLen > 1 {
-				l3, _ = strconv.ParseInt(fields[1], 0, 64)
+				l3 = parseInt(fields[1], "L3 cache size")
 			}
 			cachesize = l2 + l3
 
@@ -260,12 +284,13 @@ func (i *MemInfoWin) Parse(output string) {
 	swapTotal := totalVirt - totalMem
 	swapFree := int((float64(freeVirt) / float64(totalVirt)) * float64(swapTotal))
 	i.Values = &MemInfoMetrics{
-		MemTotal:  NewByteSize(fmt.Sprintf("%d", totalMem), i.RawMemByteSize).format(i.DisplayByteSize),
-		MemFree:   NewByteSize(fmt.Sprintf("%d", freeMem), i.RawMemByteSize).format(i.DisplayByteSize),
-		Cached:    NewByteSize(fmt.Sprintf("%d", cachesize), i.RawCacheByteSize).format(i.DisplayByteSize),
-		SwapTotal: NewByteSize(fmt.Sprintf("%d", swapTotal), i.RawMemByteSize).format(i.DisplayByteSize),
-		SwapFree:  NewByteSize(fmt.Sprintf("%d", swapFree), i.RawMemByteSize).format(i.DisplayByteSize),
+		MemTotal:  byteSizeOf(float64(totalMem), i.RawMemByteSize).format(i.DisplayByteSize),
+		MemFree:   byteSizeOf(float64(freeMem), i.RawMemByteSize).format(i.DisplayByteSize),
+		Cached:    byteSizeOf(float64(cachesize), i.RawCacheByteSize).format(i.DisplayByteSize),
+		SwapTotal: byteSizeOf(float64(swapTotal), i.RawMemByteSize).format(i.DisplayByteSize),
+		SwapFree:  byteSizeOf(float64(swapFree), i.RawMemByteSize).format(i.DisplayByteSize),
 	}
+	return errs.err()
 }
 
 func (i *MemInfoWin) SetDriver(driver *driver.Driver) {
@@ -280,10 +305,10 @@ func (i MemInfoWin) driverExec() driver.Command {
 	return (*i.Driver).RunCommand
 }
 
-func (i *MemInfoWin) Execute() ([]byte, error) {
+func (i *MemInfoWin) Execute() (*Result, error) {
 	memOutput, err := i.driverExec()(i.MemCommand)
 	if err != nil {
-		return []byte(""), err
+		return nil, err
 	}
 	cacheOutput, err := i.driverExec()(i.CacheCommand)
 	if err == nil {
@@ -293,10 +318,10 @@ func (i *MemInfoWin) Execute() ([]byte, error) {
 		cacheOutputCols := strings.Split(cacheOutput, "\n")
 		cache := cacheOutputCols[1]
 		output := fmt.Sprintf("%s\n%s", memOutput, cache)
-		i.Parse(output)
-		return json.Marshal(i.Values)
+		err = i.Parse(output)
+		return newResult(i.Values, i.DisplayByteSize, err)
 	}
-	return []byte(""), err
+	return nil, err
 }
 
 // NewMemInfoLinux : Initialize a new MemInfoLinux instance
@@ -306,6 +331,14 @@ func NewMemInfo(driver *driver.Driver, _ ...string) (Inspector, error) {
 	if err != nil {
 		return nil, err
 	}
+	if details.IsNodeExporter {
+		meminfo = &MemInfoNodeExporter{
+			RawByteSize:     `B`,
+			DisplayByteSize: defaultDisplayByteSize,
+		}
+		meminfo.SetDriver(driver)
+		return meminfo, nil
+	}
 	if !(details.IsLinux || details.IsDarwin || details.IsWindows) {
 		return nil, errors.New("Cannot use MemInfo on drivers outside (linux, darwin, windows)")
 	}
@@ -313,14 +346,14 @@ func NewMemInfo(driver *driver.Driver, _ ...string) (Inspector, error) {
 		meminfo = &MemInfoLinux{
 			FilePath:        `/proc/meminfo`,
 			RawByteSize:     `KB`,
-			DisplayByteSize: `MB`,
+			DisplayByteSize: defaultDisplayByteSize,
 		}
 	} else if details.IsDarwin {
 		meminfo = &MemInfoDarwin{
 			PhysMemCommand:  `top -l 1 | grep PhysMem: | awk '{print $2, $6}'`,
 			SwapCommand:     `sysctl -n vm.swapusage | awk '{print $3, $9}'`,
 			RawByteSize:     `MB`,
-			DisplayByteSize: `MB`,
+			DisplayByteSize: defaultDisplayByteSize,
 		}
 	} else if details.IsWindows {
 		meminfo = &MemInfoWin{
@@ -328,7 +361,7 @@ func NewMemInfo(driver *driver.Driver, _ ...string) (Inspector, error) {
 			CacheCommand:     `wmic cpu get L2CacheSize, L3CacheSize`,
 			RawMemByteSize:   `MB`,
 			RawCacheByteSize: `B`,
-			DisplayByteSize:  `MB`,
+			DisplayByteSize:  defaultDisplayByteSize,
 		}
 	}
 	meminfo.SetDriver(driver)
@@ -0,0 +1,132 @@
+package inspector
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/bisohns/saido/driver"
+	log "github.com/sirupsen/logrus"
+)
+
+// NetworkMetrics : Metrics used by Network, counters are since boot
+type NetworkMetrics struct {
+	Interface string  `desc:"Name of the interface"`
+	RxBytes   float64 `unit:"bytes" kind:"counter" desc:"Bytes received"`
+	TxBytes   float64 `unit:"bytes" kind:"counter" desc:"Bytes transmitted"`
+	RxRate    float64 `unit:"bytes/s" desc:"Bytes received per second since the last poll"`
+	TxRate    float64 `unit:"bytes/s" desc:"Bytes transmitted per second since the last poll"`
+	RxPackets uint64  `kind:"counter" desc:"Packets received"`
+	TxPackets uint64  `kind:"counter" desc:"Packets transmitted"`
+	RxErrors  uint64  `kind:"counter" desc:"Receive errors"`
+	TxErrors  uint64  `kind:"counter" desc:"Transmit errors"`
+	RxDropped uint64  `kind:"counter" desc:"Received packets dropped"`
+	TxDropped uint64  `kind:"counter" desc:"Transmitted packets dropped"`
+}
+
+// NetworkLinux : Parsing the /proc/net/dev output for interface counters
+type NetworkLinux struct {
+	Driver   *driver.Driver
+	FilePath string
+	// The values read from the file are in B
+	RawByteSize string
+	// We want do display traffic in MB
+	DisplayByteSize string
+	Values          []NetworkMetrics
+	rates           *rates
+	hostState
+}
+
+// Parse : run custom parsing on output of the command
+/*
+Inter-|   Receive                                                |  Transmit
+ face |bytes    packets errs drop fifo frame compressed multicast|bytes    packets errs drop fifo colls carrier compressed
+    lo:  105736    1160    0    0    0     0          0         0   105736    1160    0    0    0     0       0          0
+  eth0: 6398497   10236    0    2    0     0          0         0  1012475    7655    0    0    0     0       0          0
+*/
+func (i *NetworkLinux) Parse(output string) error {
+	log.Debug("Parsing output string in NetworkLinux inspector")
+	values := []NetworkMetrics{}
+	errs := newParseErrors("NetworkLinux")
+	for _, line := range strings.Split(output, "\n") {
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		columns := strings.Fields(parts[1])
+		if len(columns) < 12 {
+			continue
+		}
+		counters := make([]uint64, len(columns))
+		for index, column := range columns {
+			counter, err := strconv.ParseUint(column, 10, 64)
+			if err != nil {
+				errs.add("could not parse counters of %s: %s", strings.TrimSpace(parts[0]), err)
+			}
+			counters[index] = counter
+		}
+		name := strings.TrimSpace(parts[0])
+		values = append(values, NetworkMetrics{
+			Interface: name,
+			RxBytes:   byteSizeOf(float64(counters[0]), i.RawByteSize).format(i.DisplayByteSize),
+			TxBytes:   byteSizeOf(float64(counters[8]), i.RawByteSize).format(i.DisplayByteSize),
+			RxRate:    byteSizeOf(i.rates.rate(name+`.rx`, float64(counters[0])), i.RawByteSize).format(i.DisplayByteSize),
+			TxRate:    byteSizeOf(i.rates.rate(name+`.tx`, float64(counters[8])), i.RawByteSize).format(i.DisplayByteSize),
+			RxPackets: counters[1],
+			TxPackets: counters[9],
+			RxErrors:  counters[2],
+			TxErrors:  counters[10],
+			RxDropped: counters[3],
+			TxDropped: counters[11],
+		})
+	}
+	i.Values = values
+	return errs.err()
+}
+
+func (i *NetworkLinux) SetDriver(driver *driver.Driver) {
+	details, _ := (*driver).GetDetails()
+	if !details.IsLinux {
+		panic("Cannot use NetworkLinux on drivers outside (linux)")
+	}
+	i.Driver = driver
+}
+
+func (i NetworkLinux) driverExec() driver.Command {
+	return (*i.Driver).ReadFile
+}
+
+func (i *NetworkLinux) Execute() (*Result, error) {
+	output, err := i.driverExec()(i.FilePath)
+	if err == nil {
+		i.rates = newRates(i.stateKey)
+		err = i.Parse(output)
+		return newResult(i.Values, i.DisplayByteSize, err)
+	}
+	return nil, err
+}
+
+// NewNetwork : Initialize a new Network instance
+func NewNetwork(driver *driver.Driver, _ ...string) (Inspector, error) {
+	var network Inspector
+	details, err := (*driver).GetDetails()
+	if err != nil {
+		return nil, err
+	}
+	if details.IsNodeExporter {
+		network = &NetworkNodeExporter{
+			RawByteSize:     `B`,
+			DisplayByteSize: defaultDisplayByteSize,
+		}
+	} else if details.IsLinux {
+		network = &NetworkLinux{
+			FilePath:        `/proc/net/dev`,
+			RawByteSize:     `B`,
+			DisplayByteSize: defaultDisplayByteSize,
+		}
+	} else {
+		return nil, errors.New("Cannot use Network on drivers outside (linux, node_exporter)")
+	}
+	network.SetDriver(driver)
+	return network, nil
+}
@@ -0,0 +1,39 @@
+package inspector
+
+import (
+	"testing"
+)
+
+func TestNetworkLinuxParse(t *testing.T) {
+	i := &NetworkLinux{RawByteSize: `B`, DisplayByteSize: `KB`}
+	i.Parse(`Inter-|   Receive                                                |  Transmit
+ face |bytes    packets errs drop fifo frame compressed multicast|bytes    packets errs drop fifo colls carrier compressed
+    lo:  102400    1160    0    0    0     0          0         0   102400    1160    0    0    0     0       0          0
+  eth0: 2048   10236    3    2    0     0          0         0  1024    7655    4    5    0     0       0          0
+`)
+	if len(i.Values) != 2 {
+		t.Fatalf("Expected 2 interfaces, found %d", len(i.Values))
+	}
+	eth0 := i.Values[1]
+	if eth0.Interface != "eth0" || eth0.RxBytes != 2 || eth0.TxBytes != 1 {
+		t.Errorf("Unexpected bytes %v", eth0)
+	}
+	if eth0.RxPackets != 10236 || eth0.TxPackets != 7655 || eth0.RxErrors != 3 || eth0.TxErrors != 4 || eth0.RxDropped != 2 || eth0.TxDropped != 5 {
+		t.Errorf("Unexpected counters %v", eth0)
+	}
+}
+
+func TestCPULinuxParse(t *testing.T) {
+	i := &CPULinux{ClockTicks: 100}
+	i.Parse(`cpu  3000 0 1000 5000 1000 0 0 0 0 0
+cpu0 1500 0 500 2500 500 0 0 0 0 0
+cpu1 1500 0 500 2500 500 0 0 0 0 0
+intr 1462898
+`)
+	if i.Values.Cores != 2 || i.Values.User != 30 || i.Values.Idle != 50 || i.Values.IOWait != 10 {
+		t.Errorf("Unexpected cpu times %v", i.Values)
+	}
+	if i.Values.UsagePercent != 40 {
+		t.Errorf("Expected 40%% usage, found %f", i.Values.UsagePercent)
+	}
+}
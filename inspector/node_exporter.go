@@ -0,0 +1,326 @@
+package inspector
+
+import (
+	"math"
+	"sort"
+
+	"github.com/bisohns/saido/driver"
+	log "github.com/sirupsen/logrus"
+)
+
+// bytesFrom : format byte value of a series into display unit
+func bytesFrom(value float64, rawByteSize string, displayByteSize string) float64 {
+	return byteSizeOf(value, rawByteSize).format(displayByteSize)
+}
+
+// MemInfoNodeExporter : Reading node_memory_* series of node_exporter
+type MemInfoNodeExporter struct {
+	Driver *driver.Driver
+	// Unit of the values as the exporter reports them (bytes)
+	RawByteSize string
+	// Unit the values are converted to before being sent
+	DisplayByteSize string
+	// Values of metrics being read
+	Values *MemInfoMetrics
+}
+
+// Parse : linux exporters report /proc/meminfo fields while darwin
+// exporters report totals and usage
+func (i *MemInfoNodeExporter) Parse(output string) error {
+	log.Debug("Parsing output string in MemInfoNodeExporter inspector")
+	samples := parsePrometheus(output)
+	get := func(name string) float64 {
+		value, _ := samples.value(name, nil)
+		return value
+	}
+	metric := func(value float64) float64 {
+		return bytesFrom(value, i.RawByteSize, i.DisplayByteSize)
+	}
+	if _, ok := samples.value("node_memory_MemTotal_bytes", nil); ok {
+		i.Values = &MemInfoMetrics{
+			MemTotal:  metric(get("node_memory_MemTotal_bytes")),
+			MemFree:   metric(get("node_memory_MemFree_bytes")),
+			Cached:    metric(get("node_memory_Cached_bytes")),
+			SwapTotal: metric(get("node_memory_SwapTotal_bytes")),
+			SwapFree:  metric(get("node_memory_SwapFree_bytes")),
+		}
+		return nil
+	}
+	swapTotal := get("node_memory_swap_total_bytes")
+	i.Values = &MemInfoMetrics{
+		MemTotal:  metric(get("node_memory_total_bytes")),
+		MemFree:   metric(get("node_memory_free_bytes")),
+		SwapTotal: metric(swapTotal),
+		SwapFree:  metric(swapTotal - get("node_memory_swap_used_bytes")),
+	}
+	return nil
+}
+
+func (i *MemInfoNodeExporter) SetDriver(driver *driver.Driver) {
+	i.Driver = driver
+}
+
+func (i MemInfoNodeExporter) driverExec() driver.Command {
+	return scrapeNodeExporter(i.Driver)
+}
+
+func (i *MemInfoNodeExporter) Execute() (*Result, error) {
+	output, err := i.driverExec()(``)
+	if err == nil {
+		err = i.Parse(output)
+		return newResult(i.Values, i.DisplayByteSize, err)
+	}
+	return nil, err
+}
+
+// LoadAvgNodeExporter : Reading node_load* series of node_exporter
+type LoadAvgNodeExporter struct {
+	Driver *driver.Driver
+	Values *LoadAvgMetrics
+}
+
+func (i *LoadAvgNodeExporter) Parse(output string) error {
+	log.Debug("Parsing output string in LoadAvgNodeExporter inspector")
+	samples := parsePrometheus(output)
+	load1, _ := samples.value("node_load1", nil)
+	load5, _ := samples.value("node_load5", nil)
+	load15, _ := samples.value("node_load15", nil)
+	i.Values = &LoadAvgMetrics{
+		Load1M:  load1,
+		Load5M:  load5,
+		Load15M: load15,
+	}
+	return nil
+}
+
+func (i *LoadAvgNodeExporter) SetDriver(driver *driver.Driver) {
+	i.Driver = driver
+}
+
+func (i LoadAvgNodeExporter) driverExec() driver.Command {
+	return scrapeNodeExporter(i.Driver)
+}
+
+func (i *LoadAvgNodeExporter) Execute() (*Result, error) {
+	output, err := i.driverExec()(``)
+	if err == nil {
+		err = i.Parse(output)
+		return newResult(i.Values, ``, err)
+	}
+	return nil, err
+}
+
+// DFNodeExporter : Reading node_filesystem_* series of node_exporter
+type DFNodeExporter struct {
+	Driver *driver.Driver
+	// Unit of the values as the exporter reports them (bytes)
+	RawByteSize string
+	// Unit the values are converted to before being sent
+	DisplayByteSize string
+	// Values of metrics being read
+	Values []DFMetrics
+}
+
+// Parse : used space is computed the same way as `df` where space
+// reserved for root is neither used nor available
+func (i *DFNodeExporter) Parse(output string) error {
+	log.Debug("Parsing output string in DFNodeExporter inspector")
+	samples := parsePrometheus(output)
+	values := []DFMetrics{}
+	for _, size := range samples["node_filesystem_size_bytes"] {
+		labels := map[string]string{
+			"device":     size.Labels["device"],
+			"mountpoint": size.Labels["mountpoint"],
+		}
+		free, _ := samples.value("node_filesystem_free_bytes", labels)
+		available, _ := samples.value("node_filesystem_avail_bytes", labels)
+		used := size.Value - free
+		percent := 0
+		if used+available > 0 {
+			percent = int(math.Ceil(used / (used + available) * 100))
+		}
+		values = append(values, DFMetrics{
+			FileSystem:  size.Labels["device"],
+			Size:        bytesFrom(size.Value, i.RawByteSize, i.DisplayByteSize),
+			Used:        bytesFrom(used, i.RawByteSize, i.DisplayByteSize),
+			Available:   bytesFrom(available, i.RawByteSize, i.DisplayByteSize),
+			PercentFull: percent,
+		})
+	}
+	i.Values = values
+	return nil
+}
+
+func (i *DFNodeExporter) SetDriver(driver *driver.Driver) {
+	i.Driver = driver
+}
+
+func (i DFNodeExporter) driverExec() driver.Command {
+	return scrapeNodeExporter(i.Driver)
+}
+
+func (i *DFNodeExporter) Execute() (*Result, error) {
+	output, err := i.driverExec()(``)
+	if err == nil {
+		err = i.Parse(output)
+		return newResult(i.Values, i.DisplayByteSize, err)
+	}
+	return nil, err
+}
+
+// UptimeNodeExporter : Reading boot time and cpu seconds of node_exporter
+type UptimeNodeExporter struct {
+	Driver *driver.Driver
+	Values *UptimeMetrics
+}
+
+// cpuCount : number of distinct cpus in node_cpu_seconds_total
+func cpuCount(samples promSamples) int {
+	cpus := make(map[string]bool)
+	for _, sample := range samples["node_cpu_seconds_total"] {
+		cpus[sample.Labels["cpu"]] = true
+	}
+	return len(cpus)
+}
+
+func (i *UptimeNodeExporter) Parse(output string) error {
+	log.Debug("Parsing output string in UptimeNodeExporter inspector")
+	samples := parsePrometheus(output)
+	now, _ := samples.value("node_time_seconds", nil)
+	boot, _ := samples.value("node_boot_time_seconds", nil)
+	// idle is summed over processors same as /proc/uptime
+	idle := samples.sum("node_cpu_seconds_total", map[string]string{"mode": "idle"})
+	metrics := &UptimeMetrics{
+		Up:   now - boot,
+		Idle: idle,
+	}
+	if cpus := cpuCount(samples); cpus > 0 && metrics.Up > 0 {
+		metrics.IdlePercent = idle / (metrics.Up * float64(cpus)) * 100
+	}
+	i.Values = metrics
+	return nil
+}
+
+func (i *UptimeNodeExporter) SetDriver(driver *driver.Driver) {
+	i.Driver = driver
+}
+
+func (i UptimeNodeExporter) driverExec() driver.Command {
+	return scrapeNodeExporter(i.Driver)
+}
+
+func (i *UptimeNodeExporter) Execute() (*Result, error) {
+	output, err := i.driverExec()(``)
+	if err == nil {
+		err = i.Parse(output)
+		return newResult(i.Values, ``, err)
+	}
+	return nil, err
+}
+
+// CPUNodeExporter : Reading node_cpu_seconds_total of node_exporter
+type CPUNodeExporter struct {
+	Driver *driver.Driver
+	Values *CPUMetrics
+	rates  *rates
+	hostState
+}
+
+func (i *CPUNodeExporter) Parse(output string) error {
+	log.Debug("Parsing output string in CPUNodeExporter inspector")
+	samples := parsePrometheus(output)
+	mode := func(name string) float64 {
+		return samples.sum("node_cpu_seconds_total", map[string]string{"mode": name})
+	}
+	i.Values = newCPUMetrics(cpuCount(samples), map[string]float64{
+		"user":    mode("user"),
+		"nice":    mode("nice"),
+		"system":  mode("system"),
+		"idle":    mode("idle"),
+		"iowait":  mode("iowait"),
+		"irq":     mode("irq"),
+		"softirq": mode("softirq"),
+		"steal":   mode("steal"),
+	}, i.rates)
+	return nil
+}
+
+func (i *CPUNodeExporter) SetDriver(driver *driver.Driver) {
+	i.Driver = driver
+}
+
+func (i CPUNodeExporter) driverExec() driver.Command {
+	return scrapeNodeExporter(i.Driver)
+}
+
+func (i *CPUNodeExporter) Execute() (*Result, error) {
+	output, err := i.driverExec()(``)
+	if err == nil {
+		i.rates = newRates(i.stateKey)
+		err = i.Parse(output)
+		return newResult(i.Values, ``, err)
+	}
+	return nil, err
+}
+
+// NetworkNodeExporter : Reading node_network_* series of node_exporter
+type NetworkNodeExporter struct {
+	Driver *driver.Driver
+	// Unit of the values as the exporter reports them (bytes)
+	RawByteSize string
+	// Unit the values are converted to before being sent
+	DisplayByteSize string
+	Values          []NetworkMetrics
+	rates           *rates
+	hostState
+}
+
+func (i *NetworkNodeExporter) Parse(output string) error {
+	log.Debug("Parsing output string in NetworkNodeExporter inspector")
+	samples := parsePrometheus(output)
+	values := []NetworkMetrics{}
+	for _, received := range samples["node_network_receive_bytes_total"] {
+		device := map[string]string{"device": received.Labels["device"]}
+		counter := func(name string) uint64 {
+			value, _ := samples.value(name, device)
+			return uint64(value)
+		}
+		transmitted, _ := samples.value("node_network_transmit_bytes_total", device)
+		values = append(values, NetworkMetrics{
+			Interface: received.Labels["device"],
+			RxBytes:   bytesFrom(received.Value, i.RawByteSize, i.DisplayByteSize),
+			TxBytes:   bytesFrom(transmitted, i.RawByteSize, i.DisplayByteSize),
+			RxRate:    bytesFrom(i.rates.rate(device["device"]+`.rx`, received.Value), i.RawByteSize, i.DisplayByteSize),
+			TxRate:    bytesFrom(i.rates.rate(device["device"]+`.tx`, transmitted), i.RawByteSize, i.DisplayByteSize),
+			RxPackets: counter("node_network_receive_packets_total"),
+			TxPackets: counter("node_network_transmit_packets_total"),
+			RxErrors:  counter("node_network_receive_errs_total"),
+			TxErrors:  counter("node_network_transmit_errs_total"),
+			RxDropped: counter("node_network_receive_drop_total"),
+			TxDropped: counter("node_network_transmit_drop_total"),
+		})
+	}
+	sort.Slice(values, func(a, b int) bool {
+		return values[a].Interface < values[b].Interface
+	})
+	i.Values = values
+	return nil
+}
+
+func (i *NetworkNodeExporter) SetDriver(driver *driver.Driver) {
+	i.Driver = driver
+}
+
+func (i NetworkNodeExporter) driverExec() driver.Command {
+	return scrapeNodeExporter(i.Driver)
+}
+
+func (i *NetworkNodeExporter) Execute() (*Result, error) {
+	output, err := i.driverExec()(``)
+	if err == nil {
+		i.rates = newRates(i.stateKey)
+		err = i.Parse(output)
+		return newResult(i.Values, i.DisplayByteSize, err)
+	}
+	return nil, err
+}
@@ -0,0 +1,122 @@
+package inspector
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/bisohns/saido/driver"
+)
+
+const fakeNodeExporterMetrics = `# HELP node_load1 1m load average.
+# TYPE node_load1 gauge
+node_load1 0.5
+node_load5 0.25
+node_load15 0.125
+node_memory_MemTotal_bytes 2.147483648e+09
+node_memory_MemFree_bytes 1.073741824e+09
+node_memory_Cached_bytes 5.36870912e+08
+node_memory_SwapTotal_bytes 1.073741824e+09
+node_memory_SwapFree_bytes 1.073741824e+09
+node_filesystem_size_bytes{device="/dev/sda1",fstype="ext4",mountpoint="/"} 1.073741824e+10
+node_filesystem_free_bytes{device="/dev/sda1",fstype="ext4",mountpoint="/"} 5.36870912e+09
+node_filesystem_avail_bytes{device="/dev/sda1",fstype="ext4",mountpoint="/"} 4.294967296e+09
+node_time_seconds 1.7e+09
+node_boot_time_seconds 1.6999e+09
+node_cpu_seconds_total{cpu="0",mode="idle"} 80000
+node_cpu_seconds_total{cpu="0",mode="user"} 15000
+node_cpu_seconds_total{cpu="0",mode="system"} 5000
+node_cpu_seconds_total{cpu="1",mode="idle"} 90000
+node_cpu_seconds_total{cpu="1",mode="user"} 10000
+node_network_receive_bytes_total{device="eth0"} 2.097152e+06
+node_network_transmit_bytes_total{device="eth0"} 1.048576e+06
+node_network_receive_packets_total{device="eth0"} 300
+node_network_transmit_packets_total{device="eth0"} 200
+node_network_receive_errs_total{device="eth0"} 1
+node_network_receive_bytes_total{device="lo"} 1024
+node_uname_info{domainname="(none)",machine="x86_64",nodename="web-1",release="6.1.0",sysname="Linux",version="#1 SMP"} 1
+`
+
+func newFakeNodeExporter(t *testing.T) driver.Driver {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, fakeNodeExporterMetrics)
+	}))
+	t.Cleanup(server.Close)
+	host, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+	portNum, _ := strconv.Atoi(port)
+	return &driver.NodeExporter{Host: host, Port: portNum}
+}
+
+func TestParsePrometheus(t *testing.T) {
+	samples := parsePrometheus(`metric{a="x\"y",b="1"} 2 1700000000000
+metric{a="z"} NaN
+other 3.5`)
+	if value, ok := samples.value("metric", map[string]string{"a": `x"y`}); !ok || value != 2 {
+		t.Errorf("Expected escaped label to match, found %f", value)
+	}
+	if value, _ := samples.value("other", nil); value != 3.5 {
+		t.Errorf("Expected sample without labels, found %f", value)
+	}
+	if len(samples["metric"]) != 2 {
+		t.Errorf("Expected 2 samples, found %d", len(samples["metric"]))
+	}
+}
+
+func TestNodeExporterInspectors(t *testing.T) {
+	d := newFakeNodeExporter(t)
+	details, err := d.GetDetails()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !details.IsNodeExporter || details.IsLinux || details.Name != "Linux" {
+		t.Errorf("Unexpected details %v", details)
+	}
+
+	i, _ := NewMemInfo(&d)
+	if _, err = i.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	mem := i.(*MemInfoNodeExporter).Values
+	if mem.MemTotal != 2048 || mem.MemFree != 1024 || mem.Cached != 512 || mem.SwapFree != 1024 {
+		t.Errorf("Unexpected memory %v", mem)
+	}
+
+	i, _ = NewLoadAvg(&d)
+	i.Execute()
+	if load := i.(*LoadAvgNodeExporter).Values; load.Load1M != 0.5 || load.Load15M != 0.125 {
+		t.Errorf("Unexpected load %v", load)
+	}
+
+	i, _ = NewDF(&d)
+	i.Execute()
+	disks := i.(*DFNodeExporter).Values
+	if len(disks) != 1 || disks[0].FileSystem != "/dev/sda1" || disks[0].Used != 5120 || disks[0].PercentFull != 56 {
+		t.Errorf("Unexpected disks %v", disks)
+	}
+
+	i, _ = NewUptime(&d)
+	i.Execute()
+	if uptime := i.(*UptimeNodeExporter).Values; uptime.Up != 100000 || uptime.Idle != 170000 || uptime.IdlePercent != 85 {
+		t.Errorf("Unexpected uptime %v", uptime)
+	}
+
+	i, _ = NewCPU(&d)
+	i.Execute()
+	if cpu := i.(*CPUNodeExporter).Values; cpu.Cores != 2 || cpu.User != 25000 || cpu.UsagePercent != 15 {
+		t.Errorf("Unexpected cpu %v", cpu)
+	}
+
+	i, _ = NewNetwork(&d)
+	i.Execute()
+	network := i.(*NetworkNodeExporter).Values
+	if len(network) != 2 || network[0].Interface != "eth0" || network[0].RxBytes != 2 || network[0].RxPackets != 300 || network[0].RxErrors != 1 {
+		t.Errorf("Unexpected network %v", network)
+	}
+
+	if _, err = NewProcess(&d); err == nil {
+		t.Error("Expected process to be unsupported on node_exporter")
+	}
+}
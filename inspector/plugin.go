@@ -0,0 +1,265 @@
+package inspector
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/bisohns/saido/config"
+	"github.com/bisohns/saido/driver"
+	log "github.com/sirupsen/logrus"
+)
+
+// PluginRequest : first line written to a plugin, describe asks for its
+// schema and execute for its values on a host
+type PluginRequest struct {
+	Action string `json:"action"`
+	// Platform and Details of the host on execute
+	Platform string                `json:"platform,omitempty"`
+	Details  *driver.SystemDetails `json:"details,omitempty"`
+	// Args of the metric in config
+	Args string `json:"args,omitempty"`
+}
+
+// PluginMessage : line written by a plugin, either a Command to run or
+// File to read on the host which is answered by a PluginOutput, or the
+// reply to the request
+type PluginMessage struct {
+	Command string `json:"command,omitempty"`
+	File    string `json:"file,omitempty"`
+	// Reply to describe
+	Description string   `json:"description,omitempty"`
+	Platforms   []string `json:"platforms,omitempty"`
+	List        bool     `json:"list,omitempty"`
+	Fields      []Field  `json:"fields,omitempty"`
+	// Reply to execute, an Error along with Values is a parse error
+	Values json.RawMessage `json:"values,omitempty"`
+	Error  string          `json:"error,omitempty"`
+}
+
+// PluginOutput : answer to a Command or File of a plugin
+type PluginOutput struct {
+	Output string `json:"output"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Plugin : inspector run as a subprocess on every poll so a plugin that
+// crashes or hangs fails only its own metric
+type Plugin struct {
+	Driver     *driver.Driver
+	Definition config.PluginDefinition
+	Args       string
+	// units of the fields described by the plugin
+	units  map[string]string
+	Values interface{}
+}
+
+// Parse : decode values written by the plugin
+func (i *Plugin) Parse(output string) error {
+	log.Debugf("Parsing output string in %s plugin", i.Definition.Name)
+	var values interface{}
+	if err := json.Unmarshal([]byte(output), &values); err != nil {
+		return &ParseError{inspector: i.Definition.Name, content: err.Error()}
+	}
+	i.Values = values
+	return nil
+}
+
+func (i *Plugin) SetDriver(driver *driver.Driver) {
+	i.Driver = driver
+}
+
+func (i Plugin) driverExec() driver.Command {
+	return (*i.Driver).RunCommand
+}
+
+func (i *Plugin) Execute() (*Result, error) {
+	details, err := (*i.Driver).GetDetails()
+	if err != nil {
+		return nil, err
+	}
+	reply, err := runPlugin(i.Definition, PluginRequest{
+		Action:   "execute",
+		Platform: platformOf(details),
+		Details:  &details,
+		Args:     i.Args,
+	}, i.serve)
+	if err != nil {
+		return nil, err
+	}
+	if len(reply.Values) == 0 {
+		if reply.Error == `` {
+			reply.Error = "no values"
+		}
+		return nil, fmt.Errorf("Plugin %s failed: %s", i.Definition.Name, reply.Error)
+	}
+	err = i.Parse(string(reply.Values))
+	if err == nil && reply.Error != `` {
+		err = &ParseError{inspector: i.Definition.Name, content: reply.Error}
+	}
+	return &Result{Units: i.units, Values: i.Values}, err
+}
+
+// serve : run a command or read a file on the host for the plugin
+func (i *Plugin) serve(message PluginMessage) PluginOutput {
+	var (
+		output string
+		err    error
+	)
+	if message.Command != `` {
+		output, err = i.driverExec()(message.Command)
+	} else {
+		output, err = (*i.Driver).ReadFile(message.File)
+	}
+	if err != nil {
+		return PluginOutput{Output: output, Error: err.Error()}
+	}
+	return PluginOutput{Output: output}
+}
+
+// runPlugin : start the plugin, write request then answer its commands
+// and files with serve until it replies or times out
+func runPlugin(plugin config.PluginDefinition, request PluginRequest, serve func(PluginMessage) PluginOutput) (*PluginMessage, error) {
+	timeout := time.Duration(plugin.Timeout) * time.Second
+	cmd := exec.Command(plugin.Command, plugin.Args...)
+	startProcessGroup(cmd)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("Could not start plugin %s: %s", plugin.Name, err)
+	}
+	// processes started by the plugin keep its stdout open after it is
+	// killed, so its whole group is killed and stdout closed on timeout
+	timer := time.AfterFunc(timeout, func() {
+		killProcessGroup(cmd)
+		stdout.Close()
+	})
+	reply, err := exchange(stdin, stdout, request, serve)
+	stdin.Close()
+	if err == nil {
+		timer.Stop()
+		// stop plugins that keep running after replying
+		killProcessGroup(cmd)
+		cmd.Wait()
+		return reply, nil
+	}
+	waitErr := cmd.Wait()
+	if !timer.Stop() {
+		return nil, fmt.Errorf("Plugin %s timed out after %s", plugin.Name, timeout)
+	}
+	if waitErr != nil {
+		err = waitErr
+	}
+	if message := strings.TrimSpace(stderr.String()); message != `` {
+		return nil, fmt.Errorf("Plugin %s failed: %s: %s", plugin.Name, err, message)
+	}
+	return nil, fmt.Errorf("Plugin %s failed: %s", plugin.Name, err)
+}
+
+// exchange : JSON lines of a run of a plugin
+func exchange(stdin io.Writer, stdout io.Reader, request PluginRequest, serve func(PluginMessage) PluginOutput) (*PluginMessage, error) {
+	encoder := json.NewEncoder(stdin)
+	if err := encoder.Encode(request); err != nil {
+		return nil, err
+	}
+	scanner := bufio.NewScanner(stdout)
+	// values and file contents can be larger than the default of 64KB
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var message PluginMessage
+		if err := json.Unmarshal(scanner.Bytes(), &message); err != nil {
+			return nil, fmt.Errorf("invalid message %q: %s", scanner.Text(), err)
+		}
+		if message.Command == `` && message.File == `` {
+			return &message, nil
+		}
+		if serve == nil {
+			return nil, errors.New("cannot run commands or read files when describing")
+		}
+		if err := encoder.Encode(serve(message)); err != nil {
+			return nil, err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, errors.New("exited without a reply")
+}
+
+// NewPlugin : Initialize a run of a plugin on driver
+func NewPlugin(driver *driver.Driver, definition config.PluginDefinition, platforms []string, units map[string]string, custom ...string) (Inspector, error) {
+	details, err := (*driver).GetDetails()
+	if err != nil {
+		return nil, err
+	}
+	supported := false
+	for _, platform := range platforms {
+		supported = supported || platform == platformOf(details)
+	}
+	if !supported {
+		return nil, fmt.Errorf("Cannot use %s on drivers outside (%s)", definition.Name, strings.Join(platforms, ", "))
+	}
+	plugin := &Plugin{
+		Definition: definition,
+		units:      units,
+	}
+	if len(custom) > 0 {
+		plugin.Args = custom[0]
+	}
+	plugin.SetDriver(driver)
+	return plugin, nil
+}
+
+// RegisterPlugin : describe a plugin and add it to the inspectors that
+// can be initialized, must be called before polling starts
+func RegisterPlugin(definition config.PluginDefinition) error {
+	name := definition.Name
+	if name == `` {
+		return errors.New("Must specify name of plugins")
+	}
+	if _, ok := inspectorMap[name]; ok || registryName(name) != name {
+		return fmt.Errorf("Cannot register plugin %s as the name is taken", name)
+	}
+	described, err := runPlugin(definition, PluginRequest{Action: "describe"}, nil)
+	if err != nil {
+		return err
+	}
+	if described.Error != `` {
+		return fmt.Errorf("Plugin %s failed to describe itself: %s", name, described.Error)
+	}
+	units := make(map[string]string)
+	for _, field := range described.Fields {
+		if field.Unit != `` {
+			units[field.Name] = field.Unit
+		}
+	}
+	// plugins run on hosts with commands unless told otherwise
+	platforms := described.Platforms
+	if len(platforms) == 0 {
+		platforms = systemPlatforms
+	}
+	inspectorMap[name] = registration{
+		New: func(driver *driver.Driver, custom ...string) (Inspector, error) {
+			return NewPlugin(driver, definition, platforms, units, custom...)
+		},
+		Description: described.Description,
+		Platforms:   platforms,
+		List:        described.List,
+		Fields:      append([]Field{}, described.Fields...),
+	}
+	return nil
+}
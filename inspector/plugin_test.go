@@ -0,0 +1,124 @@
+package inspector
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bisohns/saido/config"
+	"github.com/bisohns/saido/driver"
+)
+
+// TestPluginProcess : not a test, the plugin run by plugin tests in the
+// mode set by SAIDO_TEST_PLUGIN
+func TestPluginProcess(t *testing.T) {
+	mode := os.Getenv("SAIDO_TEST_PLUGIN")
+	if mode == `` {
+		return
+	}
+	defer os.Exit(0)
+	reader := bufio.NewReader(os.Stdin)
+	line, _ := reader.ReadString('\n')
+	var request PluginRequest
+	json.Unmarshal([]byte(line), &request)
+	switch {
+	case mode == "crash":
+		fmt.Fprintln(os.Stderr, "plugin crashed")
+		os.Exit(2)
+	case mode == "hang":
+		time.Sleep(time.Minute)
+	case mode == "hang-in-child":
+		// the child keeps stdout open once the plugin is killed
+		child := exec.Command("sleep", "60")
+		child.Stdout = os.Stdout
+		child.Start()
+		time.Sleep(time.Minute)
+	case request.Action == "describe":
+		fmt.Println(`{"description": "Users logged in", "platforms": ["linux"], "list": true, ` +
+			`"fields": [{"name": "user", "type": "string"}, {"name": "idle", "type": "number", "unit": "s"}]}`)
+	default:
+		fmt.Println(`{"command": "who"}`)
+		line, _ = reader.ReadString('\n')
+		var output PluginOutput
+		json.Unmarshal([]byte(line), &output)
+		reply := map[string]interface{}{}
+		rows := []map[string]interface{}{}
+		for _, user := range strings.Fields(output.Output) {
+			rows = append(rows, map[string]interface{}{"user": user, "idle": 0, "args": request.Args})
+		}
+		reply["values"] = rows
+		if mode == "partial" {
+			reply["error"] = "could not parse idle"
+		}
+		json.NewEncoder(os.Stdout).Encode(reply)
+	}
+}
+
+func testPlugin(t *testing.T, mode string) config.PluginDefinition {
+	t.Setenv("SAIDO_TEST_PLUGIN", mode)
+	return config.PluginDefinition{
+		Name:    "users",
+		Command: os.Args[0],
+		Args:    []string{"-test.run=^TestPluginProcess$"},
+		Timeout: 1,
+	}
+}
+
+func TestPlugin(t *testing.T) {
+	if err := RegisterPlugin(testPlugin(t, "ok")); err != nil {
+		t.Fatal(err)
+	}
+	defer delete(inspectorMap, "users")
+	schema, ok := GetSchema("users")
+	if !ok || !schema.List || schema.Fields[1].Unit != "s" || schema.Platforms[0] != "linux" {
+		t.Fatalf("Unexpected schema %+v", schema)
+	}
+	var d driver.Driver = &fakeProcesses{output: "root\napp\n"}
+	i, err := Init("users", &d, "all")
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := i.Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows := result.Values.([]interface{})
+	if len(rows) != 2 || rows[1].(map[string]interface{})["user"] != "app" || result.Units["idle"] != "s" {
+		t.Errorf("Unexpected values %+v", result)
+	}
+	if args := rows[0].(map[string]interface{})["args"]; args != "all" {
+		t.Errorf("Expected args to be sent to the plugin, found %v", args)
+	}
+
+	t.Setenv("SAIDO_TEST_PLUGIN", "partial")
+	if result, err := i.Execute(); result == nil || err == nil {
+		t.Errorf("Expected values along with a parse error, found %v", err)
+	}
+	t.Setenv("SAIDO_TEST_PLUGIN", "crash")
+	if _, err := i.Execute(); err == nil || !strings.Contains(err.Error(), "plugin crashed") {
+		t.Errorf("Expected crash to be reported, found %v", err)
+	}
+	var dns driver.Driver = &fakeDNS{}
+	if _, err := Init("users", &dns); err == nil {
+		t.Error("Expected error for unsupported platform")
+	}
+}
+
+func TestPluginTimeout(t *testing.T) {
+	start := time.Now()
+	err := RegisterPlugin(testPlugin(t, "hang"))
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("Expected timeout, found %v", err)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Errorf("Expected plugin to be stopped after its timeout, took %s", time.Since(start))
+	}
+	if Valid("users") {
+		t.Error("Expected plugin failing to describe itself to not be registered")
+	}
+}
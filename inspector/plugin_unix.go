@@ -0,0 +1,20 @@
+//go:build !windows
+// +build !windows
+
+package inspector
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// startProcessGroup : run cmd in a process group of its own so processes
+// it starts can be killed along with it
+func startProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// killProcessGroup : kill cmd and the processes it started
+func killProcessGroup(cmd *exec.Cmd) {
+	syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+}
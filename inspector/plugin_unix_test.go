@@ -0,0 +1,21 @@
+//go:build !windows
+// +build !windows
+
+package inspector
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPluginTimeoutOfChildProcesses(t *testing.T) {
+	start := time.Now()
+	err := RegisterPlugin(testPlugin(t, "hang-in-child"))
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("Expected timeout, found %v", err)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Errorf("Expected processes started by the plugin to be stopped after its timeout, took %s", time.Since(start))
+	}
+}
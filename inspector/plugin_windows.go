@@ -0,0 +1,16 @@
+package inspector
+
+import (
+	"os/exec"
+	"strconv"
+)
+
+func startProcessGroup(cmd *exec.Cmd) {}
+
+// killProcessGroup : kill cmd and the processes it started, taskkill ends
+// the tree of processes of cmd
+func killProcessGroup(cmd *exec.Cmd) {
+	if err := exec.Command("taskkill", "/T", "/F", "/PID", strconv.Itoa(cmd.Process.Pid)).Run(); err != nil {
+		cmd.Process.Kill()
+	}
+}
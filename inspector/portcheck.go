@@ -0,0 +1,84 @@
+package inspector
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/bisohns/saido/driver"
+	log "github.com/sirupsen/logrus"
+)
+
+// PortCheckMetrics : Metrics used by PortCheck, latencies are in seconds
+type PortCheckMetrics struct {
+	Name     string `desc:"Name of the target"`
+	Address  string `desc:"Address probed"`
+	Protocol string `desc:"tcp or udp"`
+	Success  bool   `desc:"Target answered as expected"`
+	// Latency is the tcp handshake or the udp round trip
+	Latency          float64 `unit:"s" desc:"Time of the tcp handshake or udp round trip"`
+	HandshakeLatency float64 `json:",omitempty" unit:"s" desc:"Time of the tls handshake"`
+	Banner           string  `json:",omitempty" desc:"First line sent by the target"`
+	Error            string  `json:",omitempty" desc:"Error probing the target"`
+}
+
+// PortCheck : Probing the targets of a portcheck connection
+type PortCheck struct {
+	Driver *driver.Driver
+	Values []PortCheckMetrics
+}
+
+// Parse : run custom parsing on output of the probes
+func (i *PortCheck) Parse(output string) error {
+	log.Debug("Parsing output string in PortCheck inspector")
+	values := []PortCheckMetrics{}
+	if err := json.Unmarshal([]byte(output), &values); err != nil {
+		i.Values = values
+		return &ParseError{inspector: "PortCheck", content: err.Error()}
+	}
+	i.Values = values
+	return nil
+}
+
+func (i *PortCheck) SetDriver(driver *driver.Driver) {
+	details, _ := (*driver).GetDetails()
+	if !details.IsPortCheck {
+		panic("Cannot use PortCheck on drivers outside (portcheck)")
+	}
+	i.Driver = driver
+}
+
+func (i PortCheck) checkPorts(_ string) (string, error) {
+	checker, ok := (*i.Driver).(driver.PortChecker)
+	if !ok {
+		return ``, errors.New("Driver cannot check ports")
+	}
+	output, err := json.Marshal(checker.CheckPorts())
+	return string(output), err
+}
+
+func (i PortCheck) driverExec() driver.Command {
+	return i.checkPorts
+}
+
+func (i *PortCheck) Execute() (*Result, error) {
+	output, err := i.driverExec()(``)
+	if err == nil {
+		err = i.Parse(output)
+		return newResult(i.Values, ``, err)
+	}
+	return nil, err
+}
+
+// NewPortCheck : Initialize a new PortCheck instance
+func NewPortCheck(driver *driver.Driver, _ ...string) (Inspector, error) {
+	details, err := (*driver).GetDetails()
+	if err != nil {
+		return nil, err
+	}
+	if !details.IsPortCheck {
+		return nil, errors.New("Cannot use PortCheck on drivers outside (portcheck)")
+	}
+	portcheck := &PortCheck{}
+	portcheck.SetDriver(driver)
+	return portcheck, nil
+}
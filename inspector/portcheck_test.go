@@ -0,0 +1,47 @@
+package inspector
+
+import (
+	"net"
+	"testing"
+
+	"github.com/bisohns/saido/driver"
+)
+
+func TestPortCheckOnPortCheck(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Write([]byte("220 smtp ready\r\n"))
+			conn.Close()
+		}
+	}()
+	var d driver.Driver = &driver.PortCheck{
+		Name: "mail",
+		Targets: []driver.PortTarget{
+			{Name: "smtp", Address: listener.Addr().String(), Expect: "220"},
+		},
+	}
+	i, err := Init(`portcheck`, &d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := i.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	values := i.(*PortCheck).Values
+	if len(values) != 1 || !values[0].Success || values[0].Banner != "220 smtp ready" {
+		t.Errorf("Unexpected results %+v", values)
+	}
+	var local driver.Driver = &driver.Local{}
+	if _, err := NewPortCheck(&local); err == nil {
+		t.Error("Expected error for portcheck on local driver")
+	}
+}
@@ -1,34 +1,129 @@
 package inspector
 
 import (
-	"encoding/json"
 	"errors"
+	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 
 	"github.com/bisohns/saido/driver"
-	log "github.com/sirupsen/logrus"
 )
 
-// ProcessMetrics : Metrics used by Process
+// ProcessMetrics : Metrics used by Process and ProcessWin, rows of
+// grouped processes are summed and have no Pid
 type ProcessMetrics struct {
-	Command string
-	User    string
-	Pid     int
-	// Percentage value of CPU used
-	CPU float64
-	// Percentage value of memory used
-	Memory float64
-	// Number of seconds the process has been running
-	Time int64
-	TTY  string
+	Command string `desc:"Command line, image name on windows"`
+	// User is not available on windows
+	User string `desc:"User running the process, not available on windows"`
+	Pid  int    `desc:"Process ID, 0 when grouped"`
+	// Percentage value of CPU used, not available on windows
+	CPU float64 `unit:"%" desc:"CPU used, not available on windows"`
+	// Percentage value of memory used, not available on windows
+	Memory float64 `unit:"%" desc:"Memory used, not available on windows"`
+	// Resident memory in KB
+	RSS float64 `unit:"KB" desc:"Resident memory"`
+	// Number of seconds the process has been running, not available on windows
+	Time int64 `unit:"s" kind:"counter" desc:"CPU time used, not available on windows"`
+	// TTY on unix, session name on windows
+	TTY string `desc:"TTY on unix, session name on windows"`
+	// Count of processes in the row, 1 unless grouped
+	Count int `desc:"Processes in the row, 1 unless grouped"`
+}
+
+// ProcessView : server side sorting, top-N and grouping of processes
+type ProcessView struct {
+	// Top limits the processes sent when above 0
+	Top int
+	// Sort is one of cpu, memory or time, defaults to cpu with Top
+	Sort string
+	// Group is one of name or user
+	Group string
+}
+
+// ParseProcessView : parse a comma separated list of `key=value` where
+// key is one of top, sort or group
+func ParseProcessView(spec string) (ProcessView, error) {
+	var view ProcessView
+	for _, entry := range strings.Split(spec, ",") {
+		parts := strings.SplitN(strings.TrimSpace(entry), "=", 2)
+		if len(parts) != 2 {
+			return view, fmt.Errorf("Invalid process option %q, use key=value", entry)
+		}
+		value := strings.TrimSpace(parts[1])
+		switch parts[0] {
+		case "top":
+			top, err := strconv.Atoi(value)
+			if err != nil || top < 1 {
+				return view, fmt.Errorf("Invalid process option %q, top must be a positive number", entry)
+			}
+			view.Top = top
+		case "sort":
+			if value != "cpu" && value != "memory" && value != "time" {
+				return view, fmt.Errorf("Invalid process option %q, sort by cpu, memory or time", entry)
+			}
+			view.Sort = value
+		case "group":
+			if value != "name" && value != "user" {
+				return view, fmt.Errorf("Invalid process option %q, group by name or user", entry)
+			}
+			view.Group = value
+		default:
+			return view, fmt.Errorf("Unknown process option %s, use top, sort or group", parts[0])
+		}
+	}
+	return view, nil
 }
 
-type ProcessMetricsWin struct {
-	Command     string
-	SessionName string
-	Pid         int
-	Memory      float64
+// apply : group, sort then keep the top processes
+func (v ProcessView) apply(values []ProcessMetrics) []ProcessMetrics {
+	if v.Group != `` {
+		var grouped []ProcessMetrics
+		rows := make(map[string]int)
+		for _, value := range values {
+			key := value.User
+			if v.Group == "name" {
+				key = processName(value.Command)
+			}
+			index, ok := rows[key]
+			if !ok {
+				rows[key] = len(grouped)
+				row := ProcessMetrics{}
+				if v.Group == "name" {
+					row.Command = key
+				} else {
+					row.User = key
+				}
+				grouped = append(grouped, row)
+				index = rows[key]
+			}
+			grouped[index].CPU += value.CPU
+			grouped[index].Memory += value.Memory
+			grouped[index].RSS += value.RSS
+			grouped[index].Time += value.Time
+			grouped[index].Count += value.Count
+		}
+		values = grouped
+	}
+	sortBy := v.Sort
+	if sortBy == `` && v.Top > 0 {
+		sortBy = "cpu"
+	}
+	if sortBy != `` {
+		sort.SliceStable(values, func(a, b int) bool {
+			switch sortBy {
+			case "memory":
+				return values[a].RSS > values[b].RSS
+			case "time":
+				return values[a].Time > values[b].Time
+			}
+			return values[a].CPU > values[b].CPU
+		})
+	}
+	if v.Top > 0 && len(values) > v.Top {
+		values = values[:v.Top]
+	}
+	return values
 }
 
 // Process : Parsing the `ps axu` output for process monitoring
@@ -37,6 +132,7 @@ type Process struct {
 	Command string
 	// Track this particular PID
 	TrackPID int
+	View     ProcessView
 	// Values of metrics being read
 	Values []ProcessMetrics
 }
@@ -46,7 +142,8 @@ type ProcessWin struct {
 	Driver   *driver.Driver
 	Command  string
 	TrackPID int
-	Values   []ProcessMetricsWin
+	View     ProcessView
+	Values   []ProcessMetrics
 }
 
 func (i *Process) SetDriver(driver *driver.Driver) {
@@ -61,13 +158,13 @@ func (i Process) driverExec() driver.Command {
 	return (*i.Driver).RunCommand
 }
 
-func (i *Process) Execute() ([]byte, error) {
+func (i *Process) Execute() (*Result, error) {
 	output, err := i.driverExec()(i.Command)
 	if err == nil {
-		i.Parse(output)
-		return json.Marshal(i.Values)
+		err = i.Parse(output)
+		return newResult(i.Values, ``, err)
 	}
-	return []byte(""), err
+	return nil, err
 }
 
 // Parse : run custom parsing on output of the command
@@ -87,8 +184,9 @@ root           1  0.0  0.0 167580 11440 ?        Ss   18:07   0:00 /sbin/init sp
 root           2  0.0  0.0      0     0 ?        S    18:07   0:00 [kthreadd]
 root           3  0.0  0.0      0     0 ?        I<   18:07   0:00 [rcu_gp]
 */
-func (i *Process) Parse(output string) {
+func (i *Process) Parse(output string) error {
 	var values []ProcessMetrics
+	errs := newParseErrors("Process")
 	lines := strings.Split(output, "\n")
 	for index, line := range lines {
 		// skip title line
@@ -99,42 +197,52 @@ func (i *Process) Parse(output string) {
 		if len(columns) >= 10 {
 			pid, err := strconv.Atoi(columns[1])
 			if err != nil {
-				log.Fatal("Could not parse pid in Process")
+				errs.add("could not parse pid %s", columns[1])
+				continue
 			}
 			// If we are tracking only a particular ID then break loop
 			if i.TrackPID != 0 && i.TrackPID == pid {
-				value := i.createMetric(columns, pid)
+				value := i.createMetric(columns, pid, errs)
 				values = append(values, value)
 				break
 			} else if i.TrackPID == 0 {
-				value := i.createMetric(columns, pid)
+				value := i.createMetric(columns, pid, errs)
 				values = append(values, value)
 			}
 		}
 	}
-	i.Values = values
+	i.Values = i.View.apply(values)
+	return errs.err()
 }
 
-func (i Process) createMetric(columns []string, pid int) ProcessMetrics {
-	var parseErr error
-	cpu, parseErr := strconv.ParseFloat(columns[2], 64)
-	mem, parseErr := strconv.ParseFloat(columns[3], 64)
+func (i Process) createMetric(columns []string, pid int, errs *parseErrors) ProcessMetrics {
+	cpu := errs.float(columns[2], fmt.Sprintf("cpu of %d", pid))
+	mem := errs.float(columns[3], fmt.Sprintf("memory of %d", pid))
+	rss := errs.float(columns[5], fmt.Sprintf("rss of %d", pid))
 	unparsedTime := columns[9]
 	tty := columns[6]
 	minutesStr := strings.Split(unparsedTime, ":")
-	minute, parseErr := strconv.Atoi(minutesStr[0])
-	second, parseErr := strconv.ParseFloat(minutesStr[1], 64)
-	if parseErr != nil {
-		log.Fatal(parseErr)
+	var (
+		minute int
+		second float64
+	)
+	if len(minutesStr) == 2 {
+		minute = errs.int(minutesStr[0], fmt.Sprintf("time of %d", pid))
+		second = errs.float(minutesStr[1], fmt.Sprintf("time of %d", pid))
+	} else {
+		errs.add("could not parse time of %d: %s", pid, unparsedTime)
 	}
 
 	return ProcessMetrics{
 		Command: strings.Join(columns[10:], " "),
 		User:    columns[0],
+		Pid:     pid,
 		CPU:     cpu,
 		Memory:  mem,
+		RSS:     rss,
 		Time:    int64((minute * 60) + int(second)),
 		TTY:     tty,
+		Count:   1,
 	}
 }
 
@@ -149,8 +257,9 @@ Registry                       168 Services                   0     93,448 K
 smss.exe                       604 Services                   0      1,080 K
 csrss.exe                      968 Services                   0      4,916 K
 */
-func (i *ProcessWin) Parse(output string) {
-	var values []ProcessMetricsWin
+func (i *ProcessWin) Parse(output string) error {
+	var values []ProcessMetrics
+	errs := newParseErrors("ProcessWin")
 	lines := strings.Split(output, "\r\n")
 	for index, line := range lines {
 		// skip title lines and ===== line
@@ -163,37 +272,36 @@ func (i *ProcessWin) Parse(output string) {
 			pidRaw := columns[colLength-5]
 			pid, err := strconv.Atoi(pidRaw)
 			if err != nil {
-				log.Errorf("Could not parse pid for row: %e", err)
+				errs.add("could not parse pid %s", pidRaw)
 			} else {
 				if i.TrackPID != 0 && i.TrackPID == pid {
-					value := i.createMetric(columns, pid)
+					value := i.createMetric(columns, pid, errs)
 					values = append(values, value)
 					break
 				} else if i.TrackPID == 0 {
-					value := i.createMetric(columns, pid)
+					value := i.createMetric(columns, pid, errs)
 					values = append(values, value)
 				}
 			}
 		}
 	}
-	i.Values = values
+	i.Values = i.View.apply(values)
+	return errs.err()
 }
 
-func (i *ProcessWin) createMetric(columns []string, pid int) ProcessMetricsWin {
+func (i *ProcessWin) createMetric(columns []string, pid int, errs *parseErrors) ProcessMetrics {
 	colLength := len(columns)
 	memoryRaw := strings.Replace(columns[colLength-2], ",", "", -1)
-	memory, err := strconv.ParseFloat(memoryRaw, 64)
-	if err != nil {
-		panic("Error parsing memory in ProcessWin")
-	}
+	memory := errs.float(memoryRaw, fmt.Sprintf("memory of %d", pid))
 	sessionName := columns[colLength-4]
 	command := strings.Join(columns[:colLength-5], " ")
 
-	return ProcessMetricsWin{
-		Command:     command,
-		Pid:         pid,
-		SessionName: sessionName,
-		Memory:      memory,
+	return ProcessMetrics{
+		Command: command,
+		Pid:     pid,
+		TTY:     sessionName,
+		RSS:     memory,
+		Count:   1,
 	}
 }
 
@@ -209,18 +317,21 @@ func (i ProcessWin) driverExec() driver.Command {
 	return (*i.Driver).RunCommand
 }
 
-func (i *ProcessWin) Execute() ([]byte, error) {
+func (i *ProcessWin) Execute() (*Result, error) {
 	output, err := i.driverExec()(i.Command)
 	if err == nil {
-		i.Parse(output)
-		return json.Marshal(i.Values)
+		err = i.Parse(output)
+		return newResult(i.Values, ``, err)
 	}
-	return []byte(""), err
+	return nil, err
 }
 
-// NewProcess : Initialize a new Process instance
-func NewProcess(driver *driver.Driver, _ ...string) (Inspector, error) {
-	var process Inspector
+// NewProcess : Initialize a new Process instance with view options
+func NewProcess(driver *driver.Driver, custom ...string) (Inspector, error) {
+	var (
+		process Inspector
+		view    ProcessView
+	)
 	details, err := (*driver).GetDetails()
 	if err != nil {
 		return nil, err
@@ -228,13 +339,23 @@ func NewProcess(driver *driver.Driver, _ ...string) (Inspector, error) {
 	if !(details.IsLinux || details.IsDarwin || details.IsWindows) {
 		return nil, errors.New("Cannot use Process on drivers outside (linux, darwin, windows)")
 	}
+	if len(custom) > 0 && strings.TrimSpace(custom[0]) != `` {
+		if view, err = ParseProcessView(custom[0]); err != nil {
+			return nil, err
+		}
+	}
+	if details.IsWindows && (view.Group == "user" || view.Sort == "time") {
+		return nil, errors.New("Cannot group processes by user or sort them by time on windows")
+	}
 	if details.IsLinux || details.IsDarwin {
 		process = &Process{
 			Command: `ps axu`,
+			View:    view,
 		}
 	} else {
 		process = &ProcessWin{
 			Command: `tasklist`,
+			View:    view,
 		}
 	}
 	process.SetDriver(driver)
@@ -0,0 +1,134 @@
+package inspector
+
+import (
+	"testing"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/bisohns/saido/config"
+	"github.com/bisohns/saido/driver"
+)
+
+const fakeTasklist = "\r\nImage Name                     PID Session Name        Session#    Mem Usage\r\n" +
+	"========================= ======== ================ =========== ============\r\n" +
+	"System Idle Process              0 Services                   0          8 K\r\n" +
+	"svchost.exe                    968 Services                   0      4,916 K\r\n" +
+	"svchost.exe                   1024 Services                   0     10,000 K\r\n" +
+	"chrome.exe                    2048 Console                    1    204,800 K\r\n"
+
+func TestProcessView(t *testing.T) {
+	var d driver.Driver = &fakeProcesses{output: fakePsAxu}
+	view := func(spec string) []ProcessMetrics {
+		i, err := NewProcess(&d, spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := i.Execute(); err != nil {
+			t.Fatal(err)
+		}
+		return i.(*Process).Values
+	}
+	if all := view(``); len(all) != 6 || all[0].Pid != 1 || all[0].Count != 1 || all[0].RSS != 11440 {
+		t.Errorf("Expected every process unsorted, found %+v", all)
+	}
+	top := view(`top=2`)
+	if len(top) != 2 || top[0].Pid != 1200 || top[1].Pid != 900 {
+		t.Errorf("Expected top 2 by cpu, found %+v", top)
+	}
+	byTime := view(`top=1, sort=time`)
+	if len(byTime) != 1 || byTime[0].Pid != 1200 || byTime[0].Time != 300 {
+		t.Errorf("Expected process using the most cpu time, found %+v", byTime)
+	}
+	byName := view(`group=name, sort=memory`)
+	if len(byName) != 4 || byName[0].Command != "python3" || byName[3].Command != "nginx" {
+		t.Fatalf("Expected processes grouped by name, found %+v", byName)
+	}
+	if nginx := byName[3]; nginx.Count != 3 || nginx.CPU != 2 || nginx.RSS != 10240 || nginx.Pid != 0 {
+		t.Errorf("Expected nginx to be summed, found %+v", nginx)
+	}
+	byUser := view(`group=user, top=1, sort=memory`)
+	if len(byUser) != 1 || byUser[0].User != "app" || byUser[0].Count != 1 {
+		t.Errorf("Expected user using the most memory, found %+v", byUser)
+	}
+	for _, spec := range []string{`top=0`, `sort=disk`, `sort=runtime`, `group=tty`, `top=3, group`} {
+		if _, err := NewProcess(&d, spec); err == nil {
+			t.Errorf("Expected error for %s", spec)
+		}
+	}
+}
+
+// fakeTasklistDriver : a windows driver returning a fixed tasklist
+type fakeTasklistDriver struct {
+	fakeProcesses
+}
+
+func (d *fakeTasklistDriver) GetDetails() (driver.SystemDetails, error) {
+	return driver.SystemDetails{Name: "windows", IsWindows: true}, nil
+}
+
+func TestProcessViewOnWindows(t *testing.T) {
+	var d driver.Driver = &fakeTasklistDriver{fakeProcesses{output: fakeTasklist}}
+	if _, err := NewProcess(&d, `top=3, group=name, sort=memory`); err != nil {
+		t.Fatal(err)
+	}
+	for _, spec := range []string{`group=user`, `top=1, sort=time`} {
+		if _, err := NewProcess(&d, spec); err == nil {
+			t.Errorf("Expected error for %s on windows", spec)
+		}
+	}
+}
+
+func TestProcessWinParse(t *testing.T) {
+	i := &ProcessWin{View: ProcessView{Group: "name", Sort: "memory"}}
+	if err := i.Parse(fakeTasklist); err != nil {
+		t.Fatal(err)
+	}
+	if len(i.Values) != 3 || i.Values[0].Command != "chrome.exe" || i.Values[0].TTY != `` {
+		t.Fatalf("Unexpected processes %+v", i.Values)
+	}
+	if svchost := i.Values[1]; svchost.Command != "svchost.exe" || svchost.Count != 2 || svchost.RSS != 14916 {
+		t.Errorf("Expected svchost to be summed, found %+v", svchost)
+	}
+	i.View = ProcessView{}
+	i.Parse(fakeTasklist)
+	if chrome := i.Values[3]; chrome.Pid != 2048 || chrome.TTY != "Console" || chrome.RSS != 204800 {
+		t.Errorf("Unexpected chrome %+v", chrome)
+	}
+}
+
+const bareProcessConfig = `
+hosts:
+  children:
+    localhost:
+      connection:
+        type: local
+      metrics:
+        process-nginx: 'name=^nginx$'
+metrics:
+  process:
+poll-interval: 10
+`
+
+func TestBareProcessMetric(t *testing.T) {
+	var cfg config.Config
+	if err := yaml.Unmarshal([]byte(bareProcessConfig), &cfg); err != nil {
+		t.Fatal(err)
+	}
+	info := config.GetDashboardInfoConfig(&cfg)
+	metrics := config.MergeMetrics(info.Metrics, info.Hosts[0].Metrics)
+	var d driver.Driver = &fakeProcesses{output: fakePsAxu}
+	i, err := Init(`process`, &d, metrics[`process`])
+	if err != nil {
+		t.Fatalf("Expected bare process to list processes, found %s", err)
+	}
+	if _, ok := i.(*Process); !ok {
+		t.Errorf("Expected Process, found %T", i)
+	}
+	tracker, err := Init(`process-nginx`, &d, metrics[`process-nginx`])
+	if _, ok := tracker.(*ProcessTracker); err != nil || !ok {
+		t.Errorf("Expected ProcessTracker, found %T %v", tracker, err)
+	}
+	if _, err := Init(`process`, &d, `name=^nginx$`); err == nil {
+		t.Error("Expected filters to be rejected as process view")
+	}
+}
@@ -0,0 +1,307 @@
+package inspector
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/bisohns/saido/driver"
+	log "github.com/sirupsen/logrus"
+)
+
+// ProcessCommand : every tracked process inspector must be prefixed by
+// this followed by a dash e.g process-nginx
+var ProcessCommand = `process`
+
+// trackedProcessCommand : registry name of tracked process inspectors
+var trackedProcessCommand = ProcessCommand + `-*`
+
+// processFilterKey : start of every filter in a process specification
+var processFilterKey = regexp.MustCompile(`,\s*(name|cmdline|user|pidfile|pid)=`)
+
+// ProcessFilter : processes are tracked when they match every set field
+type ProcessFilter struct {
+	// Name matches the executable, first word of the command
+	Name *regexp.Regexp
+	// Cmdline matches the whole command line
+	Cmdline *regexp.Regexp
+	User    string
+	// PidFile is read on every poll for the PID to track
+	PidFile string
+	Pid     int
+}
+
+// ParseProcessFilter : parse a comma separated list of `key=value` where
+// key is one of name, cmdline, user, pidfile or pid
+func ParseProcessFilter(spec string) (ProcessFilter, error) {
+	var filter ProcessFilter
+	// regular expressions may contain commas so only split on keys
+	var entries []string
+	start := 0
+	for _, match := range processFilterKey.FindAllStringIndex(spec, -1) {
+		entries = append(entries, spec[start:match[0]])
+		start = match[0] + 1
+	}
+	entries = append(entries, spec[start:])
+	for _, entry := range entries {
+		parts := strings.SplitN(strings.TrimSpace(entry), "=", 2)
+		if len(parts) != 2 || parts[1] == `` {
+			return filter, fmt.Errorf("Invalid process filter %q, use key=value", entry)
+		}
+		var err error
+		switch parts[0] {
+		case "name":
+			filter.Name, err = regexp.Compile(parts[1])
+		case "cmdline":
+			filter.Cmdline, err = regexp.Compile(parts[1])
+		case "user":
+			filter.User = parts[1]
+		case "pidfile":
+			filter.PidFile = parts[1]
+		case "pid":
+			filter.Pid, err = strconv.Atoi(parts[1])
+		default:
+			err = fmt.Errorf("Unknown key %s, use name, cmdline, user, pidfile or pid", parts[0])
+		}
+		if err != nil {
+			return filter, fmt.Errorf("Invalid process filter %q: %s", entry, err)
+		}
+	}
+	return filter, nil
+}
+
+// processName : executable of a command e.g nginx for
+// `/usr/sbin/nginx -g daemon off;` or `nginx: worker process`
+func processName(command string) string {
+	fields := strings.Fields(command)
+	if len(fields) == 0 {
+		return ``
+	}
+	return strings.TrimSuffix(path.Base(fields[0]), ":")
+}
+
+// TrackedProcessMetrics : Metrics used by ProcessTracker, usage is summed
+// over all instances
+type TrackedProcessMetrics struct {
+	Running   bool  `desc:"At least one process matches"`
+	Instances int   `desc:"Processes matching"`
+	Pids      []int `desc:"IDs of processes matching"`
+	// Percentage value of CPU used, not available on windows
+	CPU float64 `unit:"%" desc:"CPU used, not available on windows"`
+	// Percentage value of memory used, not available on windows
+	Memory float64 `unit:"%" desc:"Memory used, not available on windows"`
+	// Resident memory in MB
+	ResidentMemory float64 `unit:"bytes" desc:"Resident memory"`
+	// Number of times all tracked PIDs were replaced since saido started
+	Restarts int `kind:"counter" desc:"Times all matching processes were replaced since saido started"`
+}
+
+// trackedState : PIDs seen on the last poll a process was running, kept
+// across polls as inspectors are initialized on every poll
+type trackedState struct {
+	pids     []int
+	restarts int
+}
+
+var (
+	trackedMu     sync.Mutex
+	trackedStates = make(map[string]*trackedState)
+)
+
+// forgetTracked : drop tracked processes of hosts other than hosts
+func forgetTracked(hosts map[string]bool) {
+	trackedMu.Lock()
+	defer trackedMu.Unlock()
+	for key := range trackedStates {
+		if !stateOf(key, hosts) {
+			delete(trackedStates, key)
+		}
+	}
+}
+
+// ProcessTracker : Watching processes matching a filter using the
+// output of Process or ProcessWin
+type ProcessTracker struct {
+	Driver  *driver.Driver
+	Filter  ProcessFilter
+	Command string
+	// DisplayByteSize of resident memory
+	DisplayByteSize string
+	IsWindows       bool
+	Values          *TrackedProcessMetrics
+	hostState
+}
+
+// Parse : run custom parsing on output of the command
+func (i *ProcessTracker) Parse(output string) error {
+	var err error
+	log.Debug("Parsing output string in ProcessTracker inspector")
+	metrics := &TrackedProcessMetrics{Pids: []int{}}
+	add := func(pid int, cpu float64, memory float64, rssKB float64) {
+		metrics.Pids = append(metrics.Pids, pid)
+		metrics.CPU += cpu
+		metrics.Memory += memory
+		metrics.ResidentMemory += byteSizeOf(rssKB, `KB`).format(i.DisplayByteSize)
+	}
+	if i.IsWindows {
+		processes := &ProcessWin{TrackPID: i.Filter.Pid}
+		err = processes.Parse(output)
+		for _, process := range processes.Values {
+			if i.matches(process.Pid, process.Command, ``, process.Command) {
+				add(process.Pid, 0, 0, process.RSS)
+			}
+		}
+	} else {
+		processes := &Process{TrackPID: i.Filter.Pid}
+		err = processes.Parse(output)
+		for _, process := range processes.Values {
+			if i.matches(process.Pid, processName(process.Command), process.User, process.Command) {
+				add(process.Pid, process.CPU, process.Memory, process.RSS)
+			}
+		}
+	}
+	sort.Ints(metrics.Pids)
+	metrics.Instances = len(metrics.Pids)
+	metrics.Running = metrics.Instances > 0
+	metrics.Restarts = i.track(metrics.Pids)
+	i.Values = metrics
+	return err
+}
+
+func (i *ProcessTracker) matches(pid int, name string, user string, command string) bool {
+	filter := i.Filter
+	if filter.Pid != 0 && filter.Pid != pid {
+		return false
+	}
+	if filter.Name != nil && !filter.Name.MatchString(name) {
+		return false
+	}
+	if filter.Cmdline != nil && !filter.Cmdline.MatchString(command) {
+		return false
+	}
+	return filter.User == `` || filter.User == user
+}
+
+// track : a restart is counted when none of the PIDs last seen running
+// are still running
+func (i *ProcessTracker) track(pids []int) int {
+	trackedMu.Lock()
+	defer trackedMu.Unlock()
+	state, ok := trackedStates[i.stateKey]
+	if !ok {
+		state = &trackedState{}
+		trackedStates[i.stateKey] = state
+	}
+	if len(pids) == 0 {
+		return state.restarts
+	}
+	if len(state.pids) > 0 {
+		seen := make(map[int]bool)
+		for _, pid := range state.pids {
+			seen[pid] = true
+		}
+		replaced := true
+		for _, pid := range pids {
+			if seen[pid] {
+				replaced = false
+				break
+			}
+		}
+		if replaced {
+			state.restarts++
+		}
+	}
+	state.pids = pids
+	return state.restarts
+}
+
+func (i *ProcessTracker) SetDriver(driver *driver.Driver) {
+	details, _ := (*driver).GetDetails()
+	if !(details.IsLinux || details.IsDarwin || details.IsWindows) {
+		panic("Cannot use ProcessTracker on drivers outside (linux, darwin, windows)")
+	}
+	i.Driver = driver
+}
+
+func (i ProcessTracker) driverExec() driver.Command {
+	return (*i.Driver).RunCommand
+}
+
+// readPidFile : a missing pidfile means the process is not running
+func (i *ProcessTracker) readPidFile() bool {
+	content, err := (*i.Driver).ReadFile(i.Filter.PidFile)
+	if err != nil {
+		log.Debugf("Could not read pidfile %s: %s", i.Filter.PidFile, err)
+		return false
+	}
+	// pidfiles like postmaster.pid have the PID on the first line
+	fields := strings.Fields(content)
+	if len(fields) == 0 {
+		return false
+	}
+	pid, err := strconv.Atoi(fields[0])
+	if err != nil {
+		log.Errorf("Could not parse pidfile %s: %s", i.Filter.PidFile, err)
+		return false
+	}
+	i.Filter.Pid = pid
+	return true
+}
+
+func (i *ProcessTracker) Execute() (*Result, error) {
+	if i.Filter.PidFile != `` && !i.readPidFile() {
+		err := i.Parse(``)
+		return newResult(i.Values, i.DisplayByteSize, err)
+	}
+	output, err := i.driverExec()(i.Command)
+	if err == nil {
+		err = i.Parse(output)
+		return newResult(i.Values, i.DisplayByteSize, err)
+	}
+	return nil, err
+}
+
+// newTrackedProcess : ProcessTracker of a process-<name> metric whose
+// value is the filter specification
+func newTrackedProcess(driver *driver.Driver, custom ...string) (Inspector, error) {
+	if len(custom) == 0 || strings.TrimSpace(custom[0]) == `` {
+		return nil, errors.New("Tracked process metrics need a process filter")
+	}
+	return NewProcessTracker(driver, custom[0])
+}
+
+// NewProcessTracker : Initialize a new ProcessTracker instance from a
+// process filter specification
+func NewProcessTracker(driver *driver.Driver, spec string) (Inspector, error) {
+	details, err := (*driver).GetDetails()
+	if err != nil {
+		return nil, err
+	}
+	if !(details.IsLinux || details.IsDarwin || details.IsWindows) {
+		return nil, errors.New("Cannot use ProcessTracker on drivers outside (linux, darwin, windows)")
+	}
+	filter, err := ParseProcessFilter(spec)
+	if err != nil {
+		return nil, err
+	}
+	tracker := &ProcessTracker{
+		Filter:          filter,
+		Command:         `ps axu`,
+		DisplayByteSize: defaultDisplayByteSize,
+	}
+	if details.IsWindows {
+		// tasklist does not show users or command lines
+		if filter.User != `` || filter.Cmdline != nil {
+			return nil, errors.New("Cannot track processes by user or cmdline on windows")
+		}
+		tracker.Command = `tasklist`
+		tracker.IsWindows = true
+	}
+	tracker.SetDriver(driver)
+	return tracker, nil
+}
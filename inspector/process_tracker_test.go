@@ -0,0 +1,120 @@
+package inspector
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/bisohns/saido/driver"
+)
+
+const fakePsAxu = `USER         PID %CPU %MEM    VSZ   RSS TTY      STAT START   TIME COMMAND
+root           1  0.0  0.1 167580 11440 ?        Ss   18:07   0:02 /sbin/init splash
+root         812  0.0  0.1  55280  2048 ?        Ss   18:07   0:00 nginx: master process /usr/sbin/nginx -g daemon on; master_process on;
+www-data     813  1.5  0.4  55900  4096 ?        S    18:07   0:10 nginx: worker process
+www-data     814  0.5  0.4  55900  4096 ?        S    18:07   0:04 nginx: worker process
+postgres     900  2.0  1.5 215000 30720 ?        Ss   18:07   1:30 /usr/lib/postgresql/14/bin/postgres -D /var/lib/postgresql/14/main
+app         1200 12.0  3.0 900000 61440 ?        Sl   18:08   5:00 python3 -m celery worker -A app,tasks
+`
+
+// fakeProcesses : returns a fixed process table and pidfiles
+type fakeProcesses struct {
+	driver.Local
+	output string
+	files  map[string]string
+}
+
+func (d *fakeProcesses) RunCommand(command string) (string, error) {
+	return d.output, nil
+}
+
+func (d *fakeProcesses) ReadFile(path string) (string, error) {
+	content, ok := d.files[path]
+	if !ok {
+		return ``, errors.New("No such file or directory")
+	}
+	return content, nil
+}
+
+func (d *fakeProcesses) GetDetails() (driver.SystemDetails, error) {
+	return driver.SystemDetails{Name: "linux", IsLinux: true}, nil
+}
+
+func TestParseProcessFilter(t *testing.T) {
+	filter, err := ParseProcessFilter(`cmdline=celery worker -A (app|tasks){1,2}, user=app`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filter.Cmdline.String() != `celery worker -A (app|tasks){1,2}` || filter.User != "app" {
+		t.Errorf("Unexpected filter %+v", filter)
+	}
+	for _, spec := range []string{`name`, `name=(`, `port=80`, `pid=one`} {
+		if _, err := ParseProcessFilter(spec); err == nil {
+			t.Errorf("Expected error for %s", spec)
+		}
+	}
+}
+
+func TestProcessTracker(t *testing.T) {
+	fake := &fakeProcesses{output: fakePsAxu, files: map[string]string{
+		"/var/lib/postgresql/14/main/postmaster.pid": "900\n/var/lib/postgresql/14/main\n",
+	}}
+	// every poll has a new driver as after a reconnect
+	tracked := func(name string, spec string) *TrackedProcessMetrics {
+		polled := *fake
+		var d driver.Driver = &polled
+		i, err := Init(name, &d, spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		result, err := Collect("web-1", name, i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result.Values.(*TrackedProcessMetrics)
+	}
+	nginx := tracked(`process-nginx`, `name=^nginx$`)
+	if !nginx.Running || nginx.Instances != 3 || !reflect.DeepEqual(nginx.Pids, []int{812, 813, 814}) {
+		t.Errorf("Unexpected nginx %+v", nginx)
+	}
+	if nginx.CPU != 2 || nginx.ResidentMemory != 10 {
+		t.Errorf("Expected usage to be summed, found %+v", nginx)
+	}
+	workers := tracked(`process-workers`, `name=nginx, user=www-data`)
+	if workers.Instances != 2 {
+		t.Errorf("Expected 2 workers, found %+v", workers)
+	}
+	postgres := tracked(`process-postgres`, `pidfile=/var/lib/postgresql/14/main/postmaster.pid`)
+	if !postgres.Running || !reflect.DeepEqual(postgres.Pids, []int{900}) {
+		t.Errorf("Unexpected postgres %+v", postgres)
+	}
+	celery := tracked(`process-celery`, `cmdline=celery worker -A app,tasks`)
+	if celery.Instances != 1 || celery.Pids[0] != 1200 {
+		t.Errorf("Unexpected celery %+v", celery)
+	}
+	missing := tracked(`process-redis`, `pidfile=/run/redis.pid`)
+	if missing.Running || missing.Instances != 0 {
+		t.Errorf("Expected missing pidfile to not be running, found %+v", missing)
+	}
+
+	// nginx dies then comes back with new PIDs
+	fake.output = ``
+	if stopped := tracked(`process-nginx`, `name=^nginx$`); stopped.Running || stopped.Restarts != 0 {
+		t.Errorf("Expected nginx to be stopped without restarts, found %+v", stopped)
+	}
+	fake.output = `USER         PID %CPU %MEM    VSZ   RSS TTY      STAT START   TIME COMMAND
+root        2812  0.0  0.1  55280  2048 ?        Ss   18:07   0:00 nginx: master process /usr/sbin/nginx
+`
+	if restarted := tracked(`process-nginx`, `name=^nginx$`); !restarted.Running || restarted.Restarts != 1 {
+		t.Errorf("Expected a restart of nginx, found %+v", restarted)
+	}
+	// a PID surviving is not a restart
+	fake.output = fakePsAxu
+	if reloaded := tracked(`process-workers`, `name=nginx, user=www-data`); reloaded.Restarts != 0 {
+		t.Errorf("Expected no restart of workers, found %+v", reloaded)
+	}
+	ForgetHosts(nil)
+	if forgotten := tracked(`process-nginx`, `name=^nginx$`); forgotten.Restarts != 0 {
+		t.Errorf("Expected restarts of removed hosts to be dropped, found %+v", forgotten)
+	}
+}
@@ -0,0 +1,150 @@
+package inspector
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/bisohns/saido/driver"
+	log "github.com/sirupsen/logrus"
+)
+
+// promSample : single sample of the prometheus text exposition format
+type promSample struct {
+	Name   string
+	Labels map[string]string
+	Value  float64
+}
+
+// promSamples : samples of a scrape grouped by metric name
+type promSamples map[string][]promSample
+
+// parsePromLabels : parse `name="value",...}` returning the labels and
+// the remainder of the line after the closing brace
+func parsePromLabels(input string) (map[string]string, string, error) {
+	labels := make(map[string]string)
+	for {
+		input = strings.TrimLeft(input, " ,")
+		if strings.HasPrefix(input, "}") {
+			return labels, input[1:], nil
+		}
+		eq := strings.Index(input, "=")
+		if eq < 0 || len(input) < eq+2 || input[eq+1] != '"' {
+			return nil, "", errors.New("invalid label")
+		}
+		name := strings.TrimSpace(input[:eq])
+		input = input[eq+2:]
+		var value strings.Builder
+		closed := false
+		for index := 0; index < len(input); index++ {
+			char := input[index]
+			if char == '\\' && index+1 < len(input) {
+				index++
+				switch input[index] {
+				case 'n':
+					value.WriteByte('\n')
+				default:
+					value.WriteByte(input[index])
+				}
+				continue
+			}
+			if char == '"' {
+				input = input[index+1:]
+				closed = true
+				break
+			}
+			value.WriteByte(char)
+		}
+		if !closed {
+			return nil, "", errors.New("unterminated label value")
+		}
+		labels[name] = value.String()
+	}
+}
+
+// parsePrometheus : parse the text exposition format
+/*
+# HELP node_load1 1m load average.
+# TYPE node_load1 gauge
+node_load1 0.21
+node_filesystem_avail_bytes{device="/dev/sda1",fstype="ext4",mountpoint="/"} 4.2e+10
+*/
+func parsePrometheus(output string) promSamples {
+	samples := make(promSamples)
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		sample := promSample{Labels: map[string]string{}}
+		rest := line
+		if brace := strings.Index(line, "{"); brace >= 0 {
+			sample.Name = line[:brace]
+			labels, remainder, err := parsePromLabels(line[brace+1:])
+			if err != nil {
+				log.Debugf("Skipping sample %s: %s", line, err)
+				continue
+			}
+			sample.Labels = labels
+			rest = remainder
+		} else {
+			fields := strings.Fields(line)
+			sample.Name = fields[0]
+			rest = strings.TrimPrefix(line, fields[0])
+		}
+		// value may be followed by a timestamp
+		fields := strings.Fields(rest)
+		if len(fields) < 1 {
+			continue
+		}
+		value, err := strconv.ParseFloat(fields[0], 64)
+		if err != nil {
+			log.Debugf("Skipping sample %s: %s", line, err)
+			continue
+		}
+		sample.Value = value
+		samples[sample.Name] = append(samples[sample.Name], sample)
+	}
+	return samples
+}
+
+// value : first sample of name matching labels
+func (s promSamples) value(name string, labels map[string]string) (float64, bool) {
+	for _, sample := range s[name] {
+		if sample.matches(labels) {
+			return sample.Value, true
+		}
+	}
+	return 0, false
+}
+
+// sum : sum of every sample of name matching labels
+func (s promSamples) sum(name string, labels map[string]string) float64 {
+	var total float64
+	for _, sample := range s[name] {
+		if sample.matches(labels) {
+			total += sample.Value
+		}
+	}
+	return total
+}
+
+func (sample promSample) matches(labels map[string]string) bool {
+	for k, v := range labels {
+		if sample.Labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// scrapeNodeExporter : used as driverExec by node_exporter inspectors
+func scrapeNodeExporter(d *driver.Driver) driver.Command {
+	return func(_ string) (string, error) {
+		provider, ok := (*d).(driver.NodeExporterProvider)
+		if !ok {
+			return ``, errors.New("Driver cannot scrape a node_exporter")
+		}
+		return provider.Scrape()
+	}
+}
@@ -0,0 +1,109 @@
+package inspector
+
+import (
+	"math"
+	"strings"
+	"sync"
+	"time"
+)
+
+// counter32Wrap : value 32 bit counters e.g SNMP ifInOctets wrap to 0 at
+var counter32Wrap = math.Pow(2, 32)
+
+// counterSample : value of a counter and when it was read
+type counterSample struct {
+	value float64
+	at    time.Time
+}
+
+var (
+	countersMu     sync.Mutex
+	counterSamples = make(map[string]counterSample)
+)
+
+// hostState : key of the state an inspector keeps across polls of a host
+// e.g samples of rates, set by Collect to the host and metric
+type hostState struct {
+	stateKey string
+}
+
+func (s *hostState) setStateKey(key string) {
+	s.stateKey = key
+}
+
+// stateful : inspectors keeping state across polls of a host
+type stateful interface {
+	setStateKey(key string)
+}
+
+// stateOf : whether key of state kept across polls belongs to one of hosts
+func stateOf(key string, hosts map[string]bool) bool {
+	host, _, _ := strings.Cut(key, "\x00")
+	return hosts[host]
+}
+
+// ForgetHosts : drop state kept across polls of hosts other than hosts
+// e.g once they are removed from the config
+func ForgetHosts(hosts []string) {
+	keep := make(map[string]bool)
+	for _, host := range hosts {
+		keep[host] = true
+	}
+	forgetTracked(keep)
+	countersMu.Lock()
+	defer countersMu.Unlock()
+	for key := range counterSamples {
+		if !stateOf(key, keep) {
+			delete(counterSamples, key)
+		}
+	}
+}
+
+// rates : per second increase of counters of a host since the last poll,
+// samples are kept across polls as inspectors are initialized on every
+// poll. A nil rates has no samples so every rate is 0
+type rates struct {
+	key string
+	// at is when the counters of this poll were read
+	at time.Time
+}
+
+// newRates : rates of counters of the host and metric of key
+func newRates(key string) *rates {
+	return &rates{key: key, at: time.Now()}
+}
+
+// rate : per second increase of counter name, 0 on its first sample and
+// when it decreased as the counter was reset e.g on reboot
+func (r *rates) rate(name string, value float64) float64 {
+	return r.delta(name, value, 0)
+}
+
+// rate32 : per second increase of a 32 bit counter, a decrease from the
+// upper half of its range is a wrap around instead of a reset
+func (r *rates) rate32(name string, value float64) float64 {
+	return r.delta(name, value, counter32Wrap)
+}
+
+func (r *rates) delta(name string, value float64, wrap float64) float64 {
+	if r == nil {
+		return 0
+	}
+	countersMu.Lock()
+	defer countersMu.Unlock()
+	key := r.key + `|` + name
+	previous, ok := counterSamples[key]
+	counterSamples[key] = counterSample{value: value, at: r.at}
+	elapsed := r.at.Sub(previous.at).Seconds()
+	if !ok || elapsed <= 0 {
+		return 0
+	}
+	increase := value - previous.value
+	if increase < 0 {
+		if wrap == 0 || previous.value < wrap/2 {
+			return 0
+		}
+		increase += wrap
+	}
+	return increase / elapsed
+}
@@ -0,0 +1,82 @@
+package inspector
+
+import (
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/bisohns/saido/driver"
+)
+
+func TestRates(t *testing.T) {
+	start := time.Now()
+	poll := func(seconds int) *rates {
+		return &rates{key: "test-rates", at: start.Add(time.Duration(seconds) * time.Second)}
+	}
+	if rate := poll(0).rate("bytes", 1000); rate != 0 {
+		t.Errorf("Expected no rate on the first sample, found %f", rate)
+	}
+	if rate := poll(10).rate("bytes", 6000); rate != 500 {
+		t.Errorf("Expected 500 per second, found %f", rate)
+	}
+	if rate := poll(20).rate("bytes", 100); rate != 0 {
+		t.Errorf("Expected reset to have no rate, found %f", rate)
+	}
+	if rate := poll(30).rate("bytes", 1100); rate != 100 {
+		t.Errorf("Expected rate after reset, found %f", rate)
+	}
+
+	poll(0).rate32("octets", counter32Wrap-1000)
+	if rate := poll(10).rate32("octets", 4000); rate != 500 {
+		t.Errorf("Expected wrap around to be counted, found %f", rate)
+	}
+	if rate := poll(20).rate32("octets", 10); rate != 0 {
+		t.Errorf("Expected decrease from the lower half to be a reset, found %f", rate)
+	}
+	var none *rates
+	if rate := none.rate("bytes", 100); rate != 0 {
+		t.Errorf("Expected no rate without samples, found %f", rate)
+	}
+}
+
+func TestCPULinuxRecentUsage(t *testing.T) {
+	start := time.Now()
+	i := &CPULinux{ClockTicks: 100, rates: &rates{key: "test-cpu", at: start}}
+	i.Parse("cpu  3000 0 1000 5000 1000 0 0 0 0 0\n")
+	if i.Values.RecentUsagePercent != 0 {
+		t.Errorf("Expected no recent usage on the first poll, found %f", i.Values.RecentUsagePercent)
+	}
+	// 300 of 400 ticks busy since the last poll
+	i.rates = &rates{key: "test-cpu", at: start.Add(5 * time.Second)}
+	i.Parse("cpu  3200 0 1100 5100 1000 0 0 0 0 0\n")
+	if math.Abs(i.Values.RecentUsagePercent-75) > 1e-9 {
+		t.Errorf("Expected 75%% recent usage, found %f", i.Values.RecentUsagePercent)
+	}
+}
+
+func TestRatesPerHost(t *testing.T) {
+	collect := func(host string, busy int, idle int) float64 {
+		var d driver.Driver = &fakeProcesses{files: map[string]string{
+			"/proc/stat": fmt.Sprintf("cpu  %d 0 0 %d 0 0 0 0 0 0\n", busy, idle),
+		}}
+		i := &CPULinux{Driver: &d, FilePath: "/proc/stat", ClockTicks: 100}
+		result, err := Collect(host, "cpu", i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result.Values.(*CPUMetrics).RecentUsagePercent
+	}
+	collect("web-1", 1000, 1000)
+	if usage := collect("web-2", 5000, 5000); usage != 0 {
+		t.Errorf("Expected samples of other hosts to be ignored, found %f", usage)
+	}
+	// every poll has a new driver e.g after a reconnect
+	if usage := collect("web-1", 1300, 1100); math.Abs(usage-75) > 1e-9 {
+		t.Errorf("Expected samples of the host to be kept across drivers, found %f", usage)
+	}
+	ForgetHosts([]string{"web-1"})
+	if usage := collect("web-2", 5300, 5100); usage != 0 {
+		t.Errorf("Expected samples of removed hosts to be dropped, found %f", usage)
+	}
+}
@@ -0,0 +1,88 @@
+package inspector
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/bisohns/saido/driver"
+	log "github.com/sirupsen/logrus"
+)
+
+// Remote : inspector whose values are collected by a `saido agent`
+type Remote struct {
+	Driver *driver.Driver
+	// Name of the inspector run by the agent
+	Name   string
+	Custom string
+	// Values as returned by the agent
+	Values interface{}
+	// Units of fields of Values as returned by the agent
+	Units map[string]string
+}
+
+// Parse : decode the inspector output returned by the agent
+func (i *Remote) Parse(output string) error {
+	log.Debugf("Parsing output string in Remote(%s) inspector", i.Name)
+	if err := json.Unmarshal([]byte(output), &i.Values); err != nil {
+		return &ParseError{inspector: i.Name, content: err.Error()}
+	}
+	return nil
+}
+
+func (i *Remote) SetDriver(driver *driver.Driver) {
+	i.Driver = driver
+}
+
+func (i *Remote) fetch(custom string) (string, error) {
+	provider, ok := (*i.Driver).(driver.AgentProvider)
+	if !ok {
+		return ``, errors.New("Driver cannot fetch metrics from an agent")
+	}
+	result, err := provider.AgentMetric(i.Name, custom)
+	if result == nil {
+		return ``, err
+	}
+	i.Units = result.Units
+	return string(result.Data), err
+}
+
+func (i *Remote) driverExec() driver.Command {
+	return i.fetch
+}
+
+func (i *Remote) Execute() (*Result, error) {
+	output, err := i.driverExec()(i.Custom)
+	if err == nil {
+		err = i.Parse(output)
+		return i.result(err)
+	}
+	// agents send the values they could parse along with the error
+	if output != `` && i.Parse(output) == nil {
+		return i.result(&ParseError{inspector: i.Name, content: err.Error()})
+	}
+	return nil, err
+}
+
+// result : values along with the units the agent sent
+func (i *Remote) result(parseErr error) (*Result, error) {
+	result, err := newResult(i.Values, ``, parseErr)
+	if i.Units != nil {
+		result.Units = i.Units
+	}
+	return result, err
+}
+
+func isAgent(d *driver.Driver) bool {
+	_, ok := (*d).(driver.AgentProvider)
+	return ok
+}
+
+// NewRemote : Initialize a new Remote instance for inspector name
+func NewRemote(name string, driver *driver.Driver, custom ...string) (Inspector, error) {
+	remote := &Remote{Name: name}
+	if len(custom) > 0 {
+		remote.Custom = custom[0]
+	}
+	remote.SetDriver(driver)
+	return remote, nil
+}
@@ -1,9 +1,8 @@
 package inspector
 
 import (
-	"encoding/json"
 	"errors"
-	"strconv"
+	"strings"
 
 	"github.com/bisohns/saido/driver"
 	log "github.com/sirupsen/logrus"
@@ -11,7 +10,7 @@ import (
 
 // ResponseTimeMetrics : Metrics used by ResponseTime
 type ResponseTimeMetrics struct {
-	Seconds float64
+	Seconds float64 `unit:"s" desc:"Time taken to respond"`
 }
 
 // ResponseTime : Parsing the `web` output for response time
@@ -23,16 +22,14 @@ type ResponseTime struct {
 }
 
 // Parse : run custom parsing on output of the command
-func (i *ResponseTime) Parse(output string) {
+func (i *ResponseTime) Parse(output string) error {
 	log.Debug("Parsing output string in ResponseTime inspector")
-	strconv, err := strconv.ParseFloat(output, 64)
-	if err != nil {
-		log.Fatal(err)
-	}
+	errs := newParseErrors("ResponseTime")
 	values := ResponseTimeMetrics{
-		Seconds: strconv,
+		Seconds: errs.float(strings.TrimSpace(output), "response time"),
 	}
 	i.Values = values
+	return errs.err()
 }
 
 func (i *ResponseTime) SetDriver(driver *driver.Driver) {
@@ -43,13 +40,13 @@ func (i ResponseTime) driverExec() driver.Command {
 	return (*i.Driver).RunCommand
 }
 
-func (i *ResponseTime) Execute() ([]byte, error) {
+func (i *ResponseTime) Execute() (*Result, error) {
 	output, err := i.driverExec()(i.Command)
 	if err == nil {
-		i.Parse(output)
-		return json.Marshal(i.Values)
+		err = i.Parse(output)
+		return newResult(i.Values, ``, err)
 	}
-	return []byte(""), err
+	return nil, err
 }
 
 // NewResponseTime : Initialize a new ResponseTime instance
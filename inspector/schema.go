@@ -0,0 +1,120 @@
+package inspector
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// defaultDisplayByteSize : byte size values are read in and displayed in
+// unless display units are set
+var defaultDisplayByteSize = `MB`
+
+// Field : a value read by an inspector
+type Field struct {
+	Name string
+	// Type is one of number, string, bool, list, map or any
+	Type string
+	Unit string `json:",omitempty"`
+	// Kind of numbers, counters only increase until reset while gauges
+	// go up and down
+	Kind        string `json:",omitempty"`
+	Description string
+}
+
+// Schema : what an inspector reads and where it can read it
+type Schema struct {
+	Name        string
+	Description string
+	Platforms   []string
+	// List is set when the inspector reads a list of Fields
+	List   bool
+	Fields []Field
+}
+
+// fieldType : JSON type of values of kind
+func fieldType(kind reflect.Kind) string {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "bool"
+	case reflect.Slice, reflect.Array:
+		return "list"
+	case reflect.Map:
+		return "map"
+	}
+	return "any"
+}
+
+// newSchema : fields are described by the `unit`, `kind` and `desc` tags
+// of the values of the registration, or declared along with it
+func newSchema(name string, entry registration) Schema {
+	schema := Schema{
+		Name:        name,
+		Description: entry.Description,
+		Platforms:   entry.Platforms,
+		Fields:      []Field{},
+	}
+	if entry.Values == nil {
+		schema.List = entry.List
+		for _, field := range entry.Fields {
+			field.Unit = displayedUnit(name, field.Unit)
+			schema.Fields = append(schema.Fields, field)
+		}
+		return schema
+	}
+	kind := reflect.TypeOf(entry.Values)
+	if kind.Kind() == reflect.Slice {
+		schema.List = true
+		kind = kind.Elem()
+	}
+	units := fieldUnits(entry.Values, defaultDisplayByteSize)
+	for index := 0; index < kind.NumField(); index++ {
+		structField := kind.Field(index)
+		field := Field{
+			Name:        structField.Name,
+			Type:        fieldType(structField.Type.Kind()),
+			Unit:        displayedUnit(name, units[structField.Name]),
+			Description: structField.Tag.Get("desc"),
+		}
+		if jsonName := strings.Split(structField.Tag.Get("json"), ",")[0]; jsonName != `` {
+			field.Name = jsonName
+		}
+		if field.Type == "number" {
+			field.Kind = "gauge"
+			if structField.Tag.Get("kind") == "counter" {
+				field.Kind = "counter"
+			}
+		}
+		schema.Fields = append(schema.Fields, field)
+	}
+	return schema
+}
+
+// Schemas : schemas of every inspector sorted by name
+func Schemas() []Schema {
+	schemas := []Schema{}
+	for name, entry := range inspectorMap {
+		schemas = append(schemas, newSchema(name, entry))
+	}
+	sort.Slice(schemas, func(a, b int) bool {
+		return schemas[a].Name < schemas[b].Name
+	})
+	return schemas
+}
+
+// GetSchema : schema of inspector name, names of custom, snmp and
+// tracked process inspectors are matched by prefix
+func GetSchema(name string) (Schema, bool) {
+	name = registryName(name)
+	entry, ok := inspectorMap[name]
+	if !ok {
+		return Schema{}, false
+	}
+	return newSchema(name, entry), true
+}
@@ -0,0 +1,40 @@
+package inspector
+
+import (
+	"testing"
+)
+
+func TestSchemas(t *testing.T) {
+	schemas := Schemas()
+	if len(schemas) != len(inspectorMap) {
+		t.Fatalf("Expected a schema of every inspector, found %d", len(schemas))
+	}
+	for _, schema := range schemas {
+		if schema.Description == `` || len(schema.Platforms) == 0 || len(schema.Fields) == 0 {
+			t.Errorf("Incomplete schema of %s", schema.Name)
+		}
+		for _, field := range schema.Fields {
+			if field.Description == `` {
+				t.Errorf("Missing description of %s.%s", schema.Name, field.Name)
+			}
+		}
+	}
+}
+
+func TestGetSchema(t *testing.T) {
+	disk, ok := GetSchema(`disk`)
+	if !ok || !disk.List {
+		t.Fatalf("Expected disk to read a list, found %+v", disk)
+	}
+	size := disk.Fields[1]
+	if size.Name != "Size" || size.Type != "number" || size.Unit != "MB" || size.Kind != "gauge" {
+		t.Errorf("Unexpected size field %+v", size)
+	}
+	tracked, ok := GetSchema(`process-nginx`)
+	if !ok || tracked.List || tracked.Fields[len(tracked.Fields)-1].Kind != "counter" {
+		t.Errorf("Expected restarts of tracked processes to be a counter, found %+v", tracked)
+	}
+	if _, ok := GetSchema(`unknown`); ok {
+		t.Error("Expected no schema of unknown inspector")
+	}
+}
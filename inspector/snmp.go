@@ -0,0 +1,439 @@
+package inspector
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bisohns/saido/driver"
+	log "github.com/sirupsen/logrus"
+)
+
+// SNMPCommand : every configurable OID inspector must be prefixed by this
+var SNMPCommand = `snmp`
+
+const (
+	ifTableOID  = `1.3.6.1.2.1.2.2.1`
+	ifXTableOID = `1.3.6.1.2.1.31.1.1.1`
+	// hrSystemUptime is the uptime of the host rather than the agent
+	hrSystemUptimeOID = `1.3.6.1.2.1.25.1.1.0`
+	sysUpTimeOID      = `1.3.6.1.2.1.1.3.0`
+)
+
+// snmpProvider : returns the SNMP capability of the driver
+func snmpProvider(d *driver.Driver) (driver.SNMPProvider, error) {
+	provider, ok := (*d).(driver.SNMPProvider)
+	if !ok {
+		return nil, errors.New("Driver cannot query an SNMP agent")
+	}
+	return provider, nil
+}
+
+// walkSNMP : used as driverExec by snmp inspectors, walks every space
+// separated root and returns the variables found as JSON
+func walkSNMP(d *driver.Driver) driver.Command {
+	return func(roots string) (string, error) {
+		provider, err := snmpProvider(d)
+		if err != nil {
+			return ``, err
+		}
+		variables := []driver.SNMPVariable{}
+		for _, root := range strings.Fields(roots) {
+			walked, err := provider.SNMPWalk(root)
+			if err != nil {
+				return ``, err
+			}
+			variables = append(variables, walked...)
+		}
+		output, err := json.Marshal(variables)
+		return string(output), err
+	}
+}
+
+// getSNMP : used as driverExec by snmp inspectors, gets every space
+// separated OID and walks those that are not scalar objects
+func getSNMP(d *driver.Driver) driver.Command {
+	return func(oids string) (string, error) {
+		provider, err := snmpProvider(d)
+		if err != nil {
+			return ``, err
+		}
+		got, err := provider.SNMPGet(strings.Fields(oids))
+		if err != nil {
+			return ``, err
+		}
+		variables := []driver.SNMPVariable{}
+		for index, variable := range got {
+			if variable.Found() {
+				variables = append(variables, variable)
+				continue
+			}
+			walked, err := provider.SNMPWalk(strings.Fields(oids)[index])
+			if err != nil {
+				return ``, err
+			}
+			variables = append(variables, walked...)
+		}
+		output, err := json.Marshal(variables)
+		return string(output), err
+	}
+}
+
+// parseSNMP : decode variables keeping the precision of 64 bit counters
+func parseSNMP(inspector string, output string) ([]driver.SNMPVariable, error) {
+	variables := []driver.SNMPVariable{}
+	decoder := json.NewDecoder(strings.NewReader(output))
+	decoder.UseNumber()
+	if err := decoder.Decode(&variables); err != nil {
+		return variables, &ParseError{inspector: inspector, content: err.Error()}
+	}
+	return variables, nil
+}
+
+func snmpUint(value interface{}) uint64 {
+	number, ok := value.(json.Number)
+	if !ok {
+		return 0
+	}
+	if parsed, err := strconv.ParseUint(number.String(), 10, 64); err == nil {
+		return parsed
+	}
+	parsed, _ := strconv.ParseInt(number.String(), 10, 64)
+	return uint64(parsed)
+}
+
+// snmpIndex : splits a table column OID into column and row index
+func snmpIndex(oid string, table string) (int, string, bool) {
+	if !strings.HasPrefix(oid, table+".") {
+		return 0, ``, false
+	}
+	parts := strings.SplitN(strings.TrimPrefix(oid, table+"."), ".", 2)
+	if len(parts) != 2 {
+		return 0, ``, false
+	}
+	column, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, ``, false
+	}
+	return column, parts[1], true
+}
+
+// InterfaceMetrics : Metrics used by Interfaces, counters are since the
+// agent started
+type InterfaceMetrics struct {
+	Index       int    `desc:"ifIndex of the interface"`
+	Name        string `desc:"Name of the interface"`
+	Description string `desc:"Description of the interface"`
+	Alias       string `desc:"Alias configured on the device"`
+	AdminStatus string `desc:"Configured status e.g up, down"`
+	OperStatus  string `desc:"Operational status e.g up, down"`
+	// Speed is in Mbps
+	Speed       float64 `unit:"Mbps" desc:"Speed of the interface"`
+	InBytes     float64 `unit:"bytes" kind:"counter" desc:"Bytes received"`
+	OutBytes    float64 `unit:"bytes" kind:"counter" desc:"Bytes transmitted"`
+	InRate      float64 `unit:"bytes/s" desc:"Bytes received per second since the last poll"`
+	OutRate     float64 `unit:"bytes/s" desc:"Bytes transmitted per second since the last poll"`
+	InErrors    uint64  `kind:"counter" desc:"Receive errors"`
+	OutErrors   uint64  `kind:"counter" desc:"Transmit errors"`
+	InDiscards  uint64  `kind:"counter" desc:"Received packets discarded"`
+	OutDiscards uint64  `kind:"counter" desc:"Transmitted packets discarded"`
+}
+
+// ifStatus : values of ifAdminStatus and ifOperStatus
+var ifStatus = map[uint64]string{
+	1: "up",
+	2: "down",
+	3: "testing",
+	4: "unknown",
+	5: "dormant",
+	6: "notPresent",
+	7: "lowerLayerDown",
+}
+
+// Interfaces : Walking ifTable and ifXTable of an SNMP agent
+type Interfaces struct {
+	Driver *driver.Driver
+	// Octet counters of the agent are read as bytes
+	RawByteSize string
+	// Unit the traffic rates are converted to before being sent
+	DisplayByteSize string
+	Values          []InterfaceMetrics
+	rates           *rates
+	hostState
+}
+
+// Parse : 64 bit counters of ifXTable are preferred over the ifTable
+// counters which wrap on busy interfaces
+func (i *Interfaces) Parse(output string) error {
+	log.Debug("Parsing output string in Interfaces inspector")
+	rows := make(map[string]*InterfaceMetrics)
+	var (
+		inOctets  = make(map[string]uint64)
+		outOctets = make(map[string]uint64)
+		highIn    = make(map[string]uint64)
+		highOut   = make(map[string]uint64)
+		highSpeed = make(map[string]uint64)
+	)
+	row := func(index string) *InterfaceMetrics {
+		if _, ok := rows[index]; !ok {
+			number, _ := strconv.Atoi(index)
+			rows[index] = &InterfaceMetrics{Index: number}
+		}
+		return rows[index]
+	}
+	variables, err := parseSNMP("Interfaces", output)
+	for _, variable := range variables {
+		value, _ := variable.Value.(string)
+		if column, index, ok := snmpIndex(variable.OID, ifTableOID); ok {
+			metrics := row(index)
+			switch column {
+			case 2:
+				metrics.Description = value
+			case 5:
+				metrics.Speed = float64(snmpUint(variable.Value)) / 1000000
+			case 7:
+				metrics.AdminStatus = ifStatus[snmpUint(variable.Value)]
+			case 8:
+				metrics.OperStatus = ifStatus[snmpUint(variable.Value)]
+			case 10:
+				inOctets[index] = snmpUint(variable.Value)
+			case 13:
+				metrics.InDiscards = snmpUint(variable.Value)
+			case 14:
+				metrics.InErrors = snmpUint(variable.Value)
+			case 16:
+				outOctets[index] = snmpUint(variable.Value)
+			case 19:
+				metrics.OutDiscards = snmpUint(variable.Value)
+			case 20:
+				metrics.OutErrors = snmpUint(variable.Value)
+			}
+		} else if column, index, ok := snmpIndex(variable.OID, ifXTableOID); ok {
+			metrics := row(index)
+			switch column {
+			case 1:
+				metrics.Name = value
+			case 6:
+				highIn[index] = snmpUint(variable.Value)
+			case 10:
+				highOut[index] = snmpUint(variable.Value)
+			case 15:
+				highSpeed[index] = snmpUint(variable.Value)
+			case 18:
+				metrics.Alias = value
+			}
+		}
+	}
+	values := []InterfaceMetrics{}
+	for index, metrics := range rows {
+		in, out := inOctets[index], outOctets[index]
+		inRate, outRate := i.rates.rate32, i.rates.rate32
+		if counter, ok := highIn[index]; ok {
+			in, inRate = counter, i.rates.rate
+		}
+		if counter, ok := highOut[index]; ok {
+			out, outRate = counter, i.rates.rate
+		}
+		// ifSpeed saturates at 4294967295 for links above 4Gbps
+		if speed := highSpeed[index]; speed > 0 {
+			metrics.Speed = float64(speed)
+		}
+		if metrics.Name == `` {
+			metrics.Name = metrics.Description
+		}
+		metrics.InBytes = byteSizeOf(float64(in), i.RawByteSize).format(i.DisplayByteSize)
+		metrics.OutBytes = byteSizeOf(float64(out), i.RawByteSize).format(i.DisplayByteSize)
+		metrics.InRate = byteSizeOf(inRate(index+`.in`, float64(in)), i.RawByteSize).format(i.DisplayByteSize)
+		metrics.OutRate = byteSizeOf(outRate(index+`.out`, float64(out)), i.RawByteSize).format(i.DisplayByteSize)
+		values = append(values, *metrics)
+	}
+	sort.Slice(values, func(a, b int) bool {
+		return values[a].Index < values[b].Index
+	})
+	i.Values = values
+	return err
+}
+
+func (i *Interfaces) SetDriver(driver *driver.Driver) {
+	details, _ := (*driver).GetDetails()
+	if !details.IsSNMP {
+		panic("Cannot use Interfaces on drivers outside (snmp)")
+	}
+	i.Driver = driver
+}
+
+func (i Interfaces) driverExec() driver.Command {
+	return walkSNMP(i.Driver)
+}
+
+func (i *Interfaces) Execute() (*Result, error) {
+	output, err := i.driverExec()(ifTableOID + ` ` + ifXTableOID)
+	if err == nil {
+		i.rates = newRates(i.stateKey)
+		err = i.Parse(output)
+		return newResult(i.Values, i.DisplayByteSize, err)
+	}
+	return nil, err
+}
+
+// NewInterfaces : Initialize a new Interfaces instance
+func NewInterfaces(driver *driver.Driver, _ ...string) (Inspector, error) {
+	details, err := (*driver).GetDetails()
+	if err != nil {
+		return nil, err
+	}
+	if !details.IsSNMP {
+		return nil, errors.New("Cannot use Interfaces on drivers outside (snmp)")
+	}
+	interfaces := &Interfaces{
+		RawByteSize:     `B`,
+		DisplayByteSize: defaultDisplayByteSize,
+	}
+	interfaces.SetDriver(driver)
+	return interfaces, nil
+}
+
+// UptimeSNMP : Reading the uptime of an SNMP agent
+type UptimeSNMP struct {
+	Driver *driver.Driver
+	Values *UptimeMetrics
+}
+
+// Parse : uptimes are in hundredths of a second, idle time is not
+// available over SNMP
+func (i *UptimeSNMP) Parse(output string) error {
+	log.Debug("Parsing output string in UptimeSNMP inspector")
+	i.Values = &UptimeMetrics{}
+	variables, err := parseSNMP("UptimeSNMP", output)
+	for _, variable := range variables {
+		if variable.Found() {
+			i.Values.Up = float64(snmpUint(variable.Value)) / 100
+			break
+		}
+	}
+	return err
+}
+
+func (i *UptimeSNMP) SetDriver(driver *driver.Driver) {
+	i.Driver = driver
+}
+
+func (i UptimeSNMP) driverExec() driver.Command {
+	return getSNMP(i.Driver)
+}
+
+func (i *UptimeSNMP) Execute() (*Result, error) {
+	// network devices without HOST-RESOURCES-MIB fall back to sysUpTime
+	output, err := i.driverExec()(hrSystemUptimeOID + ` ` + sysUpTimeOID)
+	if err == nil {
+		err = i.Parse(output)
+		return newResult(i.Values, ``, err)
+	}
+	return nil, err
+}
+
+// SNMPOIDMetrics : Metrics used by SNMPOID
+type SNMPOIDMetrics struct {
+	Name  string      `desc:"Configured name followed by the instance suffix of walked OIDs"`
+	OID   string      `desc:"OID read"`
+	Type  string      `desc:"SNMP type of the value"`
+	Value interface{} `desc:"Value of the OID"`
+}
+
+// SNMPOID : Reading a configured list of OIDs, OIDs that are not scalar
+// objects are walked
+type SNMPOID struct {
+	Driver *driver.Driver
+	// Names of the configured OIDs
+	Names  map[string]string
+	OIDs   []string
+	Values []SNMPOIDMetrics
+}
+
+// Parse : walked variables are named after the configured name and the
+// instance suffix of their OID e.g ifInOctets.2
+func (i *SNMPOID) Parse(output string) error {
+	log.Debug("Parsing output string in SNMPOID inspector")
+	values := []SNMPOIDMetrics{}
+	variables, err := parseSNMP("SNMPOID", output)
+	for _, variable := range variables {
+		metrics := SNMPOIDMetrics{
+			Name:  variable.OID,
+			OID:   variable.OID,
+			Type:  variable.Type,
+			Value: variable.Value,
+		}
+		for _, oid := range i.OIDs {
+			if variable.OID == oid {
+				metrics.Name = i.Names[oid]
+				break
+			}
+			if strings.HasPrefix(variable.OID, oid+".") {
+				metrics.Name = i.Names[oid] + strings.TrimPrefix(variable.OID, oid)
+				break
+			}
+		}
+		values = append(values, metrics)
+	}
+	i.Values = values
+	return err
+}
+
+func (i *SNMPOID) SetDriver(driver *driver.Driver) {
+	details, _ := (*driver).GetDetails()
+	if !details.IsSNMP {
+		panic("Cannot use SNMPOID on drivers outside (snmp)")
+	}
+	i.Driver = driver
+}
+
+func (i SNMPOID) driverExec() driver.Command {
+	return getSNMP(i.Driver)
+}
+
+func (i *SNMPOID) Execute() (*Result, error) {
+	output, err := i.driverExec()(strings.Join(i.OIDs, ` `))
+	if err == nil {
+		err = i.Parse(output)
+		return newResult(i.Values, ``, err)
+	}
+	return nil, err
+}
+
+// NewSNMPOID : Initialize a new SNMPOID instance from a comma separated
+// list of `name=oid` or `oid`
+func NewSNMPOID(driver *driver.Driver, custom ...string) (Inspector, error) {
+	details, err := (*driver).GetDetails()
+	if err != nil {
+		return nil, err
+	}
+	if !details.IsSNMP {
+		return nil, errors.New("Cannot use SNMPOID on drivers outside (snmp)")
+	}
+	if len(custom) < 1 || strings.TrimSpace(custom[0]) == `` {
+		return nil, errors.New("Must specify OIDs for snmp")
+	}
+	oidInspector := &SNMPOID{Names: make(map[string]string)}
+	for _, entry := range strings.Split(custom[0], ",") {
+		name, oid := ``, strings.TrimSpace(entry)
+		if parts := strings.SplitN(entry, "=", 2); len(parts) == 2 {
+			name, oid = strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+		}
+		oid = strings.TrimPrefix(oid, ".")
+		for _, part := range strings.Split(oid, ".") {
+			if _, err := strconv.ParseUint(part, 10, 32); err != nil {
+				return nil, fmt.Errorf("Invalid OID %s for snmp", oid)
+			}
+		}
+		if name == `` {
+			name = oid
+		}
+		oidInspector.Names[oid] = name
+		oidInspector.OIDs = append(oidInspector.OIDs, oid)
+	}
+	oidInspector.SetDriver(driver)
+	return oidInspector, nil
+}
@@ -0,0 +1,126 @@
+package inspector
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bisohns/saido/driver"
+)
+
+// fakeSNMP : serves variables from a fixed table for snmp inspectors
+type fakeSNMP struct {
+	driver.Local
+	variables []driver.SNMPVariable
+}
+
+func (d *fakeSNMP) SNMPGet(oids []string) ([]driver.SNMPVariable, error) {
+	variables := []driver.SNMPVariable{}
+	for _, oid := range oids {
+		found := driver.SNMPVariable{OID: oid, Type: "NoSuchObject"}
+		for _, variable := range d.variables {
+			if variable.OID == oid {
+				found = variable
+			}
+		}
+		variables = append(variables, found)
+	}
+	return variables, nil
+}
+
+func (d *fakeSNMP) SNMPWalk(root string) ([]driver.SNMPVariable, error) {
+	variables := []driver.SNMPVariable{}
+	for _, variable := range d.variables {
+		if strings.HasPrefix(variable.OID, root+".") {
+			variables = append(variables, variable)
+		}
+	}
+	return variables, nil
+}
+
+func (d *fakeSNMP) GetDetails() (driver.SystemDetails, error) {
+	return driver.SystemDetails{Name: "core-sw-1", IsSNMP: true}, nil
+}
+
+func newFakeSNMP() driver.Driver {
+	return &fakeSNMP{variables: []driver.SNMPVariable{
+		{OID: "1.3.6.1.2.1.1.3.0", Type: "TimeTicks", Value: uint64(123456)},
+		{OID: "1.3.6.1.2.1.2.2.1.2.1", Type: "OctetString", Value: "GigabitEthernet0/1"},
+		{OID: "1.3.6.1.2.1.2.2.1.2.2", Type: "OctetString", Value: "Null0"},
+		{OID: "1.3.6.1.2.1.2.2.1.5.1", Type: "Gauge32", Value: uint64(4294967295)},
+		{OID: "1.3.6.1.2.1.2.2.1.5.2", Type: "Gauge32", Value: uint64(10000000)},
+		{OID: "1.3.6.1.2.1.2.2.1.7.1", Type: "Integer", Value: int64(1)},
+		{OID: "1.3.6.1.2.1.2.2.1.8.1", Type: "Integer", Value: int64(1)},
+		{OID: "1.3.6.1.2.1.2.2.1.8.2", Type: "Integer", Value: int64(7)},
+		{OID: "1.3.6.1.2.1.2.2.1.10.1", Type: "Counter32", Value: uint64(1048576)},
+		{OID: "1.3.6.1.2.1.2.2.1.10.2", Type: "Counter32", Value: uint64(2097152)},
+		{OID: "1.3.6.1.2.1.2.2.1.14.1", Type: "Counter32", Value: uint64(3)},
+		{OID: "1.3.6.1.2.1.31.1.1.1.1.1", Type: "OctetString", Value: "Gi0/1"},
+		{OID: "1.3.6.1.2.1.31.1.1.1.6.1", Type: "Counter64", Value: uint64(10737418240)},
+		{OID: "1.3.6.1.2.1.31.1.1.1.15.1", Type: "Gauge32", Value: uint64(10000)},
+		{OID: "1.3.6.1.2.1.31.1.1.1.18.1", Type: "OctetString", Value: "uplink"},
+	}}
+}
+
+func TestInterfacesOnSNMP(t *testing.T) {
+	d := newFakeSNMP()
+	i, err := Init(`interfaces`, &d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := i.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	values := i.(*Interfaces).Values
+	if len(values) != 2 {
+		t.Fatalf("Expected 2 interfaces, found %v", values)
+	}
+	uplink := values[0]
+	if uplink.Name != "Gi0/1" || uplink.Alias != "uplink" || uplink.OperStatus != "up" || uplink.AdminStatus != "up" {
+		t.Errorf("Unexpected uplink %v", uplink)
+	}
+	if uplink.Speed != 10000 || uplink.InBytes != 10240 || uplink.InErrors != 3 {
+		t.Errorf("Expected high speed counters to be preferred, found %v", uplink)
+	}
+	null := values[1]
+	if null.Name != "Null0" || null.OperStatus != "lowerLayerDown" || null.Speed != 10 || null.InBytes != 2 {
+		t.Errorf("Unexpected fallback to ifTable %v", null)
+	}
+}
+
+func TestUptimeOnSNMP(t *testing.T) {
+	d := newFakeSNMP()
+	i, err := NewUptime(&d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := i.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if up := i.(*UptimeSNMP).Values.Up; up != 1234.56 {
+		t.Errorf("Expected sysUpTime fallback of 1234.56, found %f", up)
+	}
+}
+
+func TestSNMPOIDOnSNMP(t *testing.T) {
+	d := newFakeSNMP()
+	i, err := Init(`snmp-ports`, &d, `uptime=.1.3.6.1.2.1.1.3.0, inOctets=1.3.6.1.2.1.2.2.1.10`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := i.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	values := i.(*SNMPOID).Values
+	if len(values) != 3 {
+		t.Fatalf("Expected 3 values, found %v", values)
+	}
+	if values[0].Name != "uptime" || values[1].Name != "inOctets.1" || values[2].Name != "inOctets.2" {
+		t.Errorf("Unexpected names %v", values)
+	}
+	if _, err := Init(`snmp-bad`, &d, `name=1.3.six`); err == nil {
+		t.Error("Expected error for invalid OID")
+	}
+	if !Valid(`snmp-ports`) {
+		t.Error("Expected snmp prefixed inspectors to be valid")
+	}
+}
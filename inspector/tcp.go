@@ -2,7 +2,6 @@
 package inspector
 
 import (
-	"encoding/json"
 	"errors"
 	"strconv"
 	"strings"
@@ -15,7 +14,7 @@ import (
 type TcpMetrics struct {
 	// Ports map a port to a status string
 	// e.g {8081: "LISTEN"}
-	Ports map[int]string
+	Ports map[int]string `desc:"Status of every local port e.g 8081: LISTEN"`
 }
 
 type TcpDarwin struct {
@@ -48,7 +47,7 @@ tcp4       0      0  192.168.1.172.59964    162.247.243.147.443    SYN_SENT
 tcp4       0      0  192.168.1.172.59931    13.224.227.146.443     ESTABLISHED
 tcp4       0      0  127.0.0.1.59905        127.0.0.1.53300        CLOSE_WAIT
 */
-func (i *TcpDarwin) Parse(output string) {
+func (i *TcpDarwin) Parse(output string) error {
 	ports := make(map[int]string)
 	lines := strings.Split(output, "\n")
 	for index, line := range lines {
@@ -71,6 +70,7 @@ func (i *TcpDarwin) Parse(output string) {
 		}
 	}
 	i.Values.Ports = ports
+	return nil
 }
 
 func (i *TcpDarwin) SetDriver(driver *driver.Driver) {
@@ -85,13 +85,13 @@ func (i TcpDarwin) driverExec() driver.Command {
 	return (*i.Driver).RunCommand
 }
 
-func (i *TcpDarwin) Execute() ([]byte, error) {
+func (i *TcpDarwin) Execute() (*Result, error) {
 	output, err := i.driverExec()(i.Command)
 	if err == nil {
-		i.Parse(output)
-		return json.Marshal(i.Values)
+		err = i.Parse(output)
+		return newResult(i.Values, ``, err)
 	}
-	return []byte(""), err
+	return nil, err
 }
 
 /*
@@ -107,7 +107,7 @@ Parse for output (netstat)
 Proto Recv-Q Send-Q Local Address           Foreign Address         State       PID/Program name
 tcp        0      0 172.17.0.2:2222         172.17.0.1:51874        ESTABLISHED 2104/sshd.pam: ci-d
 */
-func (i *TcpLinux) Parse(output string) {
+func (i *TcpLinux) Parse(output string) error {
 	ports := make(map[int]string)
 	lines := strings.Split(output, "\n")
 	for index, line := range lines {
@@ -137,6 +137,7 @@ func (i *TcpLinux) Parse(output string) {
 		}
 	}
 	i.Values.Ports = ports
+	return nil
 }
 
 func (i *TcpLinux) SetDriver(driver *driver.Driver) {
@@ -151,17 +152,17 @@ func (i TcpLinux) driverExec() driver.Command {
 	return (*i.Driver).RunCommand
 }
 
-func (i *TcpLinux) Execute() ([]byte, error) {
+func (i *TcpLinux) Execute() (*Result, error) {
 	output, err := i.driverExec()(i.Command)
 	if err != nil {
 		output, err = i.driverExec()(i.BackupCommand)
 		i.UseBackup = true
 	}
 	if err == nil {
-		i.Parse(output)
-		return json.Marshal(i.Values)
+		err = i.Parse(output)
+		return newResult(i.Values, ``, err)
 	}
-	return []byte(""), err
+	return nil, err
 }
 
 /*
@@ -177,7 +178,7 @@ Active Connections
 	TCP    0.0.0.0:6646           0.0.0.0:0              LISTENING
 	TCP    0.0.0.0:49664          0.0.0.0:0              LISTENING
 */
-func (i *TcpWin) Parse(output string) {
+func (i *TcpWin) Parse(output string) error {
 	ports := make(map[int]string)
 	lines := strings.Split(output, "\n")
 	for index, line := range lines {
@@ -200,6 +201,7 @@ func (i *TcpWin) Parse(output string) {
 		}
 	}
 	i.Values.Ports = ports
+	return nil
 }
 
 func (i *TcpWin) SetDriver(driver *driver.Driver) {
@@ -214,13 +216,13 @@ func (i TcpWin) driverExec() driver.Command {
 	return (*i.Driver).RunCommand
 }
 
-func (i *TcpWin) Execute() ([]byte, error) {
+func (i *TcpWin) Execute() (*Result, error) {
 	output, err := i.driverExec()(i.Command)
 	if err == nil {
-		i.Parse(output)
-		return json.Marshal(i.Values)
+		err = i.Parse(output)
+		return newResult(i.Values, ``, err)
 	}
-	return []byte(""), err
+	return nil, err
 }
 
 // NewTcp: Initialize a new Tcp instance
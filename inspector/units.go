@@ -0,0 +1,183 @@
+package inspector
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/bisohns/saido/config"
+)
+
+// AutoByteSize : displays every byte size field in the largest unit its
+// values are at least 1 of
+const AutoByteSize = `auto`
+
+var (
+	displayUnitsMu sync.RWMutex
+	displayUnits   config.DisplayUnits
+)
+
+// SetDisplayUnits : units byte sizes of collected results are converted
+// to, results keep the units of their inspector when units are empty
+func SetDisplayUnits(units config.DisplayUnits) {
+	displayUnitsMu.Lock()
+	defer displayUnitsMu.Unlock()
+	displayUnits = units
+}
+
+// unitsOf : byte size and standard metric is displayed in, an empty byte
+// size keeps the units of the inspector
+func unitsOf(metric string) (string, string) {
+	displayUnitsMu.RLock()
+	defer displayUnitsMu.RUnlock()
+	bytes, ok := displayUnits.Metrics[metric]
+	if !ok {
+		bytes, ok = displayUnits.Metrics[registryName(metric)]
+	}
+	if !ok {
+		bytes = displayUnits.Bytes
+	}
+	if bytes == `` && displayUnits.Standard != `` {
+		bytes = defaultDisplayByteSize
+	}
+	return bytes, displayUnits.Standard
+}
+
+// byteUnit : bytes in a unit of results and whether it is per second,
+// unit is not a byte size when bytes is 0. Units without i are powers of
+// 1024 as displayed by inspectors apart from kB
+func byteUnit(unit string) (float64, bool) {
+	perSecond := strings.HasSuffix(unit, "/s")
+	unit = strings.TrimSuffix(unit, "/s")
+	for power := range byteMap {
+		if unit == byteMap[power] || unit == iecByteMap[power] {
+			return math.Pow(1024, float64(power)), perSecond
+		}
+	}
+	if unit == siByteMap[1] {
+		return 1000, perSecond
+	}
+	return 0, perSecond
+}
+
+// displayUnit : base and labels of byte sizes of standard
+func displayUnit(standard string) (float64, []string) {
+	switch standard {
+	case `si`:
+		return 1000, siByteMap
+	case `iec`:
+		return 1024, iecByteMap
+	}
+	return 1024, byteMap
+}
+
+// displayedUnit : unit schemas show for a field of metric read in unit,
+// auto when the unit is picked from the values of every result
+func displayedUnit(metric string, unit string) string {
+	factor, perSecond := byteUnit(unit)
+	bytes, standard := unitsOf(metric)
+	if factor == 0 || bytes == `` {
+		return unit
+	}
+	displayed := AutoByteSize
+	if bytes != AutoByteSize {
+		_, labels := displayUnit(standard)
+		displayed = labels[int(index(byteMap, bytes))]
+	}
+	if perSecond {
+		displayed += "/s"
+	}
+	return displayed
+}
+
+// convertUnits : convert byte size fields of result to the units metric
+// is displayed in along with their units
+func convertUnits(metric string, result *Result) {
+	bytes, standard := unitsOf(metric)
+	if bytes == `` || len(result.Units) == 0 {
+		return
+	}
+	base, labels := displayUnit(standard)
+	values := reflect.ValueOf(&result.Values).Elem()
+	units := make(map[string]string, len(result.Units))
+	for field, unit := range result.Units {
+		units[field] = unit
+		factor, perSecond := byteUnit(unit)
+		if factor == 0 {
+			continue
+		}
+		power := int(index(byteMap, bytes))
+		if bytes == AutoByteSize {
+			largest := 0.0
+			eachField(values, field, func(value float64) float64 {
+				largest = math.Max(largest, math.Abs(value*factor))
+				return value
+			})
+			power = 0
+			for power < len(labels)-1 && largest >= math.Pow(base, float64(power+1)) {
+				power++
+			}
+		}
+		scale := factor / math.Pow(base, float64(power))
+		eachField(values, field, func(value float64) float64 {
+			return value * scale
+		})
+		units[field] = labels[power]
+		if perSecond {
+			units[field] += "/s"
+		}
+	}
+	result.Units = units
+}
+
+// eachField : replace numbers of field in value by apply of them, value
+// is a struct, a map of a json object or a slice or pointer of them
+func eachField(value reflect.Value, field string, apply func(float64) float64) {
+	switch value.Kind() {
+	case reflect.Interface:
+		if value.IsNil() {
+			return
+		}
+		elem := value.Elem()
+		// values held by interfaces cannot be set so a copy is changed
+		if elem.Kind() == reflect.Struct {
+			copied := reflect.New(elem.Type()).Elem()
+			copied.Set(elem)
+			eachField(copied, field, apply)
+			if value.CanSet() {
+				value.Set(copied)
+			}
+			return
+		}
+		eachField(elem, field, apply)
+	case reflect.Ptr:
+		if !value.IsNil() {
+			eachField(value.Elem(), field, apply)
+		}
+	case reflect.Slice, reflect.Array:
+		for index := 0; index < value.Len(); index++ {
+			eachField(value.Index(index), field, apply)
+		}
+	case reflect.Struct:
+		number := value.FieldByName(field)
+		if number.IsValid() && number.CanSet() && (number.Kind() == reflect.Float64 || number.Kind() == reflect.Float32) {
+			number.SetFloat(apply(number.Float()))
+		}
+	case reflect.Map:
+		if value.Type().Key().Kind() != reflect.String {
+			return
+		}
+		key := reflect.ValueOf(field)
+		number := value.MapIndex(key)
+		if !number.IsValid() {
+			return
+		}
+		if number.Kind() == reflect.Interface {
+			number = number.Elem()
+		}
+		if number.Kind() == reflect.Float64 {
+			value.SetMapIndex(key, reflect.ValueOf(apply(number.Float())))
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package inspector
+
+import (
+	"testing"
+
+	"github.com/bisohns/saido/config"
+)
+
+func TestConvertUnits(t *testing.T) {
+	defer SetDisplayUnits(config.DisplayUnits{})
+	disks := func() *Result {
+		result, _ := newResult([]DFMetrics{
+			{FileSystem: "array", Size: 20 * 1024 * 1024, Used: 512},
+			{FileSystem: "boot", Size: 1024, Used: 256},
+		}, `MB`, nil)
+		return result
+	}
+
+	SetDisplayUnits(config.DisplayUnits{})
+	kept := disks()
+	convertUnits("disk", kept)
+	if kept.Units["Size"] != "MB" || kept.Values.([]DFMetrics)[0].Size != 20*1024*1024 {
+		t.Errorf("Expected units of the inspector to be kept, found %+v", kept)
+	}
+
+	SetDisplayUnits(config.DisplayUnits{Bytes: "MB", Metrics: map[string]string{"disk": AutoByteSize}})
+	auto := disks()
+	convertUnits("disk", auto)
+	values := auto.Values.([]DFMetrics)
+	if auto.Units["Size"] != "TB" || values[0].Size != 20 || values[1].Size != 1024.0/1024/1024 {
+		t.Errorf("Expected sizes in TB, found %+v %+v", auto.Units, values)
+	}
+	if auto.Units["Used"] != "MB" || values[0].Used != 512 {
+		t.Errorf("Expected used in MB, found %+v %+v", auto.Units, values)
+	}
+
+	SetDisplayUnits(config.DisplayUnits{Bytes: "GB", Standard: "si"})
+	si := disks()
+	convertUnits("disk", si)
+	if size := si.Values.([]DFMetrics)[1].Size; si.Units["Size"] != "GB" || size != 1.073741824 {
+		t.Errorf("Expected size in powers of 1000, found %s %f", si.Units["Size"], size)
+	}
+
+	// values of agents and declared inspectors are json objects
+	SetDisplayUnits(config.DisplayUnits{Standard: "iec"})
+	remote := &Result{
+		Units:  map[string]string{"MemTotal": "KB", "RxRate": "B/s", "Temp": "C"},
+		Values: map[string]interface{}{"MemTotal": float64(2048), "RxRate": float64(1024 * 1024), "Temp": float64(40)},
+	}
+	convertUnits("memory", remote)
+	object := remote.Values.(map[string]interface{})
+	if remote.Units["MemTotal"] != "MiB" || object["MemTotal"] != float64(2) {
+		t.Errorf("Expected memory in MiB, found %+v %+v", remote.Units, object)
+	}
+	if remote.Units["RxRate"] != "MiB/s" || object["RxRate"] != float64(1) {
+		t.Errorf("Expected rate in MiB/s, found %+v %+v", remote.Units, object)
+	}
+	if remote.Units["Temp"] != "C" || object["Temp"] != float64(40) {
+		t.Errorf("Expected other units to be kept, found %+v %+v", remote.Units, object)
+	}
+}
+
+func TestSchemaDisplayUnits(t *testing.T) {
+	defer SetDisplayUnits(config.DisplayUnits{})
+	unitOf := func(metric string, field string) string {
+		schema, _ := GetSchema(metric)
+		for _, f := range schema.Fields {
+			if f.Name == field {
+				return f.Unit
+			}
+		}
+		return ``
+	}
+	if unit := unitOf("disk", "Size"); unit != "MB" {
+		t.Errorf("Expected default unit, found %s", unit)
+	}
+	SetDisplayUnits(config.DisplayUnits{Bytes: "GB", Standard: "iec", Metrics: map[string]string{"disk": AutoByteSize}})
+	if unit := unitOf("memory", "MemTotal"); unit != "GiB" {
+		t.Errorf("Expected configured unit, found %s", unit)
+	}
+	if unit := unitOf("network", "RxRate"); unit != "GiB/s" {
+		t.Errorf("Expected configured unit per second, found %s", unit)
+	}
+	if unit := unitOf("disk", "Size"); unit != AutoByteSize {
+		t.Errorf("Expected auto unit of disk, found %s", unit)
+	}
+	if unit := unitOf("disk", "PercentFull"); unit != "%" {
+		t.Errorf("Expected other units to be kept, found %s", unit)
+	}
+}
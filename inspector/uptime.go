@@ -1,10 +1,8 @@
 package inspector
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
-	"strconv"
 	"strings"
 
 	"github.com/bisohns/saido/driver"
@@ -13,15 +11,15 @@ import (
 
 // UptimeMetrics : Metrics used by Uptime
 type UptimeMetrics struct {
-	Up float64
+	Up float64 `unit:"s" desc:"Time since boot"`
 	// Idle time will not be less than uptime on
 	// multiprocessor systems as the metric being
 	// returned is the idle time from all processors
 	// e.g 80 on an 8 processor system means each
 	// processor has been idle for an average of 10 seconds
-	Idle float64
+	Idle float64 `unit:"s" kind:"counter" desc:"Idle time summed over processors"`
 	// % of time CPU has been idle
-	IdlePercent float64
+	IdlePercent float64 `unit:"%" desc:"Time CPU has been idle"`
 }
 
 // UptimeLinux : Parsing the /proc/uptime output for uptime monitoring
@@ -48,21 +46,24 @@ type UptimeWindows struct {
 /*
 1545.95 12026.34
 */
-func (i *UptimeLinux) Parse(output string) {
+func (i *UptimeLinux) Parse(output string) error {
 	fmt.Print(output)
-	var err error
 	log.Debug("Parsing output string in Uptime inspector")
+	errs := newParseErrors("UptimeLinux")
 	columns := strings.Fields(output)
-	Up, err := strconv.ParseFloat(columns[0], 64)
-	Idle, err := strconv.ParseFloat(columns[1], 64)
-	if err != nil {
-		log.Fatalf(`Error Parsing Uptime: %s `, err)
+	if len(columns) < 2 {
+		errs.add("expected up and idle times in %q", output)
+		i.Values = &UptimeMetrics{}
+		return errs.err()
 	}
+	Up := errs.float(columns[0], "up time")
+	Idle := errs.float(columns[1], "idle time")
 
 	i.Values = &UptimeMetrics{
 		Up:   Up,
 		Idle: Idle,
 	}
+	return errs.err()
 }
 
 func (i *UptimeLinux) SetDriver(driver *driver.Driver) {
@@ -77,13 +78,13 @@ func (i UptimeLinux) driverExec() driver.Command {
 	return (*i.Driver).ReadFile
 }
 
-func (i *UptimeLinux) Execute() ([]byte, error) {
+func (i *UptimeLinux) Execute() (*Result, error) {
 	output, err := i.driverExec()(i.FilePath)
 	if err == nil {
-		i.Parse(output)
-		return json.Marshal(i.Values)
+		err = i.Parse(output)
+		return newResult(i.Values, ``, err)
 	}
-	return []byte(""), err
+	return nil, err
 }
 
 // Parse : Parsing output of uptime commands on darwin
@@ -92,22 +93,24 @@ func (i *UptimeLinux) Execute() ([]byte, error) {
 1646035560
 34.96
 */
-func (i *UptimeDarwin) Parse(output string) {
+func (i *UptimeDarwin) Parse(output string) error {
 	fmt.Print(output)
 	log.Debug("Parsing output string in UptimeDarwin inspector")
+	errs := newParseErrors("UptimeDarwin")
 	output = strings.TrimSuffix(output, ",")
 	lines := strings.Split(output, "\n")
-	unixTime, err := strconv.Atoi(lines[0])
-	switchedOn, err := strconv.Atoi(lines[1])
-	idleTime, err := strconv.ParseFloat(lines[2], 64)
-	if err != nil {
-		log.Errorf("Could not parse times in UptimeDarwin: %e", err)
-	} else {
-		i.Values = &UptimeMetrics{
-			Up:          float64(unixTime - switchedOn),
-			IdlePercent: idleTime,
-		}
+	if len(lines) < 3 {
+		errs.add("expected time, boot time and idle percentage in %q", output)
+		return errs.err()
 	}
+	unixTime := errs.int(lines[0], "time")
+	switchedOn := errs.int(lines[1], "boot time")
+	idleTime := errs.float(lines[2], "idle percentage")
+	i.Values = &UptimeMetrics{
+		Up:          float64(unixTime - switchedOn),
+		IdlePercent: idleTime,
+	}
+	return errs.err()
 }
 
 func (i *UptimeDarwin) SetDriver(driver *driver.Driver) {
@@ -122,7 +125,7 @@ func (i UptimeDarwin) driverExec() driver.Command {
 	return (*i.Driver).RunCommand
 }
 
-func (i *UptimeDarwin) Execute() ([]byte, error) {
+func (i *UptimeDarwin) Execute() (*Result, error) {
 	upOutput, err := i.driverExec()(i.UpCommand)
 	idleOutput, err := i.driverExec()(i.IdleCommand)
 	if err == nil {
@@ -131,10 +134,10 @@ func (i *UptimeDarwin) Execute() ([]byte, error) {
 		idleOutput = strings.TrimSpace(idleOutput)
 		idleOutput = strings.TrimSuffix(idleOutput, "%")
 		output := fmt.Sprintf("%s\n%s", upOutput, idleOutput)
-		i.Parse(output)
-		return json.Marshal(i.Values)
+		err = i.Parse(output)
+		return newResult(i.Values, ``, err)
 	}
-	return []byte(""), err
+	return nil, err
 }
 
 /* Parse : SystemUpTime on windows
@@ -143,19 +146,20 @@ SystemUpTime
 162054
 
 */
-func (i *UptimeWindows) Parse(output string) {
+func (i *UptimeWindows) Parse(output string) error {
 	log.Debug("Parsing output string in UptimeWindows inspector")
+	errs := newParseErrors("UptimeWindows")
 	output = strings.ReplaceAll(output, "\r", "")
 	output = strings.ReplaceAll(output, " ", "")
-	upUnformatted := strings.Split(output, "\n")[1]
-	up, err := strconv.ParseFloat(upUnformatted, 64)
-	if err != nil {
-		log.Error("Error parsing windows uptime", err)
-	} else {
-		i.Values = &UptimeMetrics{
-			Up: up,
-		}
+	lines := strings.Split(output, "\n")
+	if len(lines) < 2 {
+		errs.add("no SystemUpTime in %q", output)
+		return errs.err()
+	}
+	i.Values = &UptimeMetrics{
+		Up: errs.float(lines[1], "up time"),
 	}
+	return errs.err()
 }
 
 func (i *UptimeWindows) SetDriver(driver *driver.Driver) {
@@ -170,13 +174,13 @@ func (i UptimeWindows) driverExec() driver.Command {
 	return (*i.Driver).RunCommand
 }
 
-func (i *UptimeWindows) Execute() ([]byte, error) {
+func (i *UptimeWindows) Execute() (*Result, error) {
 	output, err := i.driverExec()(i.UpCommand)
 	if err == nil {
-		i.Parse(output)
-		return json.Marshal(i.Values)
+		err = i.Parse(output)
+		return newResult(i.Values, ``, err)
 	}
-	return []byte(""), err
+	return nil, err
 }
 
 // NewUptime : Initialize a new Uptime instance
@@ -186,6 +190,16 @@ func NewUptime(driver *driver.Driver, _ ...string) (Inspector, error) {
 	if err != nil {
 		return nil, err
 	}
+	if details.IsNodeExporter {
+		uptime = &UptimeNodeExporter{}
+		uptime.SetDriver(driver)
+		return uptime, nil
+	}
+	if details.IsSNMP {
+		uptime = &UptimeSNMP{}
+		uptime.SetDriver(driver)
+		return uptime, nil
+	}
 	if !(details.IsDarwin || details.IsLinux || details.IsWindows) {
 		return nil, errors.New("Cannot use Uptime on drivers outside (linux, darwin, windows)")
 	}
@@ -2,13 +2,22 @@ package inspector
 
 import (
 	"fmt"
-	log "github.com/sirupsen/logrus"
 	"math"
+	"regexp"
 	"strconv"
+	"strings"
 )
 
 var byteMap = []string{"B", "KB", "MB", "GB", "TB"}
 
+// iecByteMap, siByteMap : labels of powers of 1024 and 1000
+var (
+	iecByteMap = []string{"B", "KiB", "MiB", "GiB", "TiB"}
+	siByteMap  = []string{"B", "kB", "MB", "GB", "TB"}
+)
+
+var sizePattern = regexp.MustCompile(`^([0-9]*\.?[0-9]+)\s*([A-Za-z]*)$`)
+
 // ByteSize : helps parse string into individual byte values
 type ByteSize struct {
 	value float64
@@ -19,17 +28,56 @@ func (b *ByteSize) format(unit string) float64 {
 }
 
 // Initialize a NewByteSize
-func NewByteSize(byteCount string, unit string) *ByteSize {
+func NewByteSize(byteCount string, unit string) (*ByteSize, error) {
 	if byteCount == `-` {
 		byteCount = "0"
 	}
 	byteCountInt, err := strconv.ParseFloat(byteCount, 64)
-	byteCountInt = byteCountInt * math.Pow(1024, index(byteMap, unit))
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
+	}
+	return byteSizeOf(byteCountInt, unit), nil
+}
+
+// ParseByteSize : size printed along with its unit e.g 1.5GB or 796KiB
+// by tools like docker, xB units are powers of 1000 and xiB of 1024
+func ParseByteSize(size string) (*ByteSize, error) {
+	if size == `-` || size == `` {
+		return &ByteSize{}, nil
+	}
+	match := sizePattern.FindStringSubmatch(strings.TrimSpace(size))
+	if match == nil {
+		return nil, fmt.Errorf("invalid size %q", size)
+	}
+	count, err := strconv.ParseFloat(match[1], 64)
+	if err != nil {
+		return nil, err
+	}
+	unit := strings.ToUpper(match[2])
+	base := 1000.0
+	if strings.HasSuffix(unit, "IB") {
+		base = 1024
+		unit = strings.Replace(unit, "I", "", 1)
+	}
+	if unit == "" {
+		unit = "B"
+	}
+	power := -1
+	for index, name := range byteMap {
+		if name == unit || (index > 0 && name[:1] == unit) {
+			power = index
+		}
+	}
+	if power < 0 {
+		return nil, fmt.Errorf("unknown unit of size %q", size)
 	}
+	return &ByteSize{value: count * math.Pow(base, float64(power))}, nil
+}
+
+// byteSizeOf : ByteSize of a value already parsed
+func byteSizeOf(byteCount float64, unit string) *ByteSize {
 	return &ByteSize{
-		value: byteCountInt,
+		value: byteCount * math.Pow(1024, index(byteMap, unit)),
 	}
 }
 
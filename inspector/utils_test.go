@@ -6,11 +6,17 @@ import (
 )
 
 func TestByteSize(t *testing.T) {
-	d := NewByteSize(`1000`, `KB`)
+	d, err := NewByteSize(`1000`, `KB`)
+	if err != nil {
+		t.Fatal(err)
+	}
 	if d.value != 1024000 {
 		t.Error("Did not set byte value correctly")
 	}
-	second := NewByteSize(`0.9765625`, `MB`)
+	second, err := NewByteSize(`0.9765625`, `MB`)
+	if err != nil {
+		t.Fatal(err)
+	}
 	if second.value != d.value {
 		fmt.Println(second.value, d.value)
 		t.Error("MB value not equivalent to KB value")
@@ -18,4 +24,34 @@ func TestByteSize(t *testing.T) {
 	if second.format(`KB`) != 1000 {
 		t.Error("Could not convert MB back to KB value")
 	}
+	if _, err := NewByteSize(`1.5GB`, `GB`); err == nil {
+		t.Error("Expected error for byte count with unit")
+	}
+}
+
+func TestParseByteSize(t *testing.T) {
+	sizes := map[string]float64{
+		`1.5GB`:  1.5e9,
+		`796KiB`: 796 * 1024,
+		`1.2kB`:  1200,
+		`64MiB`:  64 * 1024 * 1024,
+		`648B`:   648,
+		`12 MB`:  12e6,
+		`0`:      0,
+		`-`:      0,
+		`.5TiB`:  0.5 * 1024 * 1024 * 1024 * 1024,
+	}
+	for size, expected := range sizes {
+		parsed, err := ParseByteSize(size)
+		if err != nil {
+			t.Errorf("Could not parse %s: %s", size, err)
+		} else if parsed.value != expected {
+			t.Errorf("Expected %s to be %f, found %f", size, expected, parsed.value)
+		}
+	}
+	for _, size := range []string{`GB`, `1.5PB`, `1.5 G B`} {
+		if _, err := ParseByteSize(size); err == nil {
+			t.Errorf("Expected error for %s", size)
+		}
+	}
 }
@@ -9,7 +9,7 @@ import ThemeConfig from "ThemeConfig";
 import { Box } from "@mui/material";
 
 function App() {
-  const { serversGroupedByHost, connectionStatus, setJsonMessage } =
+  const { serversGroupedByHost, statuses, connectionStatus, setJsonMessage } =
     useSocket();
 
   useEffect(() => {
@@ -22,6 +22,7 @@ function App() {
       element: (
         <ServerList
           servers={serversGroupedByHost}
+          statuses={statuses}
           connectionStatus={connectionStatus}
           setJsonMessage={setJsonMessage}
         />
@@ -1,6 +1,8 @@
 import { useEffect, useState } from "react";
 import useWebSocket, { ReadyState } from "react-use-websocket";
 import {
+  HostStatus,
+  HostStatusResponseType,
   ServerGroupedByHostResponseType,
   ServerResponseByHostType,
   ServerResponseType,
@@ -15,6 +17,7 @@ const wssMetricsURL = `${
 */
 export default function useSocket(options = {}) {
   const [servers, setServers] = useState<ServerResponseByHostType>({});
+  const [statuses, setStatuses] = useState<{ [host: string]: HostStatus }>({});
   const [jsonMessage, setJsonMessage] = useState<{ [key: string]: string }>();
 
   const serversGroupedByHost: ServerGroupedByHostResponseType = servers;
@@ -30,7 +33,18 @@ export default function useSocket(options = {}) {
     onClose: () => console.info("WebSocket connection closed."),
     shouldReconnect: (closeEvent) => true,
     onMessage: (event: WebSocketEventMap["message"]) => {
-      const newMessage: ServerResponseType = JSON.parse(event.data);
+      const received: ServerResponseType | HostStatusResponseType = JSON.parse(
+        event.data
+      );
+      // statuses of hosts are sent after every poll of their metrics
+      if (received.Status) {
+        setStatuses((current) => ({
+          ...current,
+          [received.Message.Host]: received.Message,
+        }));
+        return;
+      }
+      const newMessage: ServerResponseType = received;
 
       const newMessageGroupedByHost: ServerGroupedByHostResponseType = [
         newMessage,
@@ -78,6 +92,7 @@ export default function useSocket(options = {}) {
     connectionStatus,
     setJsonMessage,
     servers,
+    statuses,
     serversGroupedByHost,
     servicesGroupedByName,
   };
@@ -1,3 +1,5 @@
+import ThemeConfig from "ThemeConfig";
+
 export const ServerNameEnum = {
   DISK: "disk",
   DOCKER: "docker",
@@ -8,3 +10,11 @@ export const ServerNameEnum = {
   TCP: "tcp",
   CUSTOM:'custom'
 };
+
+// HostStateColor : color of every host state in the server list
+export const HostStateColor = {
+  up: ThemeConfig.palette.success.main,
+  degraded: ThemeConfig.palette.warning.main,
+  down: ThemeConfig.palette.error.main,
+  unknown: ThemeConfig.palette.grey[500],
+};
@@ -19,9 +19,11 @@ export default function ServerDetailServicesTabPanelProcess(
 ) {
   const {
     serverData: {
-      Message: { Data: data },
+      Message: { Data: data, Units: units },
     },
   } = props;
+  const rssUnit = units?.RSS ?? "KB";
+  const columns = React.useMemo(() => getColumns(rssUnit), [rssUnit]);
   const tableInstance = useTable({
     data,
     columns,
@@ -49,18 +51,39 @@ export default function ServerDetailServicesTabPanelProcess(
   );
 }
 
-const columns = [
+// getColumns : RSS is labelled with the unit sent along with the values
+const getColumns = (rssUnit: string) => [
   {
-    header: "SessionName",
-    accessorKey: "SessionName",
+    header: "Pid",
+    accessorKey: "Pid",
+  },
+  {
+    header: "User",
+    accessorKey: "User",
   },
   {
-    header: "Memory",
+    header: "CPU %",
+    accessorKey: "CPU",
+  },
+  {
+    header: "Memory %",
     accessorKey: "Memory",
   },
   {
-    header: "Pid",
-    accessorKey: "Pid",
+    header: `RSS (${rssUnit})`,
+    accessorKey: "RSS",
+  },
+  {
+    header: "Time (s)",
+    accessorKey: "Time",
+  },
+  {
+    header: "TTY",
+    accessorKey: "TTY",
+  },
+  {
+    header: "Count",
+    accessorKey: "Count",
   },
   {
     header: "Command",
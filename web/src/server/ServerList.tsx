@@ -5,15 +5,18 @@ import { useNavigate } from "react-router-dom";
 import { ReactComponent as ServerIcon } from "assets/svg/server.svg";
 import LoadingContent from "common/LoadingContent";
 import ThemeConfig from "ThemeConfig";
-import { ServerGroupedByHostResponseType } from "./ServerType";
+import { HostStatus, ServerGroupedByHostResponseType } from "./ServerType";
+import { HostStateColor } from "./ServerConstant";
 import AppHeader from "AppHeader";
 
 export default function ServerList({
   servers,
+  statuses,
   connectionStatus,
   setJsonMessage,
 }: {
   servers: ServerGroupedByHostResponseType;
+  statuses: { [host: string]: HostStatus };
   connectionStatus: string;
   setJsonMessage: (arg0: any) => void;
 }) {
@@ -66,6 +69,20 @@ export default function ServerList({
                         </span>
                       </>
                     </Typography>
+                    {statuses[serverHost] && (
+                      <Typography
+                        textTransform={"uppercase"}
+                        fontWeight={600}
+                        variant="caption"
+                        title={statuses[serverHost].Error}
+                        style={{
+                          color: HostStateColor[statuses[serverHost].State],
+                        }}
+                      >
+                        {statuses[serverHost].State}
+                        {statuses[serverHost].Flapping && " (flapping)"}
+                      </Typography>
+                    )}
                   </Box>
                 </CardActionArea>
               </Card>
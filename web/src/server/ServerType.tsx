@@ -16,14 +16,44 @@ export type ServerResponseMessageData =
   | LoadingAvgData
   | TCPData;
 
+export type HostState = "unknown" | "up" | "degraded" | "down";
+
+export interface HostStatus {
+  Host: string;
+  State: HostState;
+  LastPoll?: string;
+  LastSuccess?: string;
+  ConsecutiveFailures: number;
+  FailedMetrics?: string[];
+  Flapping: boolean;
+  Error?: string;
+  History?: Array<{
+    From: HostState;
+    To: HostState;
+    At: string;
+    Error?: string;
+  }>;
+  Source?: string;
+}
+
+export interface HostStatusResponseType {
+  Error: boolean;
+  Status: true;
+  Message: HostStatus;
+}
+
 export interface ServerResponseType<T = ServerResponseMessageData> {
   Error: boolean;
+  Status?: false;
   Message: {
     Host: string;
     Error?: string;
     Name: ServerServiceNameType;
     Platform: "Windows" | "Linux" | "Darwin" | "MacOS";
     Data: T;
+    Units?: { [field: string]: string };
+    Time?: string;
+    Duration?: number;
   };
 }
 
@@ -84,10 +114,15 @@ export interface TCPData {
 }
 
 export interface ProcessData {
+  Command: string;
+  User: string;
   Pid: number;
+  CPU: number;
   Memory: number;
-  SessionName: string;
-  Command: string;
+  RSS: number;
+  Time: number;
+  TTY: string;
+  Count: number;
 }
 
 export interface ServerGroupedByHostResponseType {